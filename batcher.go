@@ -0,0 +1,166 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Batcher groups individual items into batches which are flushed when either
+// the maximum batch size is reached or the maximum delay has elapsed since the
+// first item of the batch was added. Flushes are executed on a Queue so a
+// slow flush function applies backpressure to Add, and run in a shared Pool
+// so they count against its limit with mapping functions using it.
+type Batcher[T any] struct {
+	mu       sync.Mutex
+	q        *Queue[[]T]
+	pool     *Pool
+	batch    []T
+	maxSize  int
+	maxDelay time.Duration
+	timer    *time.Timer
+	gen      int
+	closed   bool
+	pending  sync.WaitGroup // batches taken but not yet submitted
+	err      error          // errors of flushes not yet returned
+}
+
+// NewBatcher returns a Batcher calling fn with batches of up to maxBatchSize
+// items in one of workers goroutines, running in the given Pool, or without a
+// limit if the pool is nil. A non-positive maxDelay disables time based
+// flushing.
+func NewBatcher[T any](maxBatchSize int, maxDelay time.Duration, workers int, pool *Pool, fn func([]T) error) *Batcher[T] {
+	if maxBatchSize < 1 {
+		maxBatchSize = 1
+	}
+	b := &Batcher[T]{
+		pool:     pool,
+		maxSize:  maxBatchSize,
+		maxDelay: maxDelay,
+	}
+	b.q = NewQueue(workers, workers, func(batch []T) {
+		b.flush(fn, batch)
+	})
+	return b
+}
+
+// Add an item to the current batch. Flushes run in the background, so the
+// errors of failed flushes, or a PanicError if fn panics, are returned joined
+// by the next call to Add, Flush or Close.
+func (b *Batcher[T]) Add(v T) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrQueueClosed
+	}
+	err := b.err
+	b.err = nil
+	b.batch = append(b.batch, v)
+	if len(b.batch) == 1 && b.maxDelay > 0 && b.maxSize > 1 {
+		gen := b.gen
+		b.timer = time.AfterFunc(b.maxDelay, func() {
+			b.flushGen(gen)
+		})
+	}
+	if len(b.batch) < b.maxSize {
+		b.mu.Unlock()
+		return err
+	}
+	batch := b.take()
+	b.mu.Unlock()
+	b.submit(batch)
+	return err
+}
+
+// Flush the current batch immediately, if any, returning the errors of
+// earlier flushes as by Add. ErrQueueClosed is returned once the Batcher is
+// closed.
+func (b *Batcher[T]) Flush() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrQueueClosed
+	}
+	err := b.err
+	b.err = nil
+	batch := b.take()
+	b.mu.Unlock()
+	b.submit(batch)
+	return err
+}
+
+// Close flushes any remaining items and waits for all flushes to complete.
+// The errors of flushes not yet returned by Add or Flush, including the
+// final flush, are returned.
+func (b *Batcher[T]) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	batch := b.take()
+	b.mu.Unlock()
+	b.submit(batch)
+	b.pending.Wait() // batches taken before close
+	b.q.Close()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.err
+	b.err = nil
+	return err
+}
+
+func (b *Batcher[T]) flushGen(gen int) {
+	b.mu.Lock()
+	if gen != b.gen {
+		b.mu.Unlock()
+		return // batch was already flushed
+	}
+	batch := b.take()
+	b.mu.Unlock()
+	b.submit(batch)
+}
+
+// submit a batch returned by take to the queue, if not empty. The queue is
+// closed only once all taken batches are submitted, so this cannot fail.
+func (b *Batcher[T]) submit(batch []T) {
+	defer b.pending.Done()
+	if len(batch) > 0 {
+		_ = b.q.Submit(batch)
+	}
+}
+
+// flush a batch by fn in the pool, recording its error.
+func (b *Batcher[T]) flush(fn func([]T) error, batch []T) {
+	start, _ := b.pool.begin(context.Background()) // never cancelled
+	err := protect(func() error {
+		return fn(batch)
+	})
+	if err != nil {
+		b.mu.Lock()
+		if b.err == nil {
+			b.err = err
+		} else {
+			b.err = errors.Join(b.err, err)
+		}
+		b.mu.Unlock()
+	}
+	b.pool.end(start)
+}
+
+// take the current batch, which must be passed to submit, must be called
+// with lock held.
+func (b *Batcher[T]) take() []T {
+	b.pending.Add(1)
+	batch := b.batch
+	b.batch = nil
+	b.gen++
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return batch
+}
@@ -0,0 +1,143 @@
+package goroutines
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		delay   time.Duration
+		add     int
+		sleep   time.Duration
+		batches []int
+	}{
+		{
+			name:    "flush by size",
+			size:    3,
+			delay:   time.Second,
+			add:     9,
+			batches: []int{3, 3, 3},
+		},
+		{
+			name:    "flush by delay",
+			size:    100,
+			delay:   20 * time.Millisecond,
+			add:     5,
+			sleep:   50 * time.Millisecond,
+			batches: []int{5},
+		},
+		{
+			name:    "flush remainder on close",
+			size:    4,
+			delay:   time.Second,
+			add:     6,
+			batches: []int{4, 2},
+		},
+		{
+			name:    "batch of one",
+			size:    0,
+			delay:   time.Second,
+			add:     2,
+			batches: []int{1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var sizes []int
+			b := NewBatcher(tt.size, tt.delay, 1, nil, func(batch []int) error {
+				mu.Lock()
+				sizes = append(sizes, len(batch))
+				mu.Unlock()
+				return nil
+			})
+			for i := 0; i < tt.add; i++ {
+				if err := b.Add(i); err != nil {
+					t.Fatalf("Unexpected error=%v", err)
+				}
+			}
+			time.Sleep(tt.sleep)
+			if err := b.Close(); err != nil {
+				t.Errorf("Expected error=%v but received error=%v", nil, err)
+			}
+			if err := b.Add(0); err != ErrQueueClosed {
+				t.Errorf("Expected error=%v but received error=%v", ErrQueueClosed, err)
+			}
+			if err := b.Flush(); err != ErrQueueClosed {
+				t.Errorf("Expected error=%v but received error=%v", ErrQueueClosed, err)
+			}
+			if len(sizes) != len(tt.batches) {
+				t.Fatalf("Expected batches=%v but received batches=%v", tt.batches, sizes)
+			}
+			for i := range sizes {
+				if sizes[i] != tt.batches[i] {
+					t.Errorf("Expected batches=%v but received batches=%v", tt.batches, sizes)
+				}
+			}
+		})
+	}
+}
+
+func TestBatcherCloseRace(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		var mu sync.Mutex
+		var n int
+		b := NewBatcher(10, time.Millisecond, 1, nil, func(batch []int) error {
+			mu.Lock()
+			n += len(batch)
+			mu.Unlock()
+			return nil
+		})
+		if err := b.Add(i); err != nil {
+			t.Fatalf("Unexpected error=%v", err)
+		}
+		time.Sleep(time.Duration(i%3) * 500 * time.Microsecond)
+		b.Close()
+
+		mu.Lock()
+		if n != 1 {
+			t.Errorf("Expected items=%v but received items=%v", 1, n)
+		}
+		mu.Unlock()
+	}
+}
+
+func TestBatcherError(t *testing.T) {
+	pool := NewPool(1)
+	b := NewBatcher(2, time.Hour, 2, pool, func(batch []int) error {
+		for _, n := range batch {
+			if n < 0 {
+				return testErr
+			}
+		}
+		return nil
+	})
+	if err := b.Add(-1); err != nil {
+		t.Fatalf("Unexpected error=%v", err)
+	}
+	if err := b.Flush(); err != nil {
+		t.Fatalf("Unexpected error=%v", err)
+	}
+	for pool.Completed() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := b.Add(1); err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+	if err := b.Add(-2); err != nil {
+		t.Errorf("Expected error=%v but received error=%v", nil, err)
+	}
+	if err := b.Close(); err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+	if n := pool.Completed(); n != 2 {
+		t.Errorf("Expected flushes in pool=%v but received flushes=%v", 2, n)
+	}
+	if err := b.Close(); err != nil {
+		t.Errorf("Expected error=%v but received error=%v", nil, err)
+	}
+}
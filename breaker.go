@@ -0,0 +1,213 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen occurs when a CircuitBreaker rejects a call
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed allows all calls.
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen rejects all calls until the cooldown has elapsed.
+	CircuitOpen
+
+	// CircuitHalfOpen allows a limited number of trial calls.
+	CircuitHalfOpen
+)
+
+// String returns the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerPolicy configures when a CircuitBreaker trips and recovers.
+type BreakerPolicy struct {
+	// FailureRate [0, 1] within the window which trips the breaker, 0.5
+	// when zero.
+	FailureRate float64
+
+	// Window is the number of most recent calls used to compute the failure
+	// rate, 20 when zero.
+	Window int
+
+	// MinCalls in the window before the failure rate is evaluated, equal to
+	// Window when zero.
+	MinCalls int
+
+	// Cooldown is how long the breaker stays open before trial calls.
+	Cooldown time.Duration
+
+	// TrialCalls is the number of successful half-open calls required to
+	// close the breaker, one when zero.
+	TrialCalls int
+
+	// IsFailure reports if an error counts as a failure, all non-nil errors
+	// are failures when nil.
+	IsFailure func(error) bool
+}
+
+// CircuitBreaker wraps a function and fails fast with ErrCircuitOpen when
+// the function fails too frequently, giving the backend time to recover.
+type CircuitBreaker[T any] struct {
+	mu       sync.Mutex
+	fn       func(context.Context) (T, error)
+	policy   BreakerPolicy
+	state    CircuitState
+	gen      int
+	results  []bool // ring buffer of recent failures
+	next     int
+	calls    int
+	failures int
+	opened   time.Time
+	trials   int
+	passed   int
+}
+
+// NewCircuitBreaker wraps the given function with a circuit breaker.
+func NewCircuitBreaker[T any](fn func(context.Context) (T, error), policy BreakerPolicy) *CircuitBreaker[T] {
+	if policy.FailureRate <= 0 {
+		policy.FailureRate = 0.5
+	}
+	if policy.Window <= 0 {
+		policy.Window = 20
+	}
+	if policy.MinCalls <= 0 || policy.MinCalls > policy.Window {
+		policy.MinCalls = policy.Window
+	}
+	if policy.TrialCalls <= 0 {
+		policy.TrialCalls = 1
+	}
+	return &CircuitBreaker[T]{
+		fn:      fn,
+		policy:  policy,
+		results: make([]bool, policy.Window),
+	}
+}
+
+// Run the function unless the breaker is open.
+func (cb *CircuitBreaker[T]) Run() (T, error) {
+	return cb.RunWithContext(context.Background())
+}
+
+// RunWithContext runs the function with a context unless the breaker is open.
+// A panic of the function is recorded as a failure and re-raised.
+func (cb *CircuitBreaker[T]) RunWithContext(ctx context.Context) (T, error) {
+	gen, err := cb.allow()
+	if err != nil {
+		v := new(T)
+		return *v, err
+	}
+	failed := true
+	defer func() {
+		cb.record(gen, failed)
+	}()
+	v, err := cb.fn(ctx)
+	failed = err != nil && (cb.policy.IsFailure == nil || cb.policy.IsFailure(err))
+	return v, err
+}
+
+// State returns the current state of the breaker.
+func (cb *CircuitBreaker[T]) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == CircuitOpen && time.Since(cb.opened) >= cb.policy.Cooldown {
+		return CircuitHalfOpen
+	}
+	return cb.state
+}
+
+// Reset closes the breaker and clears all recorded calls.
+func (cb *CircuitBreaker[T]) Reset() {
+	cb.mu.Lock()
+	cb.setState(CircuitClosed)
+	cb.mu.Unlock()
+}
+
+func (cb *CircuitBreaker[T]) allow() (int, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == CircuitOpen {
+		if time.Since(cb.opened) < cb.policy.Cooldown {
+			return 0, ErrCircuitOpen
+		}
+		cb.setState(CircuitHalfOpen)
+	}
+	if cb.state == CircuitHalfOpen {
+		if cb.trials >= cb.policy.TrialCalls {
+			return 0, ErrCircuitOpen
+		}
+		cb.trials++
+	}
+	return cb.gen, nil
+}
+
+func (cb *CircuitBreaker[T]) record(gen int, failed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if gen != cb.gen {
+		return // state changed while running
+	}
+
+	if cb.state == CircuitHalfOpen {
+		if failed {
+			cb.setState(CircuitOpen)
+			return
+		}
+		cb.passed++
+		if cb.passed >= cb.policy.TrialCalls {
+			cb.setState(CircuitClosed)
+		}
+		return
+	}
+
+	if cb.calls == len(cb.results) {
+		if cb.results[cb.next] {
+			cb.failures--
+		}
+	} else {
+		cb.calls++
+	}
+	cb.results[cb.next] = failed
+	cb.next = (cb.next + 1) % len(cb.results)
+	if failed {
+		cb.failures++
+	}
+
+	if cb.calls >= cb.policy.MinCalls && float64(cb.failures)/float64(cb.calls) >= cb.policy.FailureRate {
+		cb.setState(CircuitOpen)
+	}
+}
+
+// setState transitions and resets counters, must be called with lock held.
+func (cb *CircuitBreaker[T]) setState(s CircuitState) {
+	cb.state = s
+	cb.gen++
+	cb.trials = 0
+	cb.passed = 0
+	if s == CircuitOpen {
+		cb.opened = time.Now()
+	}
+	if s == CircuitClosed {
+		for i := range cb.results {
+			cb.results[i] = false
+		}
+		cb.next, cb.calls, cb.failures = 0, 0, 0
+	}
+}
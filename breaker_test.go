@@ -0,0 +1,113 @@
+package goroutines
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var fail bool
+	cb := NewCircuitBreaker(func(_ context.Context) (string, error) {
+		if fail {
+			return "", testErr
+		}
+		return "foo", nil
+	}, BreakerPolicy{
+		FailureRate: 0.5,
+		Window:      4,
+		Cooldown:    50 * time.Millisecond,
+		TrialCalls:  2,
+	})
+
+	tests := []struct {
+		name      string
+		fail      bool
+		sleep     time.Duration
+		expectErr error
+		state     CircuitState
+	}{
+		{name: "closed success", expectErr: nil, state: CircuitClosed},
+		{name: "closed failure", fail: true, expectErr: testErr, state: CircuitClosed},
+		{name: "closed second failure", fail: true, expectErr: testErr, state: CircuitClosed},
+		{name: "trips on rate", fail: false, expectErr: nil, state: CircuitOpen},
+		{name: "open rejects", fail: false, expectErr: ErrCircuitOpen, state: CircuitOpen},
+		{name: "half open trial", sleep: 60 * time.Millisecond, expectErr: nil, state: CircuitHalfOpen},
+		{name: "half open closes", expectErr: nil, state: CircuitClosed},
+		{name: "closed after recovery", expectErr: nil, state: CircuitClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			time.Sleep(tt.sleep)
+			fail = tt.fail
+			v, err := cb.Run()
+			if err != tt.expectErr {
+				t.Errorf("Expected error=%v but received error=%v", tt.expectErr, err)
+			}
+			if err == nil && v != "foo" {
+				t.Errorf("Expected foo received=%v", v)
+			}
+			if s := cb.State(); s != tt.state {
+				t.Errorf("Expected state=%v but received state=%v", tt.state, s)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpenFailure(t *testing.T) {
+	cb := NewCircuitBreaker(func(_ context.Context) (int, error) {
+		return 0, testErr
+	}, BreakerPolicy{Window: 1, Cooldown: 10 * time.Millisecond})
+
+	if _, err := cb.Run(); err != testErr {
+		t.Fatalf("Expected error=%v but received error=%v", testErr, err)
+	}
+	if s := cb.State(); s != CircuitOpen {
+		t.Fatalf("Expected state=%v but received state=%v", CircuitOpen, s)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := cb.Run(); err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+	if _, err := cb.Run(); err != ErrCircuitOpen {
+		t.Errorf("Expected error=%v but received error=%v", ErrCircuitOpen, err)
+	}
+	cb.Reset()
+	if s := cb.State(); s != CircuitClosed {
+		t.Errorf("Expected state=%v but received state=%v", CircuitClosed, s)
+	}
+}
+
+func TestCircuitBreakerHalfOpenPanic(t *testing.T) {
+	var panics bool
+	cb := NewCircuitBreaker(func(_ context.Context) (int, error) {
+		if panics {
+			panic("test panic")
+		}
+		return 0, testErr
+	}, BreakerPolicy{Window: 1, Cooldown: 10 * time.Millisecond})
+
+	if _, err := cb.Run(); err != testErr {
+		t.Fatalf("Expected error=%v but received error=%v", testErr, err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	panics = true
+	func() {
+		defer func() {
+			if r := recover(); r != "test panic" {
+				t.Errorf("Expected panic=%v but received panic=%v", "test panic", r)
+			}
+		}()
+		_, _ = cb.Run()
+	}()
+	if s := cb.State(); s != CircuitOpen {
+		t.Errorf("Expected panicked trial to open breaker but received state=%v", s)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	panics = false
+	if _, err := cb.Run(); err != testErr {
+		t.Errorf("Expected trial call with error=%v but received error=%v", testErr, err)
+	}
+}
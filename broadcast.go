@@ -0,0 +1,166 @@
+package goroutines
+
+import (
+	"sync"
+)
+
+type subscriber[T any] struct {
+	mu     sync.RWMutex
+	c      chan T
+	done   chan struct{}
+	once   sync.Once
+	closed bool
+}
+
+// stop unblocks publishers waiting on the subscriber.
+func (s *subscriber[T]) stop() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+// close the subscriber channel once no publisher is sending to it.
+func (s *subscriber[T]) close() {
+	s.stop()
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.c)
+	}
+	s.mu.Unlock()
+}
+
+// Broadcaster delivers each published value to every subscriber. Each
+// subscriber has its own buffer, and the overflow policy determines what
+// happens when a slow subscriber's buffer is full.
+type Broadcaster[T any] struct {
+	mu     sync.RWMutex
+	subs   map[*subscriber[T]]struct{}
+	buffer int
+	policy OverflowPolicy
+	clone  func(T) T
+	closed bool
+}
+
+// NewBroadcaster returns a Broadcaster with the given per-subscriber buffer.
+func NewBroadcaster[T any](buffer int) *Broadcaster[T] {
+	if buffer < 0 {
+		buffer = 0
+	}
+	return &Broadcaster[T]{
+		subs:   make(map[*subscriber[T]]struct{}),
+		buffer: buffer,
+	}
+}
+
+// WithOverflow sets the policy for subscribers with full buffers. With
+// OverflowBlock Publish waits for slow subscribers, OverflowDropOldest
+// discards the oldest buffered value, and OverflowReject discards the
+// published value for that subscriber.
+func (b *Broadcaster[T]) WithOverflow(policy OverflowPolicy) *Broadcaster[T] {
+	b.mu.Lock()
+	b.policy = policy
+	b.mu.Unlock()
+	return b
+}
+
+// WithClone copies each published value for each subscriber with the given
+// function, so subscribers may mutate values without affecting each other.
+func (b *Broadcaster[T]) WithClone(clone func(T) T) *Broadcaster[T] {
+	b.mu.Lock()
+	b.clone = clone
+	b.mu.Unlock()
+	return b
+}
+
+// Subscribe returns a channel receiving published values and a function to
+// cancel the subscription. The channel is closed on cancel or Close.
+func (b *Broadcaster[T]) Subscribe() (<-chan T, func()) {
+	s := &subscriber[T]{
+		c:    make(chan T, b.buffer),
+		done: make(chan struct{}),
+	}
+	b.mu.Lock()
+	if b.closed {
+		s.close()
+	} else {
+		b.subs[s] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	return s.c, func() {
+		s.close()
+		b.mu.Lock()
+		delete(b.subs, s)
+		b.mu.Unlock()
+	}
+}
+
+// Publish a value to all subscribers.
+func (b *Broadcaster[T]) Publish(v T) {
+	b.mu.RLock()
+	policy, clone := b.policy, b.clone
+	subs := make([]*subscriber[T], 0, len(b.subs))
+	for s := range b.subs {
+		subs = append(subs, s)
+	}
+	b.mu.RUnlock()
+
+	for _, s := range subs {
+		s.mu.RLock()
+		if !s.closed && clone != nil {
+			s.send(policy, clone(v))
+		} else if !s.closed {
+			s.send(policy, v)
+		}
+		s.mu.RUnlock()
+	}
+}
+
+// send a value, must be called with read lock held.
+func (s *subscriber[T]) send(policy OverflowPolicy, v T) {
+	switch policy {
+	case OverflowBlock:
+		select {
+		case s.c <- v:
+		case <-s.done:
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case s.c <- v:
+				return
+			default:
+			}
+			select {
+			case <-s.c:
+			default:
+			}
+		}
+	default:
+		select {
+		case s.c <- v:
+		default:
+		}
+	}
+}
+
+// Len returns the number of subscribers.
+func (b *Broadcaster[T]) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// Close all subscriptions.
+func (b *Broadcaster[T]) Close() {
+	b.mu.Lock()
+	b.closed = true
+	subs := b.subs
+	b.subs = make(map[*subscriber[T]]struct{})
+	b.mu.Unlock()
+
+	for s := range subs {
+		s.close()
+	}
+}
@@ -0,0 +1,108 @@
+package goroutines
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBroadcaster(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy OverflowPolicy
+		expect []int
+	}{
+		{
+			name:   "drop oldest",
+			policy: OverflowDropOldest,
+			expect: []int{3, 4},
+		},
+		{
+			name:   "drop newest",
+			policy: OverflowReject,
+			expect: []int{0, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBroadcaster[int](2).WithOverflow(tt.policy)
+			c1, cancel1 := b.Subscribe()
+			c2, cancel2 := b.Subscribe()
+			defer cancel2()
+			for i := 0; i < 5; i++ {
+				b.Publish(i)
+			}
+			cancel1()
+			cancel1() // idempotent
+			if n := b.Len(); n != 1 {
+				t.Errorf("Expected subscribers=%v but received subscribers=%v", 1, n)
+			}
+			for _, c := range []<-chan int{c1, c2} {
+				var got []int
+				for i := 0; i < 2; i++ {
+					got = append(got, <-c)
+				}
+				if got[0] != tt.expect[0] || got[1] != tt.expect[1] {
+					t.Errorf("Expected values=%v but received values=%v", tt.expect, got)
+				}
+			}
+			if _, ok := <-c1; ok {
+				t.Errorf("Expected cancelled subscription to be closed")
+			}
+		})
+	}
+}
+
+func TestBroadcasterBlock(t *testing.T) {
+	b := NewBroadcaster[string](0)
+	c, cancel := b.Subscribe()
+	slow, cancelSlow := b.Subscribe()
+	_ = slow
+
+	done := make(chan struct{})
+	go func() {
+		b.Publish("foo")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatalf("Expected publish to block on subscribers")
+	case <-time.After(20 * time.Millisecond):
+	}
+	cancelSlow() // unblocks publisher waiting on slow subscriber
+	if v := <-c; v != "foo" {
+		t.Errorf("Expected foo received=%v", v)
+	}
+	<-done
+
+	b.Close()
+	if _, ok := <-c; ok {
+		t.Errorf("Expected closed subscription")
+	}
+	cancel()
+	late, _ := b.Subscribe()
+	if _, ok := <-late; ok {
+		t.Errorf("Expected closed subscription after Close")
+	}
+}
+
+func TestBroadcasterClone(t *testing.T) {
+	b := NewBroadcaster[[]int](1).WithClone(func(v []int) []int {
+		return append([]int(nil), v...)
+	})
+	c1, cancel1 := b.Subscribe()
+	defer cancel1()
+	c2, cancel2 := b.Subscribe()
+	defer cancel2()
+
+	v := []int{1, 2, 3}
+	b.Publish(v)
+	v[0] = 42
+	for _, c := range []<-chan []int{c1, c2} {
+		got := <-c
+		if got[0] != 1 {
+			t.Errorf("Expected unmodified value but received value=%v", got)
+		}
+		got[0] = 7
+	}
+}
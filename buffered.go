@@ -0,0 +1,18 @@
+package goroutines
+
+import "context"
+
+// MapErrBuffered is MapErr but limits the size of completed results held
+// while waiting on earlier results or the consumer. New arguments are not
+// dispatched while the size of held results, and running functions by the
+// average result size, would exceed n. The limit is soft, as the size of a
+// result is not known until it completes. Results with an error have no size,
+// and unordered results are not held, so are not limited.
+func MapErrBuffered[I any, R any](qlen int, n int, sizeOf func(R) int, fn func(I) (R, error), args []I, opts ...Option) func() (R, error, bool) {
+	return MapErrBufferedWithContext(context.Background(), qlen, n, sizeOf, fn, args, opts...)
+}
+
+// MapErrBufferedWithContext is MapErrBuffered but with a context.
+func MapErrBufferedWithContext[I any, R any](ctx context.Context, qlen int, n int, sizeOf func(R) int, fn func(I) (R, error), args []I, opts ...Option) func() (R, error, bool) {
+	return mapErr(ctx, qlen, fn, sliceSource(args), newOptions(opts), &hooks[I, R]{sizeOf: sizeOf, maxBuffered: n})
+}
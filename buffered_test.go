@@ -0,0 +1,49 @@
+package goroutines
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMapErrBuffered(t *testing.T) {
+	tests := []struct {
+		name    string
+		limited bool
+		limit   int64
+	}{
+		{name: "unlimited", limit: int64(len(testInts))},
+		{name: "limited", limited: true, limit: 3},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var held, peak atomic.Int64
+			fn := func(n int) ([]byte, error) {
+				if h := held.Add(1); h > peak.Load() {
+					peak.Store(h)
+				}
+				return []byte{byte(n)}, nil
+			}
+			var next func() ([]byte, error, bool)
+			if tt.limited {
+				next = MapErrBuffered(8, 2, func(b []byte) int { return len(b) }, fn, testInts)
+			} else {
+				next = MapErr(8, fn, testInts)
+			}
+			i := 0
+			for v, err, ok := next(); ok; v, err, ok = next() {
+				if err != nil || int(v[0]) != testInts[i] {
+					t.Fatalf("Expected result=%v but received result=%v error=%v", testInts[i], v, err)
+				}
+				i++
+				time.Sleep(time.Millisecond)
+				held.Add(-1)
+			}
+			if n := peak.Load(); n > tt.limit || !tt.limited && n <= 3 {
+				t.Errorf("Expected peak held results within=%v but received peak=%v", tt.limit, n)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time and timers, so tests may substitute a
+// FakeClock for real time.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single event timer created by a Clock. See time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// RealClock is the Clock of the time package.
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.t.Stop()
+}
+
+// FakeClock is a Clock which only advances when told to.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers map[*fakeTimer]struct{}
+}
+
+// NewFakeClock returns a FakeClock starting at the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{
+		now:    now,
+		timers: make(map[*fakeTimer]struct{}),
+	}
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer returns a Timer which fires once the clock is advanced by d.
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{
+		clock: c,
+		c:     make(chan time.Time, 1),
+		at:    c.now.Add(d),
+	}
+	if d <= 0 {
+		t.c <- c.now
+	} else {
+		c.timers[t] = struct{}{}
+	}
+	return t
+}
+
+// Advance the clock by d, firing any expired timers.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for t := range c.timers {
+		if !t.at.After(c.now) {
+			delete(c.timers, t)
+			t.c <- c.now
+		}
+	}
+}
+
+// Timers returns the number of timers which have not fired or stopped,
+// useful to wait until code under test is blocked on the clock.
+func (c *FakeClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	c     chan time.Time
+	at    time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	_, ok := t.clock.timers[t]
+	delete(t.clock.timers, t)
+	return ok
+}
+
+type clockCtx struct {
+	parent   context.Context
+	deadline time.Time
+	done     chan struct{}
+	mu       sync.Mutex
+	err      error
+}
+
+// withClockTimeout is context.WithTimeout using the timers of clock, so a
+// FakeClock may expire the context. RealClock and nil use context.WithTimeout.
+func withClockTimeout(parent context.Context, clock Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if clock == nil || clock == RealClock {
+		return context.WithTimeout(parent, d)
+	}
+	ctx := &clockCtx{parent: parent, deadline: clock.Now().Add(d), done: make(chan struct{})}
+	t := clock.NewTimer(d)
+	go func() {
+		defer t.Stop()
+		select {
+		case <-t.C():
+			ctx.cancel(context.DeadlineExceeded)
+		case <-parent.Done():
+			ctx.cancel(parent.Err())
+		case <-ctx.done:
+		}
+	}()
+	return ctx, func() {
+		ctx.cancel(context.Canceled)
+		t.Stop()
+	}
+}
+
+func (c *clockCtx) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = err
+		close(c.done)
+	}
+}
+
+// Deadline is the earlier of the deadline of the clock and of the parent, as
+// by context.WithDeadline.
+func (c *clockCtx) Deadline() (time.Time, bool) {
+	if d, ok := c.parent.Deadline(); ok && d.Before(c.deadline) {
+		return d, true
+	}
+	return c.deadline, true
+}
+
+func (c *clockCtx) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *clockCtx) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *clockCtx) Value(key any) any {
+	return c.parent.Value(key)
+}
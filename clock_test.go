@@ -0,0 +1,89 @@
+package goroutines
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+	t1 := c.NewTimer(time.Second)
+	t2 := c.NewTimer(2 * time.Second)
+	t3 := c.NewTimer(3 * time.Second)
+	if n := c.Timers(); n != 3 {
+		t.Errorf("Expected timers=%v but received timers=%v", 3, n)
+	}
+	if !t3.Stop() || t3.Stop() {
+		t.Errorf("Expected first stop of pending timer to succeed")
+	}
+
+	c.Advance(time.Second)
+	select {
+	case now := <-t1.C():
+		if !now.Equal(start.Add(time.Second)) {
+			t.Errorf("Expected time=%v but received time=%v", start.Add(time.Second), now)
+		}
+	default:
+		t.Errorf("Expected expired timer to fire")
+	}
+	select {
+	case <-t2.C():
+		t.Errorf("Expected pending timer not to fire")
+	default:
+	}
+	if t1.Stop() {
+		t.Errorf("Expected stop of fired timer to fail")
+	}
+
+	c.Advance(time.Hour)
+	<-t2.C()
+	if n := c.Timers(); n != 0 {
+		t.Errorf("Expected timers=%v but received timers=%v", 0, n)
+	}
+	if now := c.Now(); !now.Equal(start.Add(time.Hour + time.Second)) {
+		t.Errorf("Expected time=%v but received time=%v", start.Add(time.Hour+time.Second), now)
+	}
+}
+
+func TestWithClockTimeout(t *testing.T) {
+	start := time.Now()
+	c := NewFakeClock(start)
+	ctx, cancel := withClockTimeout(context.Background(), c, time.Second)
+	defer cancel()
+	child, cancelChild := context.WithCancel(ctx)
+	defer cancelChild()
+
+	if d, ok := ctx.Deadline(); !ok || !d.Equal(start.Add(time.Second)) {
+		t.Errorf("Expected deadline=%v but received deadline=%v", start.Add(time.Second), d)
+	}
+	if err := ctx.Err(); err != nil {
+		t.Errorf("Expected no error but received error=%v", err)
+	}
+	for c.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.Advance(time.Second)
+	<-child.Done()
+	if err := ctx.Err(); err != context.DeadlineExceeded {
+		t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+	}
+	if err := child.Err(); err != context.DeadlineExceeded {
+		t.Errorf("Expected child error=%v but received error=%v", context.DeadlineExceeded, err)
+	}
+
+	parent, cancelParent := context.WithDeadline(context.Background(), start.Add(time.Millisecond))
+	defer cancelParent()
+	ctx, cancel = withClockTimeout(parent, c, time.Second)
+	if d, ok := ctx.Deadline(); !ok || !d.Equal(start.Add(time.Millisecond)) {
+		t.Errorf("Expected deadline=%v but received deadline=%v", start.Add(time.Millisecond), d)
+	}
+	cancel()
+
+	ctx, cancel = withClockTimeout(context.Background(), c, time.Second)
+	cancel()
+	if err := ctx.Err(); err != context.Canceled || c.Timers() != 0 {
+		t.Errorf("Expected error=%v without timers but received error=%v timers=%v", context.Canceled, err, c.Timers())
+	}
+}
@@ -15,30 +15,117 @@ const (
 var (
 	zeroTime = time.Time{}
 
-	// ErrRunnerTimedout waiting for result
+	// ErrRunnerTimedout waiting for result or running the function
 	ErrRunnerTimedout = errors.New("runner timed out")
+
+	// ErrRunnerStarted occurs when no result is available but a run started
+	ErrRunnerStarted = errors.New("runner started")
 )
 
 // Coalescer is an instance of a coalesced function, ensuring only one
 // invocation is running at a time. Behavior is similar to sync/singleflight
 // with optional caching, and callers may individually abort early.
+//
+// The same result is returned to every caller, and is retained while cached.
+// Callers must not mutate results containing pointers, slices or maps unless
+// the Coalescer copies them for each caller. See WithClone.
 type Coalescer[T any] struct {
 	mu     sync.Mutex
-	fn     func() (T, error)
-	l      []chan *F[T]
+	fn     func(context.Context) (T, error)
+	l      []*waiter[T]
 	state  int
 	gen    int
 	result T
 	ttl    time.Duration
 	grace  time.Duration
 	added  time.Time
+	expire time.Duration // ttl of the cached result
+	stale  bool          // do not cache result of running generation
+	limit  time.Duration // maximum duration of each run
+	maxl   int           // maximum number of waiting callers
+	stats  CoalescerStats
+	err    error // error of the last run
+	hooks  CoalescerHooks[T]
+	alt    func() (T, error) // fallback when the function fails
+	clone  func(T) T         // copy of result for each caller
+	retry  *RetryPolicy
+	lease  Leaser
+	key    string // key of lease
+	subs   *Broadcaster[F[T]]
+	pmu    sync.Mutex // serializes publishing to subs
+	pgen   int        // generation last published to subs
+	seq    uint64     // last assigned version
+	rv     uint64     // version of running generation
+	rver   uint64     // version of cached result
+	window time.Duration
+	last   F[T]      // result of the last run, for window
+	done   time.Time // completion of the last run, for window
+	clock  Clock
+	orphan chan struct{} // closed when a function abandoned by call returns
+}
+
+// CoalescerHooks are optional callbacks around the runs of a Coalescer, such
+// as for logs, metrics or traces. Hooks are called without locks held, so
+// they may call methods of the Coalescer such as Stats.
+type CoalescerHooks[T any] struct {
+	// OnRefreshStart is called before each run of the function.
+	OnRefreshStart func()
+	// OnRefreshEnd is called with the result of each run of the function
+	// before it is delivered to waiting callers.
+	OnRefreshEnd func(v T, err error, dur time.Duration)
+	// OnServeStale is called with the age of a cached result served during
+	// grace.
+	OnServeStale func(age time.Duration)
+	// OnWaiterAbort is called when a waiting caller aborts before receiving
+	// a result.
+	OnWaiterAbort func()
+}
+
+// CoalescerStats are counters of a Coalescer. See Coalescer.Stats.
+type CoalescerStats struct {
+	Hits                uint64        // results served from cache
+	Misses              uint64        // callers which waited on the function
+	StaleServes         uint64        // results served from cache during grace
+	Refreshes           uint64        // completed runs of the function
+	RefreshFailures     uint64        // runs which returned an error
+	Waiting             int           // callers currently waiting
+	LastRefreshDuration time.Duration // duration of the last run
+}
+
+// CoalescerInfo describes the current state of a Coalescer. See
+// Coalescer.Info.
+type CoalescerInfo struct {
+	Running   bool
+	Cached    bool
+	CacheAge  time.Duration // age of the cached result, if any
+	LastError error         // error of the last run, if any
 }
 
 // Coalesce the given function.
 func Coalesce[T any](fn func() (T, error)) *Coalescer[T] {
-	return &Coalescer[T]{
-		fn: fn,
-	}
+	return CacheCoalesce(fn, 0, 0)
+}
+
+// CoalesceContext coalesces the given context aware function. The context is
+// cancelled if the run exceeds the limit set by WithRunTimeout.
+func CoalesceContext[T any](fn func(context.Context) (T, error)) *Coalescer[T] {
+	return CacheCoalesceContext(fn, 0, 0)
+}
+
+// CoalesceWithFallback coalesces the primary function, calling fallback when
+// it fails. See WithFallback.
+func CoalesceWithFallback[T any](primary func() (T, error), fallback func() (T, error)) *Coalescer[T] {
+	return Coalesce(primary).WithFallback(fallback)
+}
+
+// CoalesceWindow coalesces the given function, and calls within window after
+// a run completes receive the result of that run, including any error,
+// without running the function again. Unlike CacheCoalesce results are not
+// retained beyond window.
+func CoalesceWindow[T any](fn func() (T, error), window time.Duration) *Coalescer[T] {
+	qr := Coalesce(fn)
+	qr.window = window
+	return qr
 }
 
 // CacheCoalesce coalesces the given function with result cache ttl/grace.
@@ -48,6 +135,16 @@ func Coalesce[T any](fn func() (T, error)) *Coalescer[T] {
 // refreshing the cached result. If returned error is non-nil then a result
 // will not be cached.
 func CacheCoalesce[T any](fn func() (T, error), ttl time.Duration, grace time.Duration) *Coalescer[T] {
+	if fn == nil {
+		return CacheCoalesceContext[T](nil, ttl, grace)
+	}
+	return CacheCoalesceContext(func(_ context.Context) (T, error) {
+		return fn()
+	}, ttl, grace)
+}
+
+// CacheCoalesceContext is CacheCoalesce for a context aware function.
+func CacheCoalesceContext[T any](fn func(context.Context) (T, error), ttl time.Duration, grace time.Duration) *Coalescer[T] {
 	return &Coalescer[T]{
 		fn:    fn,
 		ttl:   ttl,
@@ -55,6 +152,102 @@ func CacheCoalesce[T any](fn func() (T, error), ttl time.Duration, grace time.Du
 	}
 }
 
+// WithName registers the Coalescer with DefaultRegistry by name.
+func (qr *Coalescer[T]) WithName(name string) *Coalescer[T] {
+	DefaultRegistry.Register(name, qr)
+	return qr
+}
+
+// WithClock sets the Clock used for cache ages, the timeouts of callers, the
+// limit of WithRunTimeout and the backoff of WithRetry, such as a FakeClock in
+// tests. A RetryPolicy with its own Clock keeps it.
+func (qr *Coalescer[T]) WithClock(clock Clock) *Coalescer[T] {
+	qr.mu.Lock()
+	qr.clock = clock
+	qr.mu.Unlock()
+	return qr
+}
+
+// WithRunTimeout limits the duration of each run of the function. When the
+// limit is exceeded the context passed to the function is cancelled, and
+// waiting callers receive ErrRunnerTimedout. A function which ignores its
+// context keeps running, so the next run waits for it to return, within its
+// own limit, and the function never runs concurrently with itself.
+func (qr *Coalescer[T]) WithRunTimeout(limit time.Duration) *Coalescer[T] {
+	qr.mu.Lock()
+	qr.limit = limit
+	qr.mu.Unlock()
+	return qr
+}
+
+// WithRetry retries a failed run of the function according to policy before
+// the error is delivered to waiting callers, which continue waiting during
+// backoff. The limit of WithRunTimeout applies to all attempts and backoff of
+// a run, and attempts are limited to three if policy.MaxAttempts is less than
+// one, so waiting callers are not blocked forever by a failing function.
+func (qr *Coalescer[T]) WithRetry(policy RetryPolicy) *Coalescer[T] {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 3
+	}
+	qr.mu.Lock()
+	qr.retry = &policy
+	qr.mu.Unlock()
+	return qr
+}
+
+// WithLeaser acquires the lease of key before each run of the function, so
+// only one instance of a fleet runs the function at a time. When the lease is
+// held by another instance waiting callers receive the cached result, which
+// may be older than ttl+grace, or ErrLeaseHeld if no result is cached. The
+// function is run without a lease if leaser returns an error.
+func (qr *Coalescer[T]) WithLeaser(leaser Leaser, key string) *Coalescer[T] {
+	qr.mu.Lock()
+	qr.lease = leaser
+	qr.key = key
+	qr.mu.Unlock()
+	return qr
+}
+
+// WithFallback calls fallback, such as a read from a secondary region or a
+// local snapshot, once per run when the function fails. Waiting callers
+// receive the result of fallback, but it is not cached so the next call runs
+// the function again.
+func (qr *Coalescer[T]) WithFallback(fallback func() (T, error)) *Coalescer[T] {
+	qr.mu.Lock()
+	qr.alt = fallback
+	qr.mu.Unlock()
+	return qr
+}
+
+// WithClone copies the result for each caller with the given function, so
+// callers may mutate the result without affecting other callers or the
+// cached result. The function is called with the Coalescer locked, and must
+// not call its methods.
+func (qr *Coalescer[T]) WithClone(clone func(T) T) *Coalescer[T] {
+	qr.mu.Lock()
+	qr.clone = clone
+	qr.mu.Unlock()
+	return qr
+}
+
+// WithHooks sets callbacks around the runs of the Coalescer.
+func (qr *Coalescer[T]) WithHooks(hooks CoalescerHooks[T]) *Coalescer[T] {
+	qr.mu.Lock()
+	qr.hooks = hooks
+	qr.mu.Unlock()
+	return qr
+}
+
+// WithMaxWaiters limits the number of callers waiting on a running function.
+// Additional callers fail fast with ErrQueueFull, shedding load rather than
+// queueing without bound. Callers served from cache are not limited.
+func (qr *Coalescer[T]) WithMaxWaiters(n int) *Coalescer[T] {
+	qr.mu.Lock()
+	qr.maxl = n
+	qr.mu.Unlock()
+	return qr
+}
+
 // UncachedCoalescer wraps a Coalescer and bypasses caching.
 type UncachedCoalescer[T any] struct {
 	qr *Coalescer[T]
@@ -87,6 +280,45 @@ func (qr *Coalescer[T]) TryRun() (T, error) {
 	return qr.run(context.Background(), 0, false)
 }
 
+// TryRunOrStart returns immediately with a result if available, like TryRun.
+// If no result is available and the function was idle, a run is started in
+// the background to warm the cache and ErrRunnerStarted is returned, or
+// ErrRunnerTimedout if the function was already running.
+func (qr *Coalescer[T]) TryRunOrStart() (T, error) {
+	var res F[T]
+	w, err := qr.enqueue(context.Background(), false)
+	if err != nil {
+		res.E = err
+		return res.Return()
+	}
+	select {
+	case res = <-w.r:
+	default:
+		qr.abort(w.gen, w.r)
+		res.E = ErrRunnerTimedout
+		if w.started {
+			res.E = ErrRunnerStarted
+		}
+	}
+	return res.Return()
+}
+
+// StartIfIdle starts a run of the function in the background if it is not
+// running, regardless of any cached result, returning true if a run was
+// started. The result is cached as with Run.
+func (qr *Coalescer[T]) StartIfIdle() bool {
+	if qr.fn == nil {
+		return false
+	}
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+	if qr.state == running {
+		return false
+	}
+	qr.start()
+	return true
+}
+
 // Run or queue for the next result.
 func (qr *Coalescer[T]) Run() (T, error) {
 	return qr.run(context.Background(), -1, false)
@@ -105,111 +337,354 @@ func (qr *Coalescer[T]) RunTimeout(timeout time.Duration) (T, error) {
 	return qr.run(context.Background(), timeout, false)
 }
 
+// RunVersioned runs or queues for the next result, like Run, and returns the
+// version of the result. Versions increase with each result of the function
+// or Prime, so callers may detect a changed result without comparing it.
+func (qr *Coalescer[T]) RunVersioned() (T, uint64, error) {
+	res, w := qr.wait(context.Background(), -1, false)
+	return res.V, w.ver, res.E
+}
+
+// RunWithFreshness runs or queues for the next result, like RunWithContext,
+// and returns the time the result was cached and whether it is fresh. A
+// result served during grace is not fresh, such as to set HTTP Age or
+// Warning headers. A result received from a run is fresh, and the time it
+// was received is returned, unless it is the result of WithFallback. A
+// cached result served while the lease of WithLeaser is held elsewhere is
+// fresh only if it is younger than ttl.
+func (qr *Coalescer[T]) RunWithFreshness(ctx context.Context) (v T, cachedAt time.Time, fresh bool, err error) {
+	res, w := qr.wait(ctx, -1, false)
+	if res.E != nil {
+		return res.V, w.added, false, res.E
+	}
+	return res.V, w.added, !w.stale, nil
+}
+
+// RunMaxAge returns the cached result if it is younger than maxAge, otherwise
+// runs or queues for the next result. Unlike ttl and grace, maxAge applies
+// only to this call.
+func (qr *Coalescer[T]) RunMaxAge(maxAge time.Duration) (T, error) {
+	qr.mu.Lock()
+	if !qr.added.IsZero() && qr.since(qr.added) <= maxAge {
+		defer qr.mu.Unlock()
+		qr.stats.Hits++
+		return qr.copy(qr.result), nil
+	}
+	qr.mu.Unlock()
+	return qr.run(context.Background(), -1, true)
+}
+
+// Subscribe returns a channel which receives the result of each run of the
+// function, independent of callers, and a function to cancel the
+// subscription. Only the latest result is buffered for slow subscribers, and
+// each subscriber receives its own copy of the result. See WithClone.
+func (qr *Coalescer[T]) Subscribe() (<-chan F[T], func()) {
+	qr.mu.Lock()
+	if qr.subs == nil {
+		qr.subs = NewBroadcaster[F[T]](1).WithOverflow(OverflowDropOldest).WithClone(func(res F[T]) F[T] {
+			qr.mu.Lock()
+			defer qr.mu.Unlock()
+			return F[T]{qr.copy(res.V), res.E}
+		})
+	}
+	subs := qr.subs
+	qr.mu.Unlock()
+	return subs.Subscribe()
+}
+
 // NoCache returns the same Coalescer with cache bypass enabled
 func (qr *Coalescer[T]) NoCache() UncachedCoalescer[T] {
 	return UncachedCoalescer[T]{qr}
 }
 
-func (qr *Coalescer[T]) run(ctx context.Context, timeout time.Duration, noCache bool) (T, error) {
-	if qr.fn == nil { // handle uninitialized
+// RunChan runs or queues for the next result, returning a channel which
+// receives the result and a function to abort waiting. The channel may be
+// closed without a result after abort.
+func (qr *Coalescer[T]) RunChan() (<-chan F[T], func()) {
+	w, err := qr.enqueue(context.Background(), false)
+	if err != nil {
 		v := new(T)
-		return *v, nil
+		w.r <- F[T]{*v, err}
+		close(w.r)
 	}
+	return w.r, func() {
+		qr.abort(w.gen, w.r)
+	}
+}
 
-	var gen int
-	qr.mu.Lock()
+func (qr *Coalescer[T]) run(ctx context.Context, timeout time.Duration, noCache bool) (T, error) {
+	res, _ := qr.wait(ctx, timeout, noCache)
+	return res.Return()
+}
 
-	if !noCache && qr.ttl > 0 && time.Since(qr.added) <= qr.ttl {
-		defer qr.mu.Unlock()
-		return qr.result, nil
+// waiter of a caller for a result.
+type waiter[T any] struct {
+	r       chan F[T]
+	gen     int
+	ver     uint64    // version of the result
+	added   time.Time // time the result was cached or delivered by a run
+	stale   bool      // result served during grace or not fresh when delivered
+	started bool      // the run was started by the caller
+}
+
+// wait for a result, returning the waiter it was received by.
+func (qr *Coalescer[T]) wait(ctx context.Context, timeout time.Duration, noCache bool) (F[T], *waiter[T]) {
+	var res F[T]
+	w, err := qr.enqueue(ctx, noCache)
+	if err != nil {
+		res.E = err
+		return res, w
 	}
 
-	if !noCache && qr.grace > 0 && time.Since(qr.added) <= qr.ttl+qr.grace {
-		defer qr.mu.Unlock()
-		if qr.state == running {
-			return qr.result, nil
+	if timeout > 0 {
+		qr.mu.Lock()
+		t := qr.getClock().NewTimer(timeout)
+		qr.mu.Unlock()
+		defer t.Stop()
+		select {
+		case res = <-w.r:
+			return res, w
+		case <-t.C():
+			qr.abort(w.gen, w.r)
+			res.E = ErrRunnerTimedout
+			return res, new(waiter[T])
+		case <-ctx.Done():
+			qr.abort(w.gen, w.r)
+			res.E = ctx.Err()
+			return res, new(waiter[T])
 		}
-
+	} else if timeout == 0 {
 		select {
+		case res = <-w.r:
+			return res, w
 		case <-ctx.Done():
-			v := new(T)
-			return *v, ctx.Err()
+			qr.abort(w.gen, w.r)
+			res.E = ctx.Err()
+			return res, new(waiter[T])
 		default:
+			qr.abort(w.gen, w.r)
+			res.E = ErrRunnerTimedout
+			return res, new(waiter[T])
 		}
+	}
 
-		qr.state = running
-		qr.gen = qr.gen + 1
-		go qr.pump()
-		return qr.result, nil
+	select {
+	case res = <-w.r:
+		return res, w
+	case <-ctx.Done():
+		qr.abort(w.gen, w.r)
+		res.E = ctx.Err()
+		return res, new(waiter[T])
 	}
+}
 
-	r := make(chan *F[T], 1)
-	if qr.state == running {
-		qr.l = append(qr.l, r)
-		gen = qr.gen
-	} else {
-		select {
-		case <-ctx.Done():
-			qr.mu.Unlock()
-			v := new(T)
-			return *v, ctx.Err()
-		default:
+// enqueue returns a waiter which receives a cached result, or the result of
+// the running generation, starting a new generation if needed.
+func (qr *Coalescer[T]) enqueue(ctx context.Context, noCache bool) (*waiter[T], error) {
+	w := &waiter[T]{r: make(chan F[T], 1)}
+	if qr.fn == nil { // handle uninitialized
+		v := new(T)
+		w.r <- F[T]{*v, nil}
+		return w, nil
+	}
+
+	var served func() // hook called after unlock
+	defer func() {
+		if served != nil {
+			served()
 		}
+	}()
 
-		qr.state = running
-		qr.l = append(qr.l, r)
-		qr.gen = qr.gen + 1
-		gen = qr.gen
-		go qr.pump()
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+
+	if !noCache && qr.window > 0 && qr.state != running && !qr.done.IsZero() && qr.since(qr.done) <= qr.window {
+		qr.stats.Hits++
+		w.gen, w.ver, w.added = qr.gen, qr.rv, qr.done
+		w.r <- F[T]{qr.copy(qr.last.V), qr.last.E}
+		return w, nil
 	}
-	qr.mu.Unlock()
 
-	if timeout > 0 {
-		t := time.NewTimer(timeout)
-		select {
-		case v := <-r:
-			return v.Return()
-		case <-t.C:
-			qr.abort(gen, r)
-			v := new(T)
-			return *v, ErrRunnerTimedout
-		case <-ctx.Done():
-			qr.abort(gen, r)
-			v := new(T)
-			return *v, ctx.Err()
+	if !noCache && qr.expire > 0 && qr.since(qr.added) <= qr.expire {
+		qr.stats.Hits++
+		w.gen, w.ver, w.added = qr.gen, qr.rver, qr.added
+		w.r <- F[T]{qr.copy(qr.result), nil}
+		return w, nil
+	}
+
+	if !noCache && qr.grace > 0 && qr.since(qr.added) <= qr.expire+qr.grace {
+		if qr.state != running {
+			select {
+			case <-ctx.Done():
+				return w, ctx.Err()
+			default:
+			}
+
+			qr.start()
 		}
-	} else if timeout == 0 {
+		qr.stats.StaleServes++
+		if fn := qr.hooks.OnServeStale; fn != nil {
+			age := qr.since(qr.added)
+			served = func() { fn(age) }
+		}
+		w.gen, w.ver, w.added, w.stale = qr.gen, qr.rver, qr.added, true
+		w.r <- F[T]{qr.copy(qr.result), nil}
+		return w, nil
+	}
+
+	if qr.state != running {
 		select {
-		case v := <-r:
-			return v.Return()
 		case <-ctx.Done():
-			qr.abort(gen, r)
-			v := new(T)
-			return *v, ctx.Err()
+			return w, ctx.Err()
 		default:
-			qr.abort(gen, r)
-			v := new(T)
-			return *v, ErrRunnerTimedout
 		}
+
+		qr.start()
+		w.started = true
+	} else if qr.maxl > 0 && len(qr.l) >= qr.maxl {
+		return w, ErrQueueFull
 	}
+	qr.stats.Misses++
+	qr.l = append(qr.l, w)
+	w.gen, w.ver = qr.gen, qr.rv
+	return w, nil
+}
+
+// start a new generation, must be called with lock held.
+func (qr *Coalescer[T]) start() {
+	qr.state = running
+	qr.gen = qr.gen + 1
+	qr.seq++
+	qr.rv = qr.seq
+	go qr.pump()
+}
+
+// Flush cached result.
+func (qr *Coalescer[T]) Flush() {
+	qr.mu.Lock()
+	qr.added = zeroTime
+	qr.done = zeroTime
+	qr.mu.Unlock()
+}
+
+// Invalidate flushes the cached result and prevents the result of a running
+// function from being cached. Callers waiting on the running function still
+// receive its result.
+func (qr *Coalescer[T]) Invalidate() {
+	qr.mu.Lock()
+	qr.added = zeroTime
+	qr.done = zeroTime
+	qr.stale = qr.state == running
+	qr.mu.Unlock()
+}
+
+// FlushAndWait invalidates the cached result and waits for a running function
+// to complete, so the next call runs the function again.
+func (qr *Coalescer[T]) FlushAndWait(ctx context.Context) error {
+	qr.mu.Lock()
+	qr.added = zeroTime
+	qr.done = zeroTime
+	if qr.state != running {
+		qr.mu.Unlock()
+		return nil
+	}
+	qr.stale = true
+	w := &waiter[T]{r: make(chan F[T], 1), gen: qr.gen}
+	qr.l = append(qr.l, w)
+	qr.mu.Unlock()
 
 	select {
-	case v := <-r:
-		return v.Return()
+	case <-w.r:
+		return nil
 	case <-ctx.Done():
-		qr.abort(gen, r)
-		v := new(T)
-		return *v, ctx.Err()
+		qr.abort(w.gen, w.r)
+		return ctx.Err()
 	}
 }
 
-// Flush cached result.
-func (qr *Coalescer[T]) Flush() {
+// Prime the cache with a result, such as one loaded from a snapshot at
+// startup, so callers do not wait for the first run. The result is subject to
+// the same ttl and grace as results of the function. Prime has no effect if
+// the Coalescer does not cache results.
+func (qr *Coalescer[T]) Prime(v T) {
 	if qr.ttl > 0 || qr.grace > 0 {
-		qr.mu.Lock()
-		qr.added = zeroTime
-		qr.mu.Unlock()
+		qr.PrimeWithTTL(v, qr.ttl)
+	}
+}
+
+// PrimeWithTTL primes the cache with a result which is fresh for the given
+// ttl, rather than the ttl of the Coalescer, followed by any grace time.
+func (qr *Coalescer[T]) PrimeWithTTL(v T, ttl time.Duration) {
+	qr.mu.Lock()
+	qr.result = v
+	qr.added = qr.now()
+	qr.expire = ttl
+	qr.seq++
+	qr.rver = qr.seq
+	qr.mu.Unlock()
+}
+
+// Peek returns the cached result and the time it was cached, without running
+// the function. The result may be older than ttl+grace. If no result is
+// cached the last return value is false.
+func (qr *Coalescer[T]) Peek() (T, time.Time, bool) {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+	if qr.added.IsZero() {
+		v := new(T)
+		return *v, zeroTime, false
 	}
+	return qr.copy(qr.result), qr.added, true
+}
+
+// Stats returns the counters of the Coalescer.
+func (qr *Coalescer[T]) Stats() CoalescerStats {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+	stats := qr.stats
+	stats.Waiting = len(qr.l)
+	return stats
+}
+
+// Info returns the current state of the Coalescer.
+func (qr *Coalescer[T]) Info() CoalescerInfo {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+	info := CoalescerInfo{
+		Running:   qr.state == running,
+		Cached:    !qr.added.IsZero(),
+		LastError: qr.err,
+	}
+	if info.Cached {
+		info.CacheAge = qr.since(qr.added)
+	}
+	return info
+}
+
+// copy the result for a caller, must be called with lock held.
+func (qr *Coalescer[T]) copy(v T) T {
+	if qr.clone == nil {
+		return v
+	}
+	return qr.clone(v)
+}
+
+// getClock returns the Clock, must be called with lock held.
+func (qr *Coalescer[T]) getClock() Clock {
+	if qr.clock == nil {
+		return RealClock
+	}
+	return qr.clock
+}
+
+// now returns the current time, must be called with lock held.
+func (qr *Coalescer[T]) now() time.Time {
+	return qr.getClock().Now()
+}
+
+// since returns the time elapsed since t, must be called with lock held.
+func (qr *Coalescer[T]) since(t time.Time) time.Duration {
+	return qr.now().Sub(t)
 }
 
 // IsRunning returns true if function is running.
@@ -222,50 +697,224 @@ func (qr *Coalescer[T]) IsRunning() bool {
 }
 
 func (qr *Coalescer[T]) pump() {
-	v, err := qr.fn()
+	qr.mu.Lock()
+	limit := qr.limit
+	hooks := qr.hooks
+	alt := qr.alt
+	retry := qr.retry
+	lease, key := qr.lease, qr.key
+	clock := qr.getClock()
+	qr.mu.Unlock()
+
+	if lease != nil {
+		ok, err := lease.TryAcquire(context.Background(), key)
+		if err == nil && !ok {
+			qr.serveStale()
+			return
+		} else if err == nil {
+			defer lease.Release(context.Background(), key)
+		}
+	}
+
+	if hooks.OnRefreshStart != nil {
+		hooks.OnRefreshStart()
+	}
+
+	start := clock.Now()
+	var v T
+	var err error
+	if retry != nil {
+		policy := *retry
+		if policy.Clock == nil {
+			policy.Clock = clock
+		}
+		ctx := context.Background()
+		if limit > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = withClockTimeout(ctx, clock, limit)
+			defer cancel()
+		}
+		v, err = Retry(ctx, policy, func(ctx context.Context) (T, error) {
+			return qr.invoke(ctx, clock, limit)
+		})
+		if err != nil && ctx.Err() != nil {
+			err = ErrRunnerTimedout
+		}
+	} else {
+		v, err = qr.invoke(context.Background(), clock, limit)
+	}
+	fallback := err != nil && alt != nil
+	if fallback {
+		err = protect(func() (err error) {
+			v, err = alt()
+			return
+		})
+	}
+	dur := clock.Now().Sub(start)
+
+	if hooks.OnRefreshEnd != nil {
+		hooks.OnRefreshEnd(v, err, dur)
+	}
 
 	qr.mu.Lock()
-	defer qr.mu.Unlock()
+	qr.stats.Refreshes++
+	qr.stats.LastRefreshDuration = dur
+	if err != nil {
+		qr.stats.RefreshFailures++
+	}
+	qr.err = err
 
-	if err == nil && (qr.ttl > 0 || qr.grace > 0) {
+	if err == nil && !fallback && !qr.stale && (qr.ttl > 0 || qr.grace > 0) {
 		qr.result = v
-		qr.added = time.Now()
+		qr.added = qr.now()
+		qr.expire = qr.ttl
+		qr.rver = qr.rv
+		if qr.seq != qr.rv { // primed while running
+			qr.seq++
+			qr.rver = qr.seq
+		}
+	}
+	qr.stale = false
+	if qr.window > 0 {
+		qr.last = F[T]{v, err}
+		qr.done = qr.now()
 	}
+	qr.deliver(v, err, qr.now(), fallback)
+	subs, gen := qr.subs, qr.gen
+	qr.mu.Unlock()
 
-	for _, l := range qr.l {
-		l <- NewF(v, err)
-		close(l)
+	if subs != nil {
+		qr.publish(subs, gen, F[T]{v, err})
+	}
+}
+
+// publish the result of generation gen to subscribers, unless the result of a
+// later generation was already published. Results are published without lock
+// held, since subscribers copy results under lock.
+func (qr *Coalescer[T]) publish(subs *Broadcaster[F[T]], gen int, res F[T]) {
+	qr.pmu.Lock()
+	defer qr.pmu.Unlock()
+	if gen <= qr.pgen {
+		return
+	}
+	qr.pgen = gen
+	subs.Publish(res)
+}
+
+// serveStale delivers the cached result, regardless of age, to waiting
+// callers without running the function.
+func (qr *Coalescer[T]) serveStale() {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+
+	qr.stale = false
+	if qr.added.IsZero() {
+		v := new(T)
+		qr.deliver(*v, ErrLeaseHeld, zeroTime, true)
+		return
+	}
+	qr.stats.StaleServes += uint64(len(qr.l))
+	qr.deliver(qr.result, nil, qr.added, qr.since(qr.added) > qr.expire)
+}
+
+// deliver a result to waiting callers, with the time it was cached and
+// whether it is stale, and stop the running generation, must be called with
+// lock held.
+func (qr *Coalescer[T]) deliver(v T, err error, added time.Time, stale bool) {
+	for _, w := range qr.l {
+		w.added, w.stale = added, stale
+		w.r <- F[T]{qr.copy(v), err}
+		close(w.r)
 	}
 	qr.l = qr.l[:0]
 	qr.state = stopped
 }
 
-// Best effort cleanup if client aborts, otherwise GC handles it.
-func (qr *Coalescer[T]) abort(gen int, r chan *F[T]) {
-	if qr.mu.TryLock() {
-		defer qr.mu.Unlock()
-		if gen != qr.gen || len(qr.l) == 0 {
-			return
+// invoke the function once, recovering panics.
+func (qr *Coalescer[T]) invoke(ctx context.Context, clock Clock, limit time.Duration) (v T, err error) {
+	if limit > 0 {
+		return qr.call(ctx, clock, limit)
+	}
+	if orphan := qr.orphaned(); orphan != nil {
+		<-orphan
+	}
+	err = protect(func() (err error) {
+		v, err = qr.fn(ctx)
+		return
+	})
+	return
+}
+
+// call the function in a separate goroutine, abandoning it when the limit is
+// exceeded or the context is done.
+func (qr *Coalescer[T]) call(ctx context.Context, clock Clock, limit time.Duration) (T, error) {
+	ctx, cancel := withClockTimeout(ctx, clock, limit)
+	defer cancel()
+
+	if orphan := qr.orphaned(); orphan != nil {
+		select {
+		case <-orphan:
+		case <-ctx.Done():
+			v := new(T)
+			return *v, ErrRunnerTimedout
 		}
-		if len(qr.l) == 1 && qr.l[0] == r {
-			qr.l = qr.l[:0]
-			close(r)
-		} else if qr.l[len(qr.l)-1] == r {
+	}
+
+	r := make(chan F[T], 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var v T
+		err := protect(func() (err error) {
+			v, err = qr.fn(ctx)
+			return
+		})
+		r <- F[T]{v, err}
+	}()
+
+	select {
+	case res := <-r:
+		return res.Return()
+	case <-ctx.Done():
+		qr.mu.Lock()
+		qr.orphan = done
+		qr.mu.Unlock()
+		v := new(T)
+		return *v, ErrRunnerTimedout
+	}
+}
+
+// orphaned returns a channel closed when the function abandoned by the last
+// call returns, or nil if no function was abandoned.
+func (qr *Coalescer[T]) orphaned() chan struct{} {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+	return qr.orphan
+}
+
+// abort removes the waiter of a caller which stopped waiting, so abandoned
+// waiters do not accumulate while the function is running.
+func (qr *Coalescer[T]) abort(gen int, r chan F[T]) {
+	qr.mu.Lock()
+	if gen != qr.gen {
+		qr.mu.Unlock()
+		return // result already delivered
+	}
+	aborted := false
+	for i, w := range qr.l {
+		if w.r == r {
+			qr.l[i] = qr.l[len(qr.l)-1]
+			qr.l[len(qr.l)-1] = nil
 			qr.l = qr.l[:len(qr.l)-1]
 			close(r)
-		} else {
-			n := -1
-			for i, l := range qr.l {
-				if l == r {
-					n = i
-					break
-				}
-			}
-			if n >= 0 {
-				qr.l[n] = qr.l[len(qr.l)-1]
-				qr.l = qr.l[:len(qr.l)-1]
-				close(r)
-			}
+			aborted = true
+			break
 		}
 	}
+	fn := qr.hooks.OnWaiterAbort
+	qr.mu.Unlock()
+
+	if aborted && fn != nil {
+		fn()
+	}
 }
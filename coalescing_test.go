@@ -2,7 +2,9 @@ package goroutines
 
 import (
 	"context"
+	"errors"
 	"runtime"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -506,22 +508,22 @@ func TestCacheCoalesce(t *testing.T) {
 func TestAbort(t *testing.T) {
 	tests := []struct {
 		name      string
-		e         chan *F[string]
+		e         chan F[string]
 		gen       int
-		cfn       func(chan *F[string]) *Coalescer[string]
+		cfn       func(chan F[string]) *Coalescer[string]
 		expectLen int
 	}{
 		{
 			name: "not the last",
-			e:    make(chan *F[string], 1),
+			e:    make(chan F[string], 1),
 			gen:  2,
-			cfn: func(e chan *F[string]) *Coalescer[string] {
+			cfn: func(e chan F[string]) *Coalescer[string] {
 				return &Coalescer[string]{
-					l: []chan *F[string]{
-						make(chan *F[string], 1),
-						make(chan *F[string], 1),
-						e,
-						make(chan *F[string], 1),
+					l: []*waiter[string]{
+						{r: make(chan F[string], 1)},
+						{r: make(chan F[string], 1)},
+						{r: e},
+						{r: make(chan F[string], 1)},
 					},
 					gen: 2,
 				}
@@ -530,15 +532,15 @@ func TestAbort(t *testing.T) {
 		},
 		{
 			name: "the first",
-			e:    make(chan *F[string], 1),
+			e:    make(chan F[string], 1),
 			gen:  2,
-			cfn: func(e chan *F[string]) *Coalescer[string] {
+			cfn: func(e chan F[string]) *Coalescer[string] {
 				return &Coalescer[string]{
-					l: []chan *F[string]{
-						e,
-						make(chan *F[string], 1),
-						make(chan *F[string], 1),
-						make(chan *F[string], 1),
+					l: []*waiter[string]{
+						{r: e},
+						{r: make(chan F[string], 1)},
+						{r: make(chan F[string], 1)},
+						{r: make(chan F[string], 1)},
 					},
 					gen: 2,
 				}
@@ -547,15 +549,15 @@ func TestAbort(t *testing.T) {
 		},
 		{
 			name: "the last",
-			e:    make(chan *F[string], 1),
+			e:    make(chan F[string], 1),
 			gen:  2,
-			cfn: func(e chan *F[string]) *Coalescer[string] {
+			cfn: func(e chan F[string]) *Coalescer[string] {
 				return &Coalescer[string]{
-					l: []chan *F[string]{
-						make(chan *F[string], 1),
-						make(chan *F[string], 1),
-						make(chan *F[string], 1),
-						e,
+					l: []*waiter[string]{
+						{r: make(chan F[string], 1)},
+						{r: make(chan F[string], 1)},
+						{r: make(chan F[string], 1)},
+						{r: e},
 					},
 					gen: 2,
 				}
@@ -564,15 +566,15 @@ func TestAbort(t *testing.T) {
 		},
 		{
 			name: "wrong gen",
-			e:    make(chan *F[string], 1),
+			e:    make(chan F[string], 1),
 			gen:  2,
-			cfn: func(e chan *F[string]) *Coalescer[string] {
+			cfn: func(e chan F[string]) *Coalescer[string] {
 				return &Coalescer[string]{
-					l: []chan *F[string]{
-						e,
-						make(chan *F[string], 1),
-						make(chan *F[string], 1),
-						make(chan *F[string], 1),
+					l: []*waiter[string]{
+						{r: e},
+						{r: make(chan F[string], 1)},
+						{r: make(chan F[string], 1)},
+						{r: make(chan F[string], 1)},
 					},
 					gen: 3,
 				}
@@ -581,12 +583,12 @@ func TestAbort(t *testing.T) {
 		},
 		{
 			name: "only",
-			e:    make(chan *F[string], 1),
+			e:    make(chan F[string], 1),
 			gen:  2,
-			cfn: func(e chan *F[string]) *Coalescer[string] {
+			cfn: func(e chan F[string]) *Coalescer[string] {
 				return &Coalescer[string]{
-					l: []chan *F[string]{
-						e,
+					l: []*waiter[string]{
+						{r: e},
 					},
 					gen: 2,
 				}
@@ -595,9 +597,9 @@ func TestAbort(t *testing.T) {
 		},
 		{
 			name: "empty",
-			e:    make(chan *F[string], 1),
+			e:    make(chan F[string], 1),
 			gen:  2,
-			cfn: func(_ chan *F[string]) *Coalescer[string] {
+			cfn: func(_ chan F[string]) *Coalescer[string] {
 				return &Coalescer[string]{
 					gen: 3,
 				}
@@ -606,9 +608,9 @@ func TestAbort(t *testing.T) {
 		},
 		{
 			name: "empty same gen",
-			e:    make(chan *F[string], 1),
+			e:    make(chan F[string], 1),
 			gen:  2,
-			cfn: func(_ chan *F[string]) *Coalescer[string] {
+			cfn: func(_ chan F[string]) *Coalescer[string] {
 				return &Coalescer[string]{
 					gen: 2,
 				}
@@ -628,3 +630,874 @@ func TestAbort(t *testing.T) {
 		})
 	}
 }
+
+func TestRunChan(t *testing.T) {
+	q := Coalesce(func() (string, error) {
+		time.Sleep(50 * time.Millisecond)
+		return "foo", nil
+	})
+
+	c1, abort1 := q.RunChan()
+	c2, _ := q.RunChan()
+	abort1()
+	if r, ok := <-c1; ok {
+		t.Errorf("Expected aborted channel to be closed but received=%v", r)
+	}
+
+	select {
+	case r := <-c2:
+		if v, err := r.Return(); v != "foo" || err != nil {
+			t.Errorf("Expected foo received=%v error=%v", v, err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected result before timeout")
+	}
+
+	cq := CacheCoalesce(func() (string, error) {
+		return "bar", nil
+	}, time.Hour, 0)
+	_, _ = cq.Run()
+	c3, abort3 := cq.RunChan()
+	abort3()
+	if r := <-c3; r.V != "bar" || r.E != nil {
+		t.Errorf("Expected cached result=bar but received=%v", r)
+	}
+}
+
+func TestPeek(t *testing.T) {
+	var calls atomic.Uint64
+	q := CacheCoalesce(func() (uint64, error) {
+		return calls.Add(1), nil
+	}, 20*time.Millisecond, 0)
+
+	if v, added, ok := q.Peek(); ok || v != 0 || !added.IsZero() {
+		t.Errorf("Expected no cached result but received=%v added=%v", v, added)
+	}
+	_, _ = q.Run()
+	time.Sleep(30 * time.Millisecond)
+	v, added, ok := q.Peek()
+	if !ok || v != 1 || time.Since(added) < 20*time.Millisecond {
+		t.Errorf("Expected stale cached result=1 but received=%v added=%v", v, added)
+	}
+	if n := calls.Load(); n != 1 || q.IsRunning() {
+		t.Errorf("Expected peek not to run function but calls=%v", n)
+	}
+	q.Flush()
+	if _, _, ok := q.Peek(); ok {
+		t.Errorf("Expected no cached result after flush")
+	}
+}
+
+func TestPrime(t *testing.T) {
+	var calls atomic.Uint64
+	fn := func() (string, error) {
+		calls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return "fresh", nil
+	}
+
+	q := CacheCoalesce(fn, 30*time.Millisecond, 30*time.Millisecond)
+	q.Prime("primed")
+	if v, err := q.TryRun(); v != "primed" || err != nil {
+		t.Errorf("Expected primed received=%v error=%v", v, err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if v, _ := q.TryRun(); v != "primed" || !q.IsRunning() {
+		t.Errorf("Expected primed result served in grace with refresh but received=%v", v)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if v, _ := q.TryRun(); v != "fresh" || calls.Load() != 1 {
+		t.Errorf("Expected fresh result after refresh but received=%v", v)
+	}
+
+	u := Coalesce(fn)
+	u.Prime("ignored")
+	if _, _, ok := u.Peek(); ok {
+		t.Errorf("Expected prime to be ignored without cache")
+	}
+	u.PrimeWithTTL("primed", 20*time.Millisecond)
+	if v, err := u.TryRun(); v != "primed" || err != nil {
+		t.Errorf("Expected primed received=%v error=%v", v, err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, err := u.TryRun(); err != ErrRunnerTimedout {
+		t.Errorf("Expected error=%v but received error=%v", ErrRunnerTimedout, err)
+	}
+}
+
+func TestRunMaxAge(t *testing.T) {
+	var calls atomic.Uint64
+	q := CacheCoalesce(func() (uint64, error) {
+		return calls.Add(1), nil
+	}, time.Hour, 0)
+
+	tests := []struct {
+		name   string
+		maxAge time.Duration
+		sleep  time.Duration
+		expect uint64
+	}{
+		{name: "runs without cache", maxAge: time.Hour, expect: 1},
+		{name: "young enough", maxAge: time.Hour, sleep: 20 * time.Millisecond, expect: 1},
+		{name: "too old", maxAge: 10 * time.Millisecond, expect: 2},
+		{name: "refreshed result cached", maxAge: 10 * time.Millisecond, expect: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := q.RunMaxAge(tt.maxAge)
+			if v != tt.expect || err != nil {
+				t.Errorf("Expected result=%v but received result=%v error=%v", tt.expect, v, err)
+			}
+			time.Sleep(tt.sleep)
+		})
+	}
+	if v, _ := q.TryRun(); v != 2 {
+		t.Errorf("Expected cached result=%v but received result=%v", 2, v)
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	var calls atomic.Uint64
+	q := CacheCoalesce(func() (uint64, error) {
+		time.Sleep(30 * time.Millisecond)
+		return calls.Add(1), nil
+	}, time.Hour, 0)
+
+	go func() {
+		_, _ = q.Run()
+	}()
+	time.Sleep(5 * time.Millisecond)
+	q.Invalidate()
+	if v, err := q.Run(); v != 1 || err != nil {
+		t.Errorf("Expected in-flight result=%v but received result=%v error=%v", 1, v, err)
+	}
+	if _, _, ok := q.Peek(); ok {
+		t.Errorf("Expected invalidated result not to be cached")
+	}
+	if v, _ := q.Run(); v != 2 {
+		t.Errorf("Expected fresh result=%v but received result=%v", 2, v)
+	}
+
+	go func() {
+		_, _ = q.NoCache().Run()
+	}()
+	time.Sleep(5 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := q.FlushAndWait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+	}
+	if err := q.FlushAndWait(context.Background()); err != nil || q.IsRunning() {
+		t.Errorf("Expected wait for in-flight run but received error=%v", err)
+	}
+	if _, _, ok := q.Peek(); ok {
+		t.Errorf("Expected flushed result not to be cached")
+	}
+	if err := q.FlushAndWait(context.Background()); err != nil {
+		t.Errorf("Expected error=%v but received error=%v", nil, err)
+	}
+}
+
+func TestCoalescePanic(t *testing.T) {
+	var calls atomic.Uint64
+	q := CacheCoalesce(func() (string, error) {
+		if calls.Add(1) == 1 {
+			time.Sleep(20 * time.Millisecond)
+			panic("test panic")
+		}
+		return "foo", nil
+	}, time.Hour, 0)
+
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, err := q.Run()
+			errs <- err
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		var perr *PanicError
+		if err := <-errs; !errors.As(err, &perr) || perr.Value != "test panic" {
+			t.Errorf("Expected panic error but received error=%v", err)
+		}
+	}
+	if q.IsRunning() {
+		t.Errorf("Expected runner to be stopped after panic")
+	}
+	if v, err := q.Run(); v != "foo" || err != nil {
+		t.Errorf("Expected foo received=%v error=%v", v, err)
+	}
+}
+
+func TestRunTimeoutLimit(t *testing.T) {
+	cancelled := make(chan struct{})
+	var calls atomic.Uint64
+	q := CacheCoalesceContext(func(ctx context.Context) (string, error) {
+		if calls.Add(1) > 1 {
+			return "foo", nil
+		}
+		<-ctx.Done() // hung upstream
+		close(cancelled)
+		return "", ctx.Err()
+	}, time.Hour, 0).WithRunTimeout(20 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := q.Run(); err != ErrRunnerTimedout {
+		t.Errorf("Expected error=%v but received error=%v", ErrRunnerTimedout, err)
+	}
+	if elapsed := time.Since(start); elapsed > allowedVariance {
+		t.Errorf("Expected run to be limited but took=%v", elapsed)
+	}
+	<-cancelled
+	if v, err := q.Run(); v != "foo" || err != nil {
+		t.Errorf("Expected foo received=%v error=%v", v, err)
+	}
+
+	p := Coalesce(func() (int, error) {
+		panic("test panic")
+	}).WithRunTimeout(time.Second)
+	var perr *PanicError
+	if _, err := p.Run(); !errors.As(err, &perr) {
+		t.Errorf("Expected panic error but received error=%v", err)
+	}
+}
+
+func TestMaxWaiters(t *testing.T) {
+	release := make(chan struct{})
+	q := Coalesce(func() (string, error) {
+		<-release
+		return "foo", nil
+	}).WithMaxWaiters(2)
+
+	r1, _ := q.RunChan()
+	r2, _ := q.RunChan()
+	if _, err := q.Run(); err != ErrQueueFull {
+		t.Errorf("Expected error=%v but received error=%v", ErrQueueFull, err)
+	}
+	r3, _ := q.RunChan()
+	if v := <-r3; v.E != ErrQueueFull {
+		t.Errorf("Expected error=%v but received error=%v", ErrQueueFull, v.E)
+	}
+
+	close(release)
+	for _, r := range []<-chan F[string]{r1, r2} {
+		if v := <-r; v.V != "foo" || v.E != nil {
+			t.Errorf("Expected foo received=%v error=%v", v.V, v.E)
+		}
+	}
+	if v, err := q.Run(); v != "foo" || err != nil {
+		t.Errorf("Expected foo received=%v error=%v", v, err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	var calls atomic.Uint64
+	q := CacheCoalesce(func() (string, error) {
+		if calls.Add(1) == 2 {
+			return "", testErr
+		}
+		time.Sleep(10 * time.Millisecond)
+		return "foo", nil
+	}, 30*time.Millisecond, time.Hour)
+
+	_, _ = q.Run()
+	_, _ = q.Run()
+	if info := q.Info(); !info.Cached || info.LastError != nil || info.Running {
+		t.Errorf("Expected cached result but received info=%+v", info)
+	}
+	time.Sleep(40 * time.Millisecond)
+	_, _ = q.Run() // stale, refresh fails
+	for q.IsRunning() {
+		time.Sleep(time.Millisecond)
+	}
+	if info := q.Info(); info.LastError != testErr || info.CacheAge < 40*time.Millisecond {
+		t.Errorf("Expected failed refresh but received info=%+v", info)
+	}
+
+	stats := q.Stats()
+	expect := CoalescerStats{Hits: 1, Misses: 1, StaleServes: 1, Refreshes: 2, RefreshFailures: 1}
+	stats.LastRefreshDuration = 0
+	if stats != expect {
+		t.Errorf("Expected stats=%+v but received stats=%+v", expect, stats)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	var starts, ends, stales, aborts atomic.Uint64
+	var q *Coalescer[string]
+	q = CacheCoalesce(func() (string, error) {
+		time.Sleep(20 * time.Millisecond)
+		return "foo", nil
+	}, 10*time.Millisecond, time.Hour).WithHooks(CoalescerHooks[string]{
+		OnRefreshStart: func() { starts.Add(1) },
+		OnRefreshEnd: func(v string, err error, dur time.Duration) {
+			if v != "foo" || err != nil || dur < 20*time.Millisecond {
+				t.Errorf("Expected foo received=%v error=%v duration=%v", v, err, dur)
+			}
+			_ = q.Stats() // hooks are called without locks held
+			ends.Add(1)
+		},
+		OnServeStale: func(age time.Duration) {
+			if age < 10*time.Millisecond {
+				t.Errorf("Expected stale age but received age=%v", age)
+			}
+			stales.Add(1)
+		},
+		OnWaiterAbort: func() { aborts.Add(1) },
+	})
+
+	if _, err := q.TryRun(); err != ErrRunnerTimedout {
+		t.Errorf("Expected error=%v but received error=%v", ErrRunnerTimedout, err)
+	}
+	_, _ = q.Run()
+	time.Sleep(20 * time.Millisecond)
+	_, _ = q.Run()
+	for q.IsRunning() {
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, c := range []struct {
+		name   string
+		n      *atomic.Uint64
+		expect uint64
+	}{
+		{"starts", &starts, 2},
+		{"ends", &ends, 2},
+		{"stales", &stales, 1},
+		{"aborts", &aborts, 1},
+	} {
+		if n := c.n.Load(); n != c.expect {
+			t.Errorf("Expected %s=%v but received %s=%v", c.name, c.expect, c.name, n)
+		}
+	}
+}
+
+func TestCoalesceWithFallback(t *testing.T) {
+	var calls, fallbacks atomic.Uint64
+	tests := []struct {
+		name    string
+		primary error
+		alt     error
+		expect  string
+		err     error
+	}{
+		{name: "primary", expect: "foo"},
+		{name: "fallback", primary: testErr, expect: "bar"},
+		{name: "both fail", primary: testErr, alt: ErrSearchFailure, err: ErrSearchFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := CacheCoalesce(func() (string, error) {
+				calls.Add(1)
+				return "foo", tt.primary
+			}, time.Hour, 0).WithFallback(func() (string, error) {
+				fallbacks.Add(1)
+				return "bar", tt.alt
+			})
+			v, err := q.Run()
+			if err != tt.err || (err == nil && v != tt.expect) {
+				t.Errorf("Expected result=%v error=%v but received result=%v error=%v", tt.expect, tt.err, v, err)
+			}
+			if _, _, ok := q.Peek(); ok != (tt.primary == nil) {
+				t.Errorf("Expected cached=%v but received cached=%v", tt.primary == nil, ok)
+			}
+		})
+	}
+	if n := fallbacks.Load(); n != 2 {
+		t.Errorf("Expected fallbacks=%v but received fallbacks=%v", 2, n)
+	}
+
+	q := CoalesceWithFallback(func() (int, error) {
+		return 0, testErr
+	}, func() (int, error) {
+		return 1, nil
+	})
+	if v, err := q.Run(); v != 1 || err != nil {
+		t.Errorf("Expected result=%v but received result=%v error=%v", 1, v, err)
+	}
+}
+
+func TestWithClone(t *testing.T) {
+	q := CacheCoalesce(func() ([]int, error) {
+		return []int{1, 2, 3}, nil
+	}, time.Hour, 0).WithClone(func(v []int) []int {
+		return append([]int(nil), v...)
+	})
+
+	for i := 0; i < 3; i++ {
+		v, err := q.Run()
+		if err != nil || len(v) != 3 || v[0] != 1 {
+			t.Errorf("Expected unmodified result but received result=%v error=%v", v, err)
+		}
+		v[0] = 42
+	}
+	if v, _, _ := q.Peek(); v[0] != 1 {
+		t.Errorf("Expected unmodified cached result but received result=%v", v)
+	}
+
+	c, cancel := q.Subscribe()
+	defer cancel()
+	q.Flush()
+	_, _ = q.Run()
+	if res := <-c; res.E != nil || res.V[0] != 1 {
+		t.Errorf("Expected unmodified subscribed result but received result=%v error=%v", res.V, res.E)
+	} else {
+		res.V[0] = 42
+	}
+	if v, _, _ := q.Peek(); v[0] != 1 {
+		t.Errorf("Expected unmodified cached result but received result=%v", v)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures uint64
+		attempts int
+		calls    uint64
+		err      error
+	}{
+		{name: "recovers", failures: 2, attempts: 3, calls: 3},
+		{name: "exhausted", failures: 5, attempts: 3, calls: 3, err: testErr},
+		{name: "default attempts", failures: 5, attempts: 0, calls: 3, err: testErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Uint64
+			q := Coalesce(func() (string, error) {
+				if calls.Add(1) <= tt.failures {
+					return "", testErr
+				}
+				return "foo", nil
+			}).WithRetry(RetryPolicy{MaxAttempts: tt.attempts, InitialBackoff: 5 * time.Millisecond})
+
+			r, _ := q.RunChan()
+			v, err := q.Run()
+			if err != tt.err || (err == nil && v != "foo") {
+				t.Errorf("Expected error=%v but received result=%v error=%v", tt.err, v, err)
+			}
+			if res := <-r; res.E != tt.err {
+				t.Errorf("Expected queued error=%v but received error=%v", tt.err, res.E)
+			}
+			if n := calls.Load(); n != tt.calls {
+				t.Errorf("Expected calls=%v but received calls=%v", tt.calls, n)
+			}
+		})
+	}
+}
+
+func TestWithRetryRunTimeout(t *testing.T) {
+	var calls atomic.Uint64
+	q := Coalesce(func() (string, error) {
+		calls.Add(1)
+		return "", testErr
+	}).WithRetry(RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour}).WithRunTimeout(30 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := q.Run(); err != ErrRunnerTimedout {
+		t.Errorf("Expected error=%v but received error=%v", ErrRunnerTimedout, err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Expected retries to stop at run timeout but waited=%v", d)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected calls=%v but received calls=%v", 1, n)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	var calls atomic.Uint64
+	q := Coalesce(func() (int, error) {
+		n := calls.Add(1)
+		if n == 2 {
+			return 0, testErr
+		}
+		return int(n), nil
+	})
+
+	c, cancel := q.Subscribe()
+	_, _ = q.Run()
+	if v := <-c; v.V != 1 || v.E != nil {
+		t.Errorf("Expected result=%v but received result=%v error=%v", 1, v.V, v.E)
+	}
+	_, _ = q.Run()
+	_, _ = q.Run()
+	if v := <-c; v.V != 3 || v.E != nil {
+		t.Errorf("Expected latest result=%v but received result=%v error=%v", 3, v.V, v.E)
+	}
+	cancel()
+	if _, ok := <-c; ok {
+		t.Errorf("Expected cancelled subscription to be closed")
+	}
+}
+
+func TestSubscribeOutOfOrder(t *testing.T) {
+	q := Coalesce(func() (int, error) { return 0, nil })
+	c, cancel := q.Subscribe()
+	defer cancel()
+
+	q.publish(q.subs, 2, F[int]{V: 2})
+	q.publish(q.subs, 1, F[int]{V: 1}) // finished before generation 2
+	if v := <-c; v.V != 2 {
+		t.Errorf("Expected latest result=%v but received result=%v", 2, v.V)
+	}
+	select {
+	case v := <-c:
+		t.Errorf("Expected older generation to be dropped but received result=%v", v.V)
+	default:
+	}
+}
+
+func TestRunVersioned(t *testing.T) {
+	var calls atomic.Uint64
+	q := CacheCoalesce(func() (uint64, error) {
+		return calls.Add(1), nil
+	}, time.Hour, 0)
+
+	tests := []struct {
+		name   string
+		fn     func()
+		expect uint64
+		ver    uint64
+	}{
+		{name: "first run", expect: 1, ver: 1},
+		{name: "cached", expect: 1, ver: 1},
+		{name: "flushed", fn: q.Flush, expect: 2, ver: 2},
+		{name: "primed", fn: func() { q.Prime(42) }, expect: 42, ver: 3},
+		{name: "invalidated", fn: q.Invalidate, expect: 3, ver: 4},
+	}
+	for _, tt := range tests {
+		if tt.fn != nil {
+			tt.fn()
+		}
+		v, ver, err := q.RunVersioned()
+		if err != nil || v != tt.expect || ver != tt.ver {
+			t.Errorf("%s: Expected result=%v version=%v but received result=%v version=%v error=%v", tt.name, tt.expect, tt.ver, v, ver, err)
+		}
+	}
+}
+
+func TestCoalesceWindow(t *testing.T) {
+	var calls atomic.Uint64
+	q := CoalesceWindow(func() (uint64, error) {
+		n := calls.Add(1)
+		if n == 2 {
+			return n, testErr
+		}
+		return n, nil
+	}, 30*time.Millisecond)
+
+	tests := []struct {
+		name   string
+		sleep  time.Duration
+		expect uint64
+		err    error
+	}{
+		{name: "first run", expect: 1},
+		{name: "within window", expect: 1},
+		{name: "after window", sleep: 40 * time.Millisecond, expect: 2, err: testErr},
+		{name: "error within window", expect: 2, err: testErr},
+		{name: "after error window", sleep: 40 * time.Millisecond, expect: 3},
+	}
+	for _, tt := range tests {
+		time.Sleep(tt.sleep)
+		v, err := q.Run()
+		if v != tt.expect || err != tt.err {
+			t.Errorf("%s: Expected result=%v error=%v but received result=%v error=%v", tt.name, tt.expect, tt.err, v, err)
+		}
+	}
+	if _, _, ok := q.Peek(); ok {
+		t.Errorf("Expected no cached result")
+	}
+}
+
+func TestStartIfIdle(t *testing.T) {
+	release := make(chan struct{})
+	q := CacheCoalesce(func() (string, error) {
+		<-release
+		return "foo", nil
+	}, time.Hour, 0)
+
+	if _, err := q.TryRunOrStart(); err != ErrRunnerStarted {
+		t.Errorf("Expected error=%v but received error=%v", ErrRunnerStarted, err)
+	}
+	if _, err := q.TryRunOrStart(); err != ErrRunnerTimedout {
+		t.Errorf("Expected error=%v but received error=%v", ErrRunnerTimedout, err)
+	}
+	if q.StartIfIdle() {
+		t.Errorf("Expected no run started while running")
+	}
+	close(release)
+	for q.IsRunning() {
+		time.Sleep(time.Millisecond)
+	}
+	if v, err := q.TryRunOrStart(); v != "foo" || err != nil {
+		t.Errorf("Expected foo received=%v error=%v", v, err)
+	}
+	if !q.StartIfIdle() {
+		t.Errorf("Expected run started while idle")
+	}
+	if v, err := q.TryRun(); v != "foo" || err != nil {
+		t.Errorf("Expected cached foo received=%v error=%v", v, err)
+	}
+}
+
+func TestTryRunOrStartConcurrent(t *testing.T) {
+	release := make(chan struct{})
+	q := CacheCoalesce(func() (string, error) {
+		<-release
+		return "foo", nil
+	}, time.Hour, 0)
+
+	var started atomic.Int64
+	var wg sync.WaitGroup
+	begin := make(chan struct{})
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-begin
+			if _, err := q.TryRunOrStart(); err == ErrRunnerStarted {
+				started.Add(1)
+			}
+		}()
+	}
+	close(begin)
+	wg.Wait()
+	close(release)
+	if n := started.Load(); n != 1 {
+		t.Errorf("Expected started=%v but received started=%v", 1, n)
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	var calls atomic.Uint64
+	release := make(chan struct{})
+	q := CacheCoalesce(func() (uint64, error) {
+		n := calls.Add(1)
+		if n == 3 {
+			<-release
+		}
+		return n, nil
+	}, time.Minute, time.Minute).WithClock(clock)
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		expect  uint64
+	}{
+		{name: "first run", expect: 1},
+		{name: "cached", advance: 59 * time.Second, expect: 1},
+		{name: "stale", advance: 30 * time.Second, expect: 1},
+		{name: "refreshed", expect: 2},
+	}
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		for q.IsRunning() {
+			time.Sleep(time.Millisecond)
+		}
+		if v, err := q.Run(); v != tt.expect || err != nil {
+			t.Errorf("%s: Expected result=%v but received result=%v error=%v", tt.name, tt.expect, v, err)
+		}
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := q.NoCache().RunTimeout(time.Second)
+		done <- err
+	}()
+	for clock.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+	if err := <-done; err != ErrRunnerTimedout {
+		t.Errorf("Expected error=%v but received error=%v", ErrRunnerTimedout, err)
+	}
+	close(release)
+}
+
+func TestWithClockRunTimeout(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	q := CoalesceContext(func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}).WithClock(clock).WithRunTimeout(time.Hour)
+
+	done := make(chan error)
+	go func() {
+		_, err := q.Run()
+		done <- err
+	}()
+	for clock.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Hour)
+	if err := <-done; err != ErrRunnerTimedout {
+		t.Errorf("Expected error=%v but received error=%v", ErrRunnerTimedout, err)
+	}
+}
+
+func TestWithClockRetry(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	var calls atomic.Uint64
+	q := Coalesce(func() (int, error) {
+		calls.Add(1)
+		return 0, testErr
+	}).WithClock(clock).WithRetry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour})
+
+	done := make(chan error)
+	go func() {
+		_, err := q.Run()
+		done <- err
+	}()
+	for _, d := range []time.Duration{time.Hour, 2 * time.Hour} {
+		for clock.Timers() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(d)
+	}
+	if err := <-done; err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("Expected calls=%v but received calls=%v", 3, n)
+	}
+}
+
+func TestAbortContention(t *testing.T) {
+	release := make(chan struct{})
+	q := Coalesce(func() (string, error) {
+		<-release
+		return "foo", nil
+	})
+
+	done := make(chan struct{})
+	for i := 0; i < 100; i++ {
+		go func() {
+			for j := 0; j < 10; j++ {
+				_, _ = q.RunTimeout(time.Millisecond)
+				_ = q.IsRunning() // contend on the lock
+			}
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		<-done
+	}
+	if n := q.Stats().Waiting; n != 0 {
+		t.Errorf("Expected waiting=%v but received waiting=%v", 0, n)
+	}
+	close(release)
+}
+
+func TestRunWithFreshness(t *testing.T) {
+	start := time.Now()
+	clock := NewFakeClock(start)
+	q := CacheCoalesce(func() (string, error) {
+		return "foo", nil
+	}, time.Minute, time.Hour).WithClock(clock)
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		fresh   bool
+	}{
+		{name: "run", fresh: true},
+		{name: "cached", advance: 30 * time.Second, fresh: true},
+		{name: "stale", advance: time.Minute, fresh: false},
+	}
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		v, cachedAt, fresh, err := q.RunWithFreshness(context.Background())
+		if v != "foo" || err != nil || fresh != tt.fresh || !cachedAt.Equal(start) {
+			t.Errorf("%s: Expected fresh=%v cachedAt=%v but received fresh=%v cachedAt=%v result=%v error=%v",
+				tt.name, tt.fresh, start, fresh, cachedAt, v, err)
+		}
+	}
+}
+
+func TestRunWithFreshnessLeaseHeld(t *testing.T) {
+	start := time.Now()
+	clock := NewFakeClock(start)
+	leaser := &testLeaser{held: map[string]bool{"foo": true}}
+	q := CacheCoalesce(func() (string, error) {
+		return "foo", nil
+	}, time.Minute, 0).WithClock(clock).WithLeaser(leaser, "foo")
+	q.Prime("bar")
+
+	clock.Advance(2 * time.Minute)
+	v, cachedAt, fresh, err := q.RunWithFreshness(context.Background())
+	if v != "bar" || err != nil || fresh || !cachedAt.Equal(start) {
+		t.Errorf("Expected stale result=%v cachedAt=%v but received result=%v fresh=%v cachedAt=%v error=%v",
+			"bar", start, v, fresh, cachedAt, err)
+	}
+}
+
+func TestRunWithFreshnessOverlap(t *testing.T) {
+	start := time.Now()
+	clock := NewFakeClock(start)
+	leaser := &testLeaser{held: map[string]bool{"foo": true}}
+	q := CacheCoalesce(func() (string, error) {
+		return "foo", nil
+	}, time.Minute, 0).WithClock(clock).WithLeaser(leaser, "foo")
+	q.Prime("bar")
+	clock.Advance(2 * time.Minute)
+
+	res, w := q.wait(context.Background(), -1, false)
+	_ = leaser.Release(context.Background(), "foo")
+	if v, err := q.NoCache().Run(); v != "foo" || err != nil {
+		t.Errorf("Expected refresh result=%v but received result=%v error=%v", "foo", v, err)
+	}
+	if res.V != "bar" || !w.stale || !w.added.Equal(start) {
+		t.Errorf("Expected stale result=%v cachedAt=%v but received result=%v stale=%v cachedAt=%v",
+			"bar", start, res.V, w.stale, w.added)
+	}
+}
+
+func TestRunWithFreshnessFallback(t *testing.T) {
+	q := CoalesceWithFallback(func() (string, error) {
+		return "", testErr
+	}, func() (string, error) {
+		return "bar", nil
+	})
+	if v, _, fresh, err := q.RunWithFreshness(context.Background()); v != "bar" || err != nil || fresh {
+		t.Errorf("Expected stale fallback result=%v but received result=%v fresh=%v error=%v", "bar", v, fresh, err)
+	}
+}
+
+func TestRunTimeoutAbandoned(t *testing.T) {
+	var calls, running, peak atomic.Int64
+	release := make(chan struct{})
+	q := Coalesce(func() (int, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		if n > peak.Load() {
+			peak.Store(n)
+		}
+		if calls.Add(1) == 1 {
+			<-release // ignores cancellation
+		}
+		return 1, nil
+	}).WithRunTimeout(100 * time.Millisecond)
+
+	if _, err := q.Run(); err != ErrRunnerTimedout {
+		t.Errorf("Expected error=%v but received error=%v", ErrRunnerTimedout, err)
+	}
+	done := make(chan error)
+	go func() {
+		_, err := q.Run()
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected next run to wait on abandoned function but received calls=%v", n)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("Expected no error but received error=%v", err)
+	}
+	if n := peak.Load(); n != 1 {
+		t.Errorf("Expected function not to run concurrently but received peak=%v", n)
+	}
+}
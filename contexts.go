@@ -0,0 +1,98 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type mergedCtx struct {
+	a, b context.Context
+	done chan struct{}
+	mu   sync.Mutex
+	err  error
+}
+
+// MergeContexts returns a context which is done when either a or b is done,
+// or the returned CancelFunc is called, with the earliest deadline and the
+// values of both, preferring a. The CancelFunc must be called to release the
+// goroutine watching a and b.
+func MergeContexts(a, b context.Context) (context.Context, context.CancelFunc) {
+	ctx := &mergedCtx{a: a, b: b, done: make(chan struct{})}
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-a.Done():
+			ctx.cancel(a.Err())
+		case <-b.Done():
+			ctx.cancel(b.Err())
+		case <-stop:
+			ctx.cancel(context.Canceled)
+		}
+	}()
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			close(stop)
+		})
+	}
+}
+
+func (c *mergedCtx) cancel(err error) {
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+	close(c.done)
+}
+
+func (c *mergedCtx) Deadline() (time.Time, bool) {
+	da, oka := c.a.Deadline()
+	db, okb := c.b.Deadline()
+	if !oka || (okb && db.Before(da)) {
+		return db, okb
+	}
+	return da, oka
+}
+
+func (c *mergedCtx) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *mergedCtx) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *mergedCtx) Value(key any) any {
+	if v := c.a.Value(key); v != nil {
+		return v
+	}
+	return c.b.Value(key)
+}
+
+type detachedCtx struct {
+	parent context.Context
+}
+
+// Detach returns a context with the values of ctx but without its deadline
+// or cancellation, such as for work which outlives a request.
+func Detach(ctx context.Context) context.Context {
+	return detachedCtx{ctx}
+}
+
+func (detachedCtx) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedCtx) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedCtx) Err() error {
+	return nil
+}
+
+func (c detachedCtx) Value(key any) any {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,61 @@
+package goroutines
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type ctxKey string
+
+func TestMergeContexts(t *testing.T) {
+	tests := []struct {
+		name   string
+		cancel func(a, b, merged context.CancelFunc)
+		err    error
+	}{
+		{name: "first cancelled", cancel: func(a, _, _ context.CancelFunc) { a() }, err: context.Canceled},
+		{name: "second cancelled", cancel: func(_, b, _ context.CancelFunc) { b() }, err: context.Canceled},
+		{name: "merged cancelled", cancel: func(_, _, m context.CancelFunc) { m() }, err: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, cancelA := context.WithCancel(context.WithValue(context.Background(), ctxKey("a"), "foo"))
+			defer cancelA()
+			b, cancelB := context.WithTimeout(context.WithValue(context.Background(), ctxKey("b"), "bar"), time.Hour)
+			defer cancelB()
+
+			ctx, cancel := MergeContexts(a, b)
+			defer cancel()
+			if ctx.Value(ctxKey("a")) != "foo" || ctx.Value(ctxKey("b")) != "bar" {
+				t.Errorf("Expected values of both contexts")
+			}
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("Expected deadline of second context")
+			}
+			if ctx.Err() != nil {
+				t.Errorf("Expected merged context not to be done")
+			}
+			tt.cancel(cancelA, cancelB, cancel)
+			<-ctx.Done()
+			if err := ctx.Err(); err != tt.err {
+				t.Errorf("Expected error=%v but received error=%v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestDetach(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.WithValue(context.Background(), ctxKey("a"), "foo"), time.Hour)
+	ctx := Detach(parent)
+	cancel()
+	if ctx.Err() != nil || ctx.Done() != nil {
+		t.Errorf("Expected detached context not to be cancelled")
+	}
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("Expected detached context without deadline")
+	}
+	if ctx.Value(ctxKey("a")) != "foo" {
+		t.Errorf("Expected values of parent context")
+	}
+}
@@ -0,0 +1,90 @@
+package goroutines
+
+import (
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+)
+
+// counterShard is padded to its own cache line, so shards updated by
+// different CPUs do not contend.
+type counterShard struct {
+	n atomic.Int64
+	_ [56]byte
+}
+
+// ShardedCounter is a counter striped across shards, so it may be updated
+// from many goroutines at once without contending on a single atomic. Reading
+// the value sums the shards, so is slower than updating it.
+// The zero value cannot be used.
+type ShardedCounter struct {
+	shards []counterShard
+}
+
+// NewShardedCounter returns a ShardedCounter with a shard for each CPU.
+func NewShardedCounter() *ShardedCounter {
+	return &ShardedCounter{shards: make([]counterShard, runtime.GOMAXPROCS(0))}
+}
+
+// Add n to the counter.
+func (c *ShardedCounter) Add(n int64) {
+	c.shards[rand.Intn(len(c.shards))].n.Add(n)
+}
+
+// Inc adds one to the counter.
+func (c *ShardedCounter) Inc() {
+	c.Add(1)
+}
+
+// Value returns the sum of the counter. It is not a snapshot, as shards may
+// be updated while they are summed.
+func (c *ShardedCounter) Value() int64 {
+	var sum int64
+	for i := range c.shards {
+		sum += c.shards[i].n.Load()
+	}
+	return sum
+}
+
+// Snapshot returns the sum of the counter and resets it to zero. Each update
+// made while the snapshot is taken is counted by either this snapshot or the
+// next, so periodic snapshots never lose counts.
+func (c *ShardedCounter) Snapshot() int64 {
+	var sum int64
+	for i := range c.shards {
+		sum += c.shards[i].n.Swap(0)
+	}
+	return sum
+}
+
+// ShardedGauge is a ShardedCounter which may go up and down, such as the
+// number of items in progress.
+// The zero value cannot be used.
+type ShardedGauge struct {
+	c *ShardedCounter
+}
+
+// NewShardedGauge returns a ShardedGauge with a shard for each CPU.
+func NewShardedGauge() *ShardedGauge {
+	return &ShardedGauge{c: NewShardedCounter()}
+}
+
+// Add delta to the gauge, which may be negative.
+func (g *ShardedGauge) Add(delta int64) {
+	g.c.Add(delta)
+}
+
+// Inc adds one to the gauge.
+func (g *ShardedGauge) Inc() {
+	g.c.Add(1)
+}
+
+// Dec subtracts one from the gauge.
+func (g *ShardedGauge) Dec() {
+	g.c.Add(-1)
+}
+
+// Value returns the sum of the gauge.
+func (g *ShardedGauge) Value() int64 {
+	return g.c.Value()
+}
@@ -0,0 +1,65 @@
+package goroutines
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestShardedCounter(t *testing.T) {
+	c := NewShardedCounter()
+	g := NewShardedGauge()
+	var snapshots atomic.Int64
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			snapshots.Add(c.Snapshot())
+		}
+	}()
+
+	err := ForEach(8, func(n int) error {
+		for i := 0; i < 1000; i++ {
+			c.Inc()
+			g.Inc()
+			g.Dec()
+		}
+		c.Add(int64(n))
+		g.Add(1)
+		return nil
+	}, testInts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	expect := int64(len(testInts)*1000 + 1830)
+	if n := snapshots.Load() + c.Value(); n != expect {
+		t.Errorf("Expected count=%v but received count=%v", expect, n)
+	}
+	if n := g.Value(); n != int64(len(testInts)) {
+		t.Errorf("Expected gauge=%v but received gauge=%v", len(testInts), n)
+	}
+	c.Snapshot()
+	if n := c.Value(); n != 0 {
+		t.Errorf("Expected count=%v after snapshot but received count=%v", 0, n)
+	}
+}
+
+func BenchmarkShardedCounter(b *testing.B) {
+	b.Run("atomic", func(b *testing.B) {
+		var n atomic.Int64
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				n.Add(1)
+			}
+		})
+	})
+	b.Run("sharded", func(b *testing.B) {
+		c := NewShardedCounter()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				c.Inc()
+			}
+		})
+	})
+}
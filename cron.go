@@ -0,0 +1,161 @@
+package goroutines
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScheduleSpec determines when a job of a Scheduler runs.
+type ScheduleSpec interface {
+	// Next returns the first time a job runs after the given time, or the
+	// zero time if it never runs again.
+	Next(after time.Time) time.Time
+}
+
+type everySpec time.Duration
+
+// EverySpec runs a job every interval. Intervals less than a millisecond are
+// treated as a millisecond.
+func EverySpec(interval time.Duration) ScheduleSpec {
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	return everySpec(interval)
+}
+
+func (s everySpec) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(s))
+}
+
+// cronSpec of bitmasks of the minutes, hours, days of the month, months and
+// days of the week which match.
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	anyDay                        bool // dom or dow is *, so both must match
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron spec of five fields: minute, hour, day of the
+// month, month and day of the week, where Sunday is 0 or 7. Fields are
+// lists of *, values and ranges with optional steps, such as "*/15" or
+// "1-5,10". As in cron, if both days are restricted a time matches either.
+// The descriptors @yearly, @monthly, @weekly, @daily, @hourly and
+// "@every <duration>" are also accepted.
+func ParseCron(spec string) (ScheduleSpec, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid cron spec %q: bad interval", spec)
+		}
+		return EverySpec(interval), nil
+	}
+	if s, ok := cronDescriptors[spec]; ok {
+		spec = s
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron spec %q: expected 5 fields", spec)
+	}
+	var c cronSpec
+	var err error
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	masks := [5]*uint64{&c.minute, &c.hour, &c.dom, &c.month, &c.dow}
+	for i, f := range fields {
+		if *masks[i], err = parseCronField(f, bounds[i][0], bounds[i][1]); err != nil {
+			return nil, fmt.Errorf("invalid cron spec %q: %w", spec, err)
+		}
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // Sunday
+	}
+	c.anyDay = fields[2] == "*" || fields[4] == "*"
+	return &c, nil
+}
+
+// parseCronField returns a bitmask of the values of a field in [lo, hi].
+func parseCronField(f string, lo, hi int) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(f, ",") {
+		expr, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+			expr, step = part[:i], n
+		}
+
+		start, end := lo, hi
+		if expr != "*" {
+			a, b, isRange := strings.Cut(expr, "-")
+			var err error
+			if start, err = strconv.Atoi(a); err != nil {
+				return 0, fmt.Errorf("bad value in %q", part)
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(b); err != nil {
+					return 0, fmt.Errorf("bad value in %q", part)
+				}
+			} else if step > 1 {
+				end = hi // "a/n" starts at a
+			}
+		}
+		if start < lo || end > hi || start > end {
+			return 0, fmt.Errorf("value out of range [%d, %d] in %q", lo, hi, part)
+		}
+		for v := start; v <= end; v += step {
+			mask |= 1 << v
+		}
+	}
+	return mask, nil
+}
+
+// Next returns the first matching minute after the given time, in its
+// location.
+func (c *cronSpec) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0) // no match, such as February 30th
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *cronSpec) matchDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.anyDay {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,46 @@
+package goroutines
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	// Wednesday
+	after := time.Date(2024, time.January, 10, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		spec   string
+		expect time.Time
+	}{
+		{spec: "* * * * *", expect: time.Date(2024, time.January, 10, 10, 31, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", expect: time.Date(2024, time.January, 10, 10, 45, 0, 0, time.UTC)},
+		{spec: "0 9-17 * * *", expect: time.Date(2024, time.January, 10, 11, 0, 0, 0, time.UTC)},
+		{spec: "30 2 * * *", expect: time.Date(2024, time.January, 11, 2, 30, 0, 0, time.UTC)},
+		{spec: "0 0 * * 0", expect: time.Date(2024, time.January, 14, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * 7", expect: time.Date(2024, time.January, 14, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 1,15 * 5", expect: time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", expect: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "5/20 * * * *", expect: time.Date(2024, time.January, 10, 10, 45, 0, 0, time.UTC)},
+		{spec: "@monthly", expect: time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "@hourly", expect: time.Date(2024, time.January, 10, 11, 0, 0, 0, time.UTC)},
+		{spec: "@every 90s", expect: after.Add(90 * time.Second)},
+		{spec: "0 0 30 2 *"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("Expected no error but received error=%v", err)
+			}
+			if next := s.Next(after); !next.Equal(tt.expect) {
+				t.Errorf("Expected next=%v but received next=%v", tt.expect, next)
+			}
+		})
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@every soon"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("Expected error for spec=%q", spec)
+		}
+	}
+}
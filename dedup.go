@@ -0,0 +1,98 @@
+package goroutines
+
+import "context"
+
+// CollectDedup is Collect but fn is called once for each distinct argument,
+// and its result is returned at the position of every duplicate. CollectDedup
+// panics if given options which would reorder or drop results of duplicates,
+// which are Unordered, WithSeededOrder, WithMaxErrors and WithMaxErrorRate,
+// and ErrSkipped is returned as an error.
+func CollectDedup[I comparable, R any](qlen int, fn func(I) (R, error), args []I, opts ...Option) ([]R, error) {
+	return CollectDedupFuncWithContext(context.Background(), qlen, func(a I) I { return a }, fn, args, opts...)
+}
+
+// CollectDedupWithContext is CollectDedup but with a context.
+func CollectDedupWithContext[I comparable, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I, opts ...Option) ([]R, error) {
+	return CollectDedupFuncWithContext(ctx, qlen, func(a I) I { return a }, fn, args, opts...)
+}
+
+// CollectDedupFunc is CollectDedup for arguments which are not comparable,
+// where arguments with the same key are duplicates.
+func CollectDedupFunc[I any, K comparable, R any](qlen int, key func(I) K, fn func(I) (R, error), args []I, opts ...Option) ([]R, error) {
+	return CollectDedupFuncWithContext(context.Background(), qlen, key, fn, args, opts...)
+}
+
+// CollectDedupFuncWithContext is CollectDedupFunc but with a context.
+func CollectDedupFuncWithContext[I any, K comparable, R any](ctx context.Context, qlen int, key func(I) K, fn func(I) (R, error), args []I, opts ...Option) ([]R, error) {
+	o := newOptions(opts)
+	o.reject("CollectDedup", optUnordered|optSeededOrder|optMaxErrors)
+	distinct, idx := dedup(key, args)
+	results, err := collect(ctx, qlen, unskipped("CollectDedup", fn), sliceSource(distinct), o, nil)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]R, len(args))
+	for i, n := range idx {
+		out[i] = results[n]
+	}
+	return out, nil
+}
+
+// CollectDistinct is Collect but suppresses results with the same key as an
+// earlier result, so only unique values are returned. Ordered results keep
+// the first result in order of the arguments, otherwise the first result to
+// complete is kept. Results with an error are never suppressed.
+func CollectDistinct[I any, R any, K comparable](qlen int, key func(R) K, fn func(I) (R, error), args []I, opts ...Option) ([]R, error) {
+	return CollectDistinctWithContext(context.Background(), qlen, key, fn, args, opts...)
+}
+
+// CollectDistinctWithContext is CollectDistinct but with a context.
+func CollectDistinctWithContext[I any, R any, K comparable](ctx context.Context, qlen int, key func(R) K, fn func(I) (R, error), args []I, opts ...Option) ([]R, error) {
+	return collect(ctx, qlen, fn, sliceSource(args), newOptions(opts), &hooks[I, R]{keep: distinctBy(key)})
+}
+
+// MapErrDistinct is MapErr but suppresses results with the same key as an
+// earlier result as by CollectDistinct.
+func MapErrDistinct[I any, R any, K comparable](qlen int, key func(R) K, fn func(I) (R, error), args []I, opts ...Option) func() (R, error, bool) {
+	return MapErrDistinctWithContext(context.Background(), qlen, key, fn, args, opts...)
+}
+
+// MapErrDistinctWithContext is MapErrDistinct but with a context.
+func MapErrDistinctWithContext[I any, R any, K comparable](ctx context.Context, qlen int, key func(R) K, fn func(I) (R, error), args []I, opts ...Option) func() (R, error, bool) {
+	return mapErr(ctx, qlen, fn, sliceSource(args), newOptions(opts), &hooks[I, R]{keep: distinctBy(key)})
+}
+
+// ForEachOnce is ForEach but fn is called at most once for each distinct key,
+// with the first argument of the key, so side effects are not repeated for
+// duplicate arguments.
+//
+// If an error is returned, new arguments will not be processed and execution
+// will return when all goroutines finish.
+func ForEachOnce[I any, K comparable](qlen int, key func(I) K, fn func(I) error, args []I, opts ...Option) error {
+	return ForEachOnceWithContext(context.Background(), qlen, key, fn, args, opts...)
+}
+
+// ForEachOnceWithContext is ForEachOnce but with a context.
+func ForEachOnceWithContext[I any, K comparable](ctx context.Context, qlen int, key func(I) K, fn func(I) error, args []I, opts ...Option) error {
+	distinct, _ := dedup(key, args)
+	return ForEachWithContext(ctx, qlen, fn, distinct, opts...)
+}
+
+// dedup returns the first argument of each distinct key, and the index in
+// distinct of each argument.
+func dedup[I any, K comparable](key func(I) K, args []I) (distinct []I, idx []int) {
+	seen := make(map[K]int, len(args))
+	distinct = make([]I, 0, len(args))
+	idx = make([]int, len(args))
+	for i, a := range args {
+		k := key(a)
+		n, ok := seen[k]
+		if !ok {
+			n = len(distinct)
+			seen[k] = n
+			distinct = append(distinct, a)
+		}
+		idx[i] = n
+	}
+	return distinct, idx
+}
@@ -0,0 +1,163 @@
+package goroutines
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCollectDistinct(t *testing.T) {
+	var expect []string
+	seen := make(map[string]bool)
+	for _, s := range testStrings {
+		if !seen[s] {
+			seen[s] = true
+			expect = append(expect, s)
+		}
+	}
+	key := func(s string) string { return s }
+	identity := func(s string) (string, error) { return s, nil }
+
+	r, err := CollectDistinct(4, key, identity, testStrings)
+	if err != nil || len(r) != len(expect) {
+		t.Fatalf("Expected results=%v but received results=%v error=%v", expect, r, err)
+	}
+	for i := range expect {
+		if r[i] != expect[i] {
+			t.Errorf("Expected result=%v but received result=%v", expect[i], r[i])
+		}
+	}
+
+	var n int
+	next := MapErrDistinct(4, key, identity, testStrings, WithOrder(Unordered))
+	for s, err, ok := next(); ok; s, err, ok = next() {
+		if err != nil || !seen[s] {
+			t.Errorf("Expected result=%v to be unique", s)
+		}
+		seen[s] = false
+		n++
+	}
+	if n != len(expect) {
+		t.Errorf("Expected results=%v but received results=%v", len(expect), n)
+	}
+
+	var errs int
+	nextInt := MapErrDistinct(4, func(n int) int { return n }, func(n int) (int, error) {
+		if n == 10 {
+			return 0, testErr
+		}
+		return n % 2, nil
+	}, testInts)
+	n = 0
+	for _, err, ok := nextInt(); ok; _, err, ok = nextInt() {
+		if err != nil {
+			errs++
+		} else {
+			n++
+		}
+	}
+	if n != 2 || errs != 1 {
+		t.Errorf("Expected results=%v errors=%v but received results=%v errors=%v", 2, 1, n, errs)
+	}
+}
+
+func TestCollectDedup(t *testing.T) {
+	var calls atomic.Int64
+	fn := func(s string) (int, error) {
+		calls.Add(1)
+		return len(s), nil
+	}
+	tests := []struct {
+		name    string
+		collect func() ([]int, error)
+	}{
+		{
+			name: "comparable",
+			collect: func() ([]int, error) {
+				return CollectDedup(3, fn, testStrings)
+			},
+		},
+		{
+			name: "key function",
+			collect: func() ([]int, error) {
+				return CollectDedupFunc(3, strings.ToUpper, fn, testStrings)
+			},
+		},
+		{
+			name: "with context",
+			collect: func() ([]int, error) {
+				return CollectDedupWithContext(context.Background(), 3, fn, testStrings)
+			},
+		},
+		{
+			name: "with options",
+			collect: func() ([]int, error) {
+				return CollectDedup(3, fn, testStrings, WithOperationTimeout(time.Minute))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			results, err := tt.collect()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != len(testStrings) {
+				t.Fatalf("Expected results=%v but received results=%v", len(testStrings), len(results))
+			}
+			for i, s := range testStrings {
+				if results[i] != len(s) {
+					t.Errorf("Expected result=%v at index=%v but received result=%v", len(s), i, results[i])
+				}
+			}
+			if n := calls.Load(); n != 15 {
+				t.Errorf("Expected calls=%v but received calls=%v", 15, n)
+			}
+		})
+	}
+
+	if _, err := CollectDedup(3, func(s string) (int, error) {
+		return 0, testErr
+	}, testStrings); err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic for option which reorders results")
+		}
+	}()
+	_, _ = CollectDedup(3, fn, testStrings, WithOrder(Unordered))
+}
+
+func TestForEachOnce(t *testing.T) {
+	var mu sync.Mutex
+	sent := make(map[string]int)
+	err := ForEachOnce(4, strings.ToLower, func(s string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sent[strings.ToLower(s)]++
+		return nil
+	}, append(testStrings, strings.ToUpper(testStrings[0])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 15 {
+		t.Errorf("Expected keys=%v but received keys=%v", 15, len(sent))
+	}
+	for k, n := range sent {
+		if n != 1 {
+			t.Errorf("Expected calls=%v for key=%v but received calls=%v", 1, k, n)
+		}
+	}
+
+	if err := ForEachOnce(4, strings.ToLower, func(s string) error {
+		return testErr
+	}, testStrings); err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+}
@@ -0,0 +1,98 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+)
+
+// Group runs functions in goroutines and returns the first error, like
+// x/sync/errgroup.Group. Panics are recovered and returned as PanicError.
+// The zero value has no limit and does not cancel a context.
+type Group struct {
+	wg     sync.WaitGroup
+	mu     sync.Mutex // guards sem
+	sem    *TimedMutex
+	cancel context.CancelCauseFunc
+	once   sync.Once
+	err    error
+}
+
+// GroupWithContext returns a Group and a context derived from ctx which is
+// cancelled when a function returns an error or Wait returns.
+func GroupWithContext(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// SetLimit limits the number of running functions, blocking Go until one
+// returns. A negative limit removes the limit. Unlike errgroup the limit may
+// be changed while functions are running, and a limit of zero is treated as
+// one rather than blocking Go forever.
+func (g *Group) SetLimit(n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	if g.sem == nil {
+		g.sem = NewVariableTimedMutex(n)
+	} else {
+		g.sem.SetLimit(n)
+	}
+}
+
+// Go calls fn in a new goroutine, waiting for the limit if set.
+func (g *Group) Go(fn func() error) {
+	sem := g.limiter()
+	if sem != nil {
+		sem.Lock()
+	}
+	g.start(fn, sem)
+}
+
+// TryGo calls fn in a new goroutine if the limit allows, reporting whether
+// it was started.
+func (g *Group) TryGo(fn func() error) bool {
+	sem := g.limiter()
+	if sem != nil && !sem.TryLock() {
+		return false
+	}
+	g.start(fn, sem)
+	return true
+}
+
+// limiter returns the semaphore of the limit, or nil if unlimited.
+func (g *Group) limiter() *TimedMutex {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.sem
+}
+
+// start fn in a new goroutine, releasing a slot of sem when it returns.
+func (g *Group) start(fn func() error, sem *TimedMutex) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if sem != nil {
+			defer sem.Unlock()
+		}
+		if err := protect(fn); err != nil {
+			g.once.Do(func() {
+				g.err = err
+				if g.cancel != nil {
+					g.cancel(err)
+				}
+			})
+		}
+	}()
+}
+
+// Wait for all functions to return, returning the first error.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(g.err)
+	}
+	return g.err
+}
@@ -0,0 +1,92 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupWithContext(t *testing.T) {
+	g, ctx := GroupWithContext(context.Background())
+	g.Go(func() error {
+		time.Sleep(10 * time.Millisecond)
+		return testErr
+	})
+	g.Go(func() error {
+		<-ctx.Done() // cancelled by first error
+		return ctx.Err()
+	})
+	if err := g.Wait(); err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+	if cause := context.Cause(ctx); cause != testErr {
+		t.Errorf("Expected cause=%v but received cause=%v", testErr, cause)
+	}
+
+	g, ctx = GroupWithContext(context.Background())
+	g.Go(func() error {
+		panic("test panic")
+	})
+	var perr *PanicError
+	if err := g.Wait(); !errors.As(err, &perr) {
+		t.Errorf("Expected panic error but received error=%v", err)
+	}
+	<-ctx.Done()
+}
+
+func TestGroupLimit(t *testing.T) {
+	var g Group
+	g.SetLimit(2)
+	var running, peak atomic.Int64
+	for i := 0; i < 10; i++ {
+		g.Go(func() error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			return nil
+		})
+	}
+	if g.TryGo(func() error { return nil }) && running.Load() >= 2 {
+		t.Errorf("Expected TryGo to respect limit")
+	}
+	if err := g.Wait(); err != nil {
+		t.Errorf("Expected no error but received error=%v", err)
+	}
+	if n := peak.Load(); n != 2 {
+		t.Errorf("Expected peak=%v but received peak=%v", 2, n)
+	}
+}
+
+func TestGroupSetLimitRunning(t *testing.T) {
+	var g Group
+	g.SetLimit(0) // treated as one
+	if !g.TryGo(func() error { time.Sleep(10 * time.Millisecond); return nil }) {
+		t.Errorf("Expected TryGo within limit to start")
+	}
+	if g.TryGo(func() error { return nil }) {
+		t.Errorf("Expected TryGo beyond limit to fail")
+	}
+	for i := 0; i < 10; i++ {
+		g.Go(func() error {
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+		switch i {
+		case 3:
+			g.SetLimit(3)
+		case 6:
+			g.SetLimit(-1)
+		}
+	}
+	if err := g.Wait(); err != nil {
+		t.Errorf("Expected no error but received error=%v", err)
+	}
+}
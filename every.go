@@ -0,0 +1,193 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// OverlapPolicy determines what happens when a Runner is triggered while
+// the previous run has not completed.
+type OverlapPolicy int
+
+const (
+	// OverlapSkip ignores triggers while running.
+	OverlapSkip OverlapPolicy = iota
+
+	// OverlapQueue runs once more after the current run completes.
+	OverlapQueue
+
+	// OverlapReplace cancels the context of the current run, and runs once
+	// more after it returns.
+	OverlapReplace
+)
+
+// Runner calls a function periodically, and on demand with Trigger. Only one
+// invocation of the function is active at a time.
+type Runner struct {
+	mu        sync.Mutex
+	fn        func(context.Context) error
+	ctx       context.Context
+	interval  time.Duration
+	overlap   OverlapPolicy
+	immediate bool
+	running   bool
+	pending   bool
+	cancel    func()
+	runCancel context.CancelFunc // cancels the current run
+	done      chan struct{}
+	wg        sync.WaitGroup
+	err       error
+}
+
+// Every starts a Runner calling fn every interval until the context is
+// cancelled or Stop is called. Every panics if interval is not positive.
+func Every(ctx context.Context, interval time.Duration, fn func(context.Context) error) *Runner {
+	return NewRunner(interval, fn).Start(ctx)
+}
+
+// Refresh starts a Runner which refreshes the result of a Coalescer every
+// interval, bypassing its cache, so callers are served a warm result.
+func Refresh[T any](ctx context.Context, interval time.Duration, qr *Coalescer[T]) *Runner {
+	return Every(ctx, interval, func(ctx context.Context) error {
+		_, err := qr.NoCache().RunWithContext(ctx)
+		return err
+	})
+}
+
+// NewRunner returns a Runner which has not been started.
+func NewRunner(interval time.Duration, fn func(context.Context) error) *Runner {
+	return &Runner{
+		fn:       fn,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+// WithOverlap sets the policy for triggers while running.
+func (r *Runner) WithOverlap(policy OverlapPolicy) *Runner {
+	r.mu.Lock()
+	r.overlap = policy
+	r.mu.Unlock()
+	return r
+}
+
+// WithImmediate runs the function as soon as the Runner is started instead
+// of waiting for the first interval.
+func (r *Runner) WithImmediate() *Runner {
+	r.mu.Lock()
+	r.immediate = true
+	r.mu.Unlock()
+	return r
+}
+
+// Start calling the function until the context is cancelled or Stop is called.
+// Starting a Runner which was already started does nothing. Start panics if
+// the interval of the Runner is not positive.
+func (r *Runner) Start(ctx context.Context) *Runner {
+	if r.interval <= 0 {
+		panic("Runner interval must be positive")
+	}
+	return r.start(ctx, func(ctx context.Context) {
+		t := time.NewTicker(r.interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				r.Trigger()
+			}
+		}
+	})
+}
+
+// start the Runner with a loop which triggers runs until the context is
+// cancelled.
+func (r *Runner) start(ctx context.Context, loop func(context.Context)) *Runner {
+	r.mu.Lock()
+	if r.cancel != nil {
+		r.mu.Unlock()
+		return r // already started
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r.ctx = ctx
+	r.cancel = cancel
+	immediate := r.immediate
+	r.mu.Unlock()
+
+	go func() {
+		defer close(r.done)
+		if immediate {
+			r.Trigger()
+		}
+		loop(ctx)
+	}()
+	return r
+}
+
+// Trigger a run now, subject to the overlap policy.
+func (r *Runner) Trigger() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ctx == nil || r.ctx.Err() != nil {
+		return // not running
+	}
+	if r.running {
+		switch r.overlap {
+		case OverlapQueue:
+			r.pending = true
+		case OverlapReplace:
+			r.pending = true
+			r.runCancel()
+		}
+		return
+	}
+	r.running = true
+	r.wg.Add(1)
+	var ctx context.Context
+	ctx, r.runCancel = context.WithCancel(r.ctx)
+	go r.run(ctx)
+}
+
+// Err returns the error of the most recent run.
+func (r *Runner) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Stop the Runner and wait for any active run to complete.
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	if r.cancel == nil {
+		r.mu.Unlock()
+		return
+	}
+	r.cancel() // under lock so Trigger cannot start new runs
+	r.mu.Unlock()
+	<-r.done
+	r.wg.Wait()
+}
+
+func (r *Runner) run(ctx context.Context) {
+	defer r.wg.Done()
+	for {
+		err := protect(func() error {
+			return r.fn(ctx)
+		})
+
+		r.mu.Lock()
+		r.runCancel()
+		r.err = err
+		if !r.pending || r.ctx.Err() != nil {
+			r.running = false
+			r.pending = false
+			r.mu.Unlock()
+			return
+		}
+		r.pending = false
+		ctx, r.runCancel = context.WithCancel(r.ctx)
+		r.mu.Unlock()
+	}
+}
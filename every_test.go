@@ -0,0 +1,131 @@
+package goroutines
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEvery(t *testing.T) {
+	tests := []struct {
+		name      string
+		runner    func(fn func(context.Context) error) *Runner
+		triggers  int
+		sleep     time.Duration
+		expectMin int64
+		expectMax int64
+	}{
+		{
+			name: "ticks",
+			runner: func(fn func(context.Context) error) *Runner {
+				return Every(context.Background(), 20*time.Millisecond, fn)
+			},
+			sleep:     110 * time.Millisecond,
+			expectMin: 1,
+			expectMax: 4,
+		},
+		{
+			name: "immediate",
+			runner: func(fn func(context.Context) error) *Runner {
+				return NewRunner(time.Hour, fn).WithImmediate().Start(context.Background())
+			},
+			sleep:     10 * time.Millisecond,
+			expectMin: 1,
+			expectMax: 1,
+		},
+		{
+			name: "started twice",
+			runner: func(fn func(context.Context) error) *Runner {
+				return NewRunner(time.Hour, fn).WithImmediate().Start(context.Background()).Start(context.Background())
+			},
+			sleep:     10 * time.Millisecond,
+			expectMin: 1,
+			expectMax: 1,
+		},
+		{
+			name: "overlapping triggers skipped",
+			runner: func(fn func(context.Context) error) *Runner {
+				return Every(context.Background(), time.Hour, fn)
+			},
+			triggers:  5,
+			sleep:     100 * time.Millisecond,
+			expectMin: 1,
+			expectMax: 1,
+		},
+		{
+			name: "overlapping triggers queued",
+			runner: func(fn func(context.Context) error) *Runner {
+				return NewRunner(time.Hour, fn).WithOverlap(OverlapQueue).Start(context.Background())
+			},
+			triggers:  5,
+			sleep:     150 * time.Millisecond,
+			expectMin: 2,
+			expectMax: 2,
+		},
+		{
+			name: "overlapping triggers replace",
+			runner: func(fn func(context.Context) error) *Runner {
+				return NewRunner(time.Hour, fn).WithOverlap(OverlapReplace).Start(context.Background())
+			},
+			triggers:  5,
+			sleep:     30 * time.Millisecond,
+			expectMin: 2,
+			expectMax: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var runs atomic.Int64
+			r := tt.runner(func(ctx context.Context) error {
+				runs.Add(1)
+				_ = Sleep(ctx, 40*time.Millisecond)
+				return testErr
+			})
+			for i := 0; i < tt.triggers; i++ {
+				r.Trigger()
+			}
+			time.Sleep(tt.sleep)
+			r.Stop()
+			r.Stop() // idempotent
+			if n := runs.Load(); n < tt.expectMin || n > tt.expectMax {
+				t.Errorf("Expected runs between %v and %v but received runs=%v", tt.expectMin, tt.expectMax, n)
+			}
+			if err := r.Err(); err != testErr {
+				t.Errorf("Expected error=%v but received error=%v", testErr, err)
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	var calls atomic.Int64
+	qr := CacheCoalesce(func() (int64, error) {
+		return calls.Add(1), nil
+	}, time.Hour, 0)
+
+	r := Refresh(context.Background(), 20*time.Millisecond, qr)
+	time.Sleep(50 * time.Millisecond)
+	r.Stop()
+
+	v, err := qr.TryRun()
+	if err != nil {
+		t.Errorf("Expected error=%v but received error=%v", nil, err)
+	}
+	if n := calls.Load(); n < 2 || v != n {
+		t.Errorf("Expected cached result=%v from background refresh but received result=%v", n, v)
+	}
+}
+
+func TestEveryInvalidInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expected panic for interval=%v", interval)
+				}
+			}()
+			Every(context.Background(), interval, func(context.Context) error { return nil })
+		}()
+	}
+}
@@ -0,0 +1,114 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInsufficientResults is returned by FanOut when too few backends succeed
+var ErrInsufficientResults = errors.New("insufficient successful results")
+
+// FanOutPolicy configures how FanOut calls backends and assembles results.
+type FanOutPolicy struct {
+	// Timeout of each backend call, or no timeout if zero.
+	Timeout time.Duration
+
+	// Quorum of successful results after which remaining backend calls are
+	// cancelled. If zero all backends are called to completion, and at least
+	// one must succeed.
+	Quorum int
+}
+
+// FanOutResult is the result of calling a single backend.
+type FanOutResult[B any, R any] struct {
+	Backend  B
+	Value    R
+	Err      error
+	Duration time.Duration
+}
+
+type fannedOut[R any] struct {
+	i   int
+	v   R
+	err error
+	dur time.Duration
+}
+
+// FanOut calls each backend concurrently, returning a result for every
+// backend in the order given. Calls still running once the quorum is
+// reached, or the context is cancelled, are cancelled and not waited for.
+// Their result has the error of the context. ErrInsufficientResults, joined
+// with the errors of each backend, is returned if the quorum is not reached.
+func FanOut[B any, R any](ctx context.Context, backends []B, call func(context.Context, B) (R, error), policy FanOutPolicy) ([]FanOutResult[B, R], error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	quorum := policy.Quorum
+	if quorum > len(backends) {
+		quorum = len(backends)
+	}
+
+	results := make([]FanOutResult[B, R], len(backends))
+	finished := make([]bool, len(backends))
+	c := make(chan fannedOut[R], len(backends)) // abandoned calls never block
+	for i, b := range backends {
+		results[i].Backend = b
+		go func(i int, b B) {
+			bctx, bcancel := ctx, context.CancelFunc(func() {})
+			if policy.Timeout > 0 {
+				bctx, bcancel = context.WithTimeout(ctx, policy.Timeout)
+			}
+			defer bcancel()
+
+			start := time.Now()
+			var v R
+			err := protect(func() (err error) {
+				v, err = call(bctx, b)
+				return
+			})
+			c <- fannedOut[R]{i, v, err, time.Since(start)}
+		}(i, b)
+	}
+
+	succeeded, failed := 0, 0
+loop:
+	for pending := len(backends); pending > 0; pending-- {
+		if quorum > 0 && (succeeded >= quorum || len(backends)-failed < quorum) {
+			break // quorum reached or unreachable
+		}
+		select {
+		case <-ctx.Done():
+			break loop
+		case r := <-c:
+			results[r.i].Value, results[r.i].Err, results[r.i].Duration = r.v, r.err, r.dur
+			finished[r.i] = true
+			if r.err != nil {
+				failed++
+			} else {
+				succeeded++
+			}
+		}
+	}
+
+	errs := []error{ErrInsufficientResults}
+	for i := range results {
+		if !finished[i] {
+			results[i].Err = context.Canceled
+			if err := ctx.Err(); err != nil {
+				results[i].Err = err
+			}
+		}
+		if results[i].Err != nil {
+			errs = append(errs, results[i].Err)
+		}
+	}
+	required := quorum
+	if required <= 0 && len(backends) > 0 {
+		required = 1 // partial results when waiting for all backends
+	}
+	if succeeded < required {
+		return results, errors.Join(errs...)
+	}
+	return results, nil
+}
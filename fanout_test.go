@@ -0,0 +1,93 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFanOut(t *testing.T) {
+	backends := []time.Duration{10 * time.Millisecond, 30 * time.Millisecond, -1, time.Second}
+	call := func(ctx context.Context, d time.Duration) (time.Duration, error) {
+		if d < 0 {
+			return 0, testErr
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(d):
+			return d, nil
+		}
+	}
+
+	tests := []struct {
+		name      string
+		policy    FanOutPolicy
+		succeeded int
+		err       error
+		elapsed   time.Duration
+	}{
+		{
+			name:      "all with timeout",
+			policy:    FanOutPolicy{Timeout: 50 * time.Millisecond},
+			succeeded: 2,
+			elapsed:   50 * time.Millisecond,
+		},
+		{
+			name:      "first success",
+			policy:    FanOutPolicy{Quorum: 1},
+			succeeded: 1,
+			elapsed:   10 * time.Millisecond,
+		},
+		{
+			name:      "quorum",
+			policy:    FanOutPolicy{Quorum: 2},
+			succeeded: 2,
+			elapsed:   30 * time.Millisecond,
+		},
+		{
+			name:      "quorum unreachable",
+			policy:    FanOutPolicy{Quorum: 3, Timeout: 50 * time.Millisecond},
+			succeeded: 2,
+			err:       ErrInsufficientResults,
+			elapsed:   50 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			start := time.Now()
+			results, err := FanOut(context.Background(), backends, call, tt.policy)
+			if elapsed := time.Since(start); elapsed < tt.elapsed || elapsed > tt.elapsed+allowedVariance {
+				t.Errorf("Expected elapsed=%v but received elapsed=%v", tt.elapsed, elapsed)
+			}
+			if !errors.Is(err, tt.err) || (err != nil) != (tt.err != nil) {
+				t.Errorf("Expected error=%v but received error=%v", tt.err, err)
+			}
+			succeeded := 0
+			for i, r := range results {
+				if r.Backend != backends[i] {
+					t.Errorf("Expected backend=%v but received backend=%v", backends[i], r.Backend)
+				}
+				if r.Err == nil {
+					succeeded++
+					if r.Value != r.Backend {
+						t.Errorf("Expected value=%v but received value=%v", r.Backend, r.Value)
+					}
+				}
+			}
+			if succeeded != tt.succeeded {
+				t.Errorf("Expected succeeded=%v but received succeeded=%v", tt.succeeded, succeeded)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	results, err := FanOut(ctx, []time.Duration{-1, time.Second}, call, FanOutPolicy{})
+	if !errors.Is(err, ErrInsufficientResults) || results[1].Err != context.DeadlineExceeded {
+		t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, results[1].Err)
+	}
+}
@@ -0,0 +1,48 @@
+package goroutines
+
+import "context"
+
+// Find is Search without sentinel errors. Function returns true to stop with
+// its result, which is returned with true. If no function returns true the
+// zero value and false are returned. Errors are returned unchanged, so a
+// wrapped ErrSearchSuccess is an error.
+//
+// If an error is returned, new arguments will not be processed and execution
+// will return when all goroutines finish.
+func Find[I any, R any](qlen int, fn func(I) (R, bool, error), args []I, opts ...Option) (R, bool, error) {
+	return FindWithContext(context.Background(), qlen, fn, args, opts...)
+}
+
+// FindWithContext is Find but with a context.
+func FindWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, bool, error), args []I, opts ...Option) (R, bool, error) {
+	v, err := search(ctx, qlen, func(e I) (R, error) {
+		v, found, err := fn(e)
+		if err == nil && found {
+			return v, ErrSearchSuccess
+		}
+		return v, err
+	}, sliceSource(args), newOptions(opts), nil)
+	switch err {
+	case nil:
+		return v, true, nil
+	case ErrSearchFailure:
+		var zero R
+		return zero, false, nil
+	}
+	return v, false, err
+}
+
+// ForEachUntil is ForEach without sentinel errors. Function returns true to
+// stop processing new arguments, and ForEachUntil returns true if any did.
+func ForEachUntil[I any](qlen int, fn func(I) (bool, error), args []I, opts ...Option) (bool, error) {
+	return ForEachUntilWithContext(context.Background(), qlen, fn, args, opts...)
+}
+
+// ForEachUntilWithContext is ForEachUntil but with a context.
+func ForEachUntilWithContext[I any](ctx context.Context, qlen int, fn func(I) (bool, error), args []I, opts ...Option) (bool, error) {
+	_, stopped, err := FindWithContext(ctx, qlen, func(e I) (struct{}, bool, error) {
+		stop, err := fn(e)
+		return struct{}{}, stop, err
+	}, args, opts...)
+	return stopped, err
+}
@@ -0,0 +1,69 @@
+package goroutines
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     func(n int) (string, bool, error)
+		expect string
+		found  bool
+		err    error
+	}{
+		{
+			name: "found",
+			fn: func(n int) (string, bool, error) {
+				return fmt.Sprint(n), n == 30, nil
+			},
+			expect: "30",
+			found:  true,
+		},
+		{
+			name: "not found",
+			fn: func(n int) (string, bool, error) {
+				return fmt.Sprint(n), false, nil
+			},
+		},
+		{
+			name: "error",
+			fn: func(n int) (string, bool, error) {
+				if n == 10 {
+					return "", false, testErr
+				}
+				return "", n == 50, nil
+			},
+			err: testErr,
+		},
+		{
+			name: "wrapped sentinel is an error",
+			fn: func(n int) (string, bool, error) {
+				return "", false, fmt.Errorf("wrapped: %w", ErrSearchSuccess)
+			},
+			err: ErrSearchSuccess,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, found, err := Find(4, tt.fn, testInts)
+			if v != tt.expect || found != tt.found {
+				t.Errorf("Expected result=%q found=%v but received result=%q found=%v", tt.expect, tt.found, v, found)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Expected error=%v but received error=%v", tt.err, err)
+			}
+		})
+	}
+
+	var seen int
+	stopped, err := ForEachUntil(1, func(n int) (bool, error) {
+		seen++
+		return n == 5, nil
+	}, testInts)
+	if !stopped || err != nil || seen >= len(testInts) {
+		t.Errorf("Expected stopped=true after processing=%v but received stopped=%v error=%v", 5, stopped, err)
+	}
+}
@@ -0,0 +1,44 @@
+package goroutines
+
+import (
+	"context"
+)
+
+// Future is the eventual result of a function running in another goroutine.
+type Future[T any] struct {
+	done chan struct{}
+	v    T
+	err  error
+}
+
+func newFuture[T any]() *Future[T] {
+	return &Future[T]{done: make(chan struct{})}
+}
+
+// resolve the future, must be called exactly once.
+func (f *Future[T]) resolve(v T, err error) {
+	f.v, f.err = v, err
+	close(f.done)
+}
+
+// Done returns a channel which is closed when the result is available.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait for the result.
+func (f *Future[T]) Wait() (T, error) {
+	<-f.done
+	return f.v, f.err
+}
+
+// WaitWithContext waits for the result or until the context is cancelled.
+func (f *Future[T]) WaitWithContext(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.v, f.err
+	case <-ctx.Done():
+		v := new(T)
+		return *v, ctx.Err()
+	}
+}
@@ -0,0 +1,212 @@
+package goroutines
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type groupEntry[K comparable, T any] struct {
+	key  K
+	qr   *Coalescer[T]
+	used time.Time
+	refs int // callers of Run holding the entry
+}
+
+// CoalescerGroup coalesces a keyed function, lazily creating a Coalescer for
+// each key. Behavior is similar to sync/singleflight.Group.
+type CoalescerGroup[K comparable, T any] struct {
+	mu         sync.Mutex
+	fn         func(K) (T, error)
+	ttl        time.Duration
+	grace      time.Duration
+	m          map[K]*list.Element
+	lru        *list.List // most recently used first
+	maxEntries int
+	idleTTL    time.Duration
+	swept      time.Time
+	onEvict    func(K)
+}
+
+// CoalesceGroup coalesces the given keyed function.
+func CoalesceGroup[K comparable, T any](fn func(K) (T, error)) *CoalescerGroup[K, T] {
+	return CacheCoalesceGroup(fn, 0, 0)
+}
+
+// CacheCoalesceGroup coalesces the given keyed function with a result cache
+// for each key. See CacheCoalesce.
+func CacheCoalesceGroup[K comparable, T any](fn func(K) (T, error), ttl time.Duration, grace time.Duration) *CoalescerGroup[K, T] {
+	return &CoalescerGroup[K, T]{
+		fn:    fn,
+		ttl:   ttl,
+		grace: grace,
+		m:     make(map[K]*list.Element),
+		lru:   list.New(),
+	}
+}
+
+// WithMaxEntries limits the number of keys with a Coalescer. When the limit
+// is exceeded the least recently used idle key is evicted, including any
+// cached result. Keys with a running function are not evicted, so the limit
+// may be exceeded while every key is running or in use by Run.
+func (g *CoalescerGroup[K, T]) WithMaxEntries(n int) *CoalescerGroup[K, T] {
+	g.mu.Lock()
+	g.maxEntries = n
+	g.mu.Unlock()
+	return g
+}
+
+// WithIdleEviction removes the Coalescer of keys which have not been used
+// within the given duration, including any cached result.
+func (g *CoalescerGroup[K, T]) WithIdleEviction(idle time.Duration) *CoalescerGroup[K, T] {
+	g.mu.Lock()
+	g.idleTTL = idle
+	g.mu.Unlock()
+	return g
+}
+
+// WithEvictionCallback calls fn with each key evicted by WithMaxEntries or
+// WithIdleEviction. Keys removed by Forget are not passed to fn.
+func (g *CoalescerGroup[K, T]) WithEvictionCallback(fn func(key K)) *CoalescerGroup[K, T] {
+	g.mu.Lock()
+	g.onEvict = fn
+	g.mu.Unlock()
+	return g
+}
+
+// Run or queue for the next result of the given key.
+func (g *CoalescerGroup[K, T]) Run(key K) (T, error) {
+	e := g.pin(key)
+	defer g.unpin(e)
+	return e.qr.Run()
+}
+
+// RunWithContext runs or queues for the next result of the given key.
+func (g *CoalescerGroup[K, T]) RunWithContext(ctx context.Context, key K) (T, error) {
+	e := g.pin(key)
+	defer g.unpin(e)
+	return e.qr.RunWithContext(ctx)
+}
+
+// Coalescer returns the Coalescer of the given key, creating it if needed.
+// Unlike Run, the key may be evicted while the Coalescer is in use, after
+// which the group creates a new Coalescer for the key.
+func (g *CoalescerGroup[K, T]) Coalescer(key K) *Coalescer[T] {
+	e := g.pin(key)
+	g.unpin(e)
+	return e.qr
+}
+
+// pin the entry of the given key, creating it if needed, so it is not evicted
+// until unpin.
+func (g *CoalescerGroup[K, T]) pin(key K) *groupEntry[K, T] {
+	g.mu.Lock()
+
+	now := time.Now()
+	evicted := g.sweep(now)
+
+	if el, ok := g.m[key]; ok {
+		e := el.Value.(*groupEntry[K, T])
+		e.used = now
+		e.refs++
+		g.lru.MoveToFront(el)
+		g.mu.Unlock()
+		g.evicted(evicted)
+		return e
+	}
+
+	qr := CacheCoalesce(func() (T, error) {
+		return g.fn(key)
+	}, g.ttl, g.grace)
+	pinned := &groupEntry[K, T]{key: key, qr: qr, used: now, refs: 1}
+	g.m[key] = g.lru.PushFront(pinned)
+	for el := g.lru.Back(); el != nil && g.maxEntries > 0 && g.lru.Len() > g.maxEntries; {
+		e := el.Value.(*groupEntry[K, T])
+		prev := el.Prev()
+		if e.evictable() {
+			evicted = append(evicted, g.remove(el))
+		}
+		el = prev
+	}
+	g.mu.Unlock()
+	g.evicted(evicted)
+	return pinned
+}
+
+// unpin an entry returned by pin.
+func (g *CoalescerGroup[K, T]) unpin(e *groupEntry[K, T]) {
+	g.mu.Lock()
+	e.refs--
+	g.mu.Unlock()
+}
+
+// evictable reports whether the entry is neither pinned nor running, must be
+// called with lock held.
+func (e *groupEntry[K, T]) evictable() bool {
+	return e.refs == 0 && !e.qr.IsRunning()
+}
+
+// Forget the Coalescer of the given key, so the next call for the key runs
+// the function. Callers waiting on a running function still receive its
+// result.
+func (g *CoalescerGroup[K, T]) Forget(key K) {
+	g.mu.Lock()
+	if el, ok := g.m[key]; ok {
+		g.remove(el)
+	}
+	g.mu.Unlock()
+}
+
+// Len returns the number of keys with a Coalescer.
+func (g *CoalescerGroup[K, T]) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.m)
+}
+
+// sweep idle entries, must be called with lock held. Returns evicted keys.
+func (g *CoalescerGroup[K, T]) sweep(now time.Time) []K {
+	if g.idleTTL <= 0 || now.Sub(g.swept) < g.idleTTL {
+		return nil
+	}
+	g.swept = now
+
+	var evicted []K
+	for el := g.lru.Back(); el != nil; {
+		e := el.Value.(*groupEntry[K, T])
+		if now.Sub(e.used) <= g.idleTTL {
+			break // remaining entries were used more recently
+		}
+		prev := el.Prev()
+		if e.evictable() {
+			evicted = append(evicted, g.remove(el))
+		}
+		el = prev
+	}
+	return evicted
+}
+
+// remove an entry, must be called with lock held.
+func (g *CoalescerGroup[K, T]) remove(el *list.Element) K {
+	e := g.lru.Remove(el).(*groupEntry[K, T])
+	delete(g.m, e.key)
+	return e.key
+}
+
+// evicted passes evicted keys to the eviction callback, must be called
+// without lock held.
+func (g *CoalescerGroup[K, T]) evicted(keys []K) {
+	if len(keys) == 0 {
+		return
+	}
+	g.mu.Lock()
+	fn := g.onEvict
+	g.mu.Unlock()
+	if fn == nil {
+		return
+	}
+	for _, k := range keys {
+		fn(k)
+	}
+}
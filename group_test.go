@@ -0,0 +1,163 @@
+package goroutines
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalescerGroup(t *testing.T) {
+	var calls atomic.Int64
+	g := CoalesceGroup(func(s string) (int, error) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		if s == "" {
+			return 0, testErr
+		}
+		return len(s), nil
+	})
+
+	var wg sync.WaitGroup
+	for _, s := range testStrings {
+		wg.Add(1)
+		go func(s string) {
+			defer wg.Done()
+			v, err := g.Run(s)
+			if err != nil || v != len(s) {
+				t.Errorf("Expected result=%v but received result=%v error=%v", len(s), v, err)
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 15 {
+		t.Errorf("Expected calls=%v for distinct keys but received calls=%v", 15, n)
+	}
+	if n := g.Len(); n != 15 {
+		t.Errorf("Expected keys=%v but received keys=%v", 15, n)
+	}
+	if _, err := g.Run(""); err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+	g.Forget("")
+	if n := g.Len(); n != 15 {
+		t.Errorf("Expected keys=%v but received keys=%v", 15, n)
+	}
+}
+
+func TestCoalescerGroupLimits(t *testing.T) {
+	var mu sync.Mutex
+	var evicted []int
+	g := CacheCoalesceGroup(func(n int) (int, error) {
+		return n * 2, nil
+	}, time.Hour, 0).WithMaxEntries(2).WithIdleEviction(30 * time.Millisecond).WithEvictionCallback(func(n int) {
+		mu.Lock()
+		evicted = append(evicted, n)
+		mu.Unlock()
+	})
+
+	for _, n := range []int{1, 2, 1, 3} {
+		if v, err := g.Run(n); err != nil || v != n*2 {
+			t.Errorf("Expected result=%v but received result=%v error=%v", n*2, v, err)
+		}
+	}
+	if n := g.Len(); n != 2 {
+		t.Errorf("Expected keys=%v but received keys=%v", 2, n)
+	}
+	if _, err := g.Coalescer(1).TryRun(); err != nil {
+		t.Errorf("Expected recently used key to be cached but received error=%v", err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	_, _ = g.Run(3)
+	if n := g.Len(); n != 1 {
+		t.Errorf("Expected idle keys to be evicted but received keys=%v", n)
+	}
+	g.Forget(3)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(evicted) != 3 || evicted[0] != 2 || evicted[1] != 3 || evicted[2] != 1 {
+		t.Errorf("Expected evicted=%v but received evicted=%v", []int{2, 3, 1}, evicted)
+	}
+}
+
+func TestCoalescerGroupMaxEntriesRunning(t *testing.T) {
+	var calls atomic.Int64
+	started := make(chan struct{})
+	release := make(chan struct{})
+	g := CoalesceGroup(func(s string) (int, error) {
+		if s == "a" {
+			if calls.Add(1) == 1 {
+				close(started)
+			}
+			<-release
+		}
+		return len(s), nil
+	}).WithMaxEntries(1)
+
+	var wg sync.WaitGroup
+	run := func() {
+		defer wg.Done()
+		if v, err := g.Run("a"); err != nil || v != 1 {
+			t.Errorf("Expected result=%v but received result=%v error=%v", 1, v, err)
+		}
+	}
+	wg.Add(1)
+	go run()
+	<-started
+
+	if v, err := g.Run("bb"); err != nil || v != 2 {
+		t.Errorf("Expected result=%v but received result=%v error=%v", 2, v, err)
+	}
+	if n := g.Len(); n != 2 {
+		t.Errorf("Expected running key to exceed limit with keys=%v but received keys=%v", 2, n)
+	}
+
+	wg.Add(2)
+	go run()
+	go run()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected calls=%v for running key but received calls=%v", 1, n)
+	}
+}
+
+func TestCoalescerGroupPinned(t *testing.T) {
+	var calls atomic.Int64
+	g := CacheCoalesceGroup(func(s string) (int, error) {
+		calls.Add(1)
+		return len(s), nil
+	}, time.Hour, 0).WithMaxEntries(1)
+
+	e := g.pin("a") // looked up by Run but not yet running
+	if v, err := g.Run("bb"); err != nil || v != 2 {
+		t.Errorf("Expected result=%v but received result=%v error=%v", 2, v, err)
+	}
+	if p := g.pin("a"); p != e {
+		t.Errorf("Expected pinned key not to be evicted")
+	} else {
+		g.unpin(p)
+	}
+	if v, err := e.qr.Run(); err != nil || v != 1 {
+		t.Errorf("Expected result=%v but received result=%v error=%v", 1, v, err)
+	}
+	g.unpin(e)
+	if v, err := g.Run("a"); err != nil || v != 1 {
+		t.Errorf("Expected result=%v but received result=%v error=%v", 1, v, err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Expected calls=%v but received calls=%v", 2, n)
+	}
+
+	if v, err := g.Run("ccc"); err != nil || v != 3 {
+		t.Errorf("Expected result=%v but received result=%v error=%v", 3, v, err)
+	}
+	if n := g.Len(); n != 1 {
+		t.Errorf("Expected unpinned keys to be evicted but received keys=%v", n)
+	}
+}
@@ -0,0 +1,68 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrStalled is returned in place of context.Canceled by a function which
+// was cancelled for not calling its heartbeat. See HeartbeatPolicy.
+var ErrStalled = errors.New("stalled without heartbeat")
+
+// HeartbeatPolicy configures how ForEachHeartbeat detects stalled functions.
+type HeartbeatPolicy[I any] struct {
+	// Interval is the longest time a function may run without calling its
+	// heartbeat before it is stalled.
+	Interval time.Duration
+
+	// OnStall is called with the argument of a stalled function, once until
+	// it calls its heartbeat again. It is called from its own goroutine, so
+	// must be safe for concurrent use.
+	OnStall func(I)
+
+	// Cancel the context of a stalled function.
+	Cancel bool
+}
+
+// ForEachHeartbeat is ForEach but each function receives a heartbeat to call
+// periodically while it runs. A function which does not call its heartbeat
+// within the interval of the policy is reported as stalled, and its context
+// is cancelled with cause ErrStalled if the policy cancels.
+//
+// If an error is returned, new arguments will not be processed and execution
+// will return when all goroutines finish. ForEachHeartbeat panics if the
+// interval of the policy is not positive.
+func ForEachHeartbeat[I any](qlen int, policy HeartbeatPolicy[I], fn func(ctx context.Context, e I, heartbeat func()) error, args []I, opts ...Option) error {
+	return ForEachHeartbeatWithContext(context.Background(), qlen, policy, fn, args, opts...)
+}
+
+// ForEachHeartbeatWithContext is ForEachHeartbeat but with a context, which
+// is the parent of the context passed to each function.
+func ForEachHeartbeatWithContext[I any](ctx context.Context, qlen int, policy HeartbeatPolicy[I], fn func(ctx context.Context, e I, heartbeat func()) error, args []I, opts ...Option) error {
+	if policy.Interval <= 0 {
+		panic("HeartbeatPolicy interval must be positive")
+	}
+	return ForEachWithContext(ctx, qlen, func(e I) error {
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+
+		stalled := time.AfterFunc(policy.Interval, func() {
+			if policy.OnStall != nil {
+				policy.OnStall(e)
+			}
+			if policy.Cancel {
+				cancel(ErrStalled)
+			}
+		})
+		err := fn(ctx, e, func() {
+			stalled.Reset(policy.Interval)
+		})
+		stalled.Stop()
+
+		if errors.Is(err, context.Canceled) && context.Cause(ctx) == ErrStalled {
+			return ErrStalled
+		}
+		return err
+	}, args, opts...)
+}
@@ -0,0 +1,72 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestForEachHeartbeat(t *testing.T) {
+	work := func(ctx context.Context, n int, heartbeat func()) error {
+		for i := 0; i < 8; i++ {
+			if n != 3 {
+				heartbeat()
+			}
+			if err := Sleep(ctx, 5*time.Millisecond); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name   string
+		cancel bool
+		expect error
+	}{
+		{name: "report stalled"},
+		{name: "cancel stalled", cancel: true, expect: ErrStalled},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var mu sync.Mutex
+			var stalled []int
+			err := ForEachHeartbeat(4, HeartbeatPolicy[int]{
+				Interval: 20 * time.Millisecond,
+				OnStall: func(n int) {
+					mu.Lock()
+					stalled = append(stalled, n)
+					mu.Unlock()
+				},
+				Cancel: tt.cancel,
+			}, work, testInts[:4])
+			if err != tt.expect {
+				t.Errorf("Expected error=%v but received error=%v", tt.expect, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(stalled) != 1 || stalled[0] != 3 {
+				t.Errorf("Expected stalled=%v but received stalled=%v", []int{3}, stalled)
+			}
+		})
+	}
+}
+
+func TestForEachHeartbeatInvalidInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expected panic for interval=%v", interval)
+				}
+			}()
+			ForEachHeartbeat(1, HeartbeatPolicy[int]{Interval: interval}, func(context.Context, int, func()) error {
+				return nil
+			}, nil)
+		}()
+	}
+}
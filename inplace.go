@@ -0,0 +1,197 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// MapInPlace applys function to each element of slice, writing each result
+// back to the element. Workers claim elements by index, so no channels are
+// used and elements are processed in about the order of the slice.
+//
+// MapInPlace panics if given WithSeededOrder, which does not apply to
+// elements processed in place, or WithMaxErrors, WithMaxErrorRate or
+// WithCancelOnError, as fn does not return errors.
+func MapInPlace[I any](qlen int, fn func(I) I, s []I, opts ...Option) error {
+	return MapInPlaceWithContext(context.Background(), qlen, fn, s, opts...)
+}
+
+// MapInPlaceWithContext is MapInPlace but with a context. If the context is
+// cancelled, new elements will not be processed and the error of the context
+// is returned when all goroutines finish.
+func MapInPlaceWithContext[I any](ctx context.Context, qlen int, fn func(I) I, s []I, opts ...Option) error {
+	o := newOptions(opts)
+	o.reject("MapInPlace", optSeededOrder|optMaxErrors|optCancelOnError)
+	defer o.finished()
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
+	poolSize := qlen
+	if poolSize <= 0 {
+		poolSize = defaultPoolSize
+	}
+	if poolSize > len(s) {
+		poolSize = len(s)
+	}
+
+	var next atomic.Int64
+	var stopped atomic.Bool // an element was not processed
+	var wg sync.WaitGroup
+	wg.Add(poolSize)
+	for w := 0; w < poolSize; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(s) {
+					return
+				}
+				if ctx.Err() != nil {
+					stopped.Store(true)
+					return
+				}
+				start, err := o.pool.begin(ctx)
+				if err != nil {
+					stopped.Store(true)
+					return
+				}
+				s[i] = fn(s[i])
+				o.pool.end(start)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if stopped.Load() {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// ForEachSlice splits slice into p disjoint sub-slices of about equal length
+// and applys function to each. If p is not positive the slice is split
+// between qlen workers. Unlike ForEach an error does not stop other
+// sub-slices, and all errors are returned joined in order of the sub-slices.
+//
+// ForEachSlice panics if given WithMaxErrors, WithMaxErrorRate or
+// WithCancelOnError, as errors do not stop other sub-slices.
+func ForEachSlice[I any](qlen int, p int, fn func([]I) error, s []I, opts ...Option) error {
+	return ForEachSliceWithContext(context.Background(), qlen, p, fn, s, opts...)
+}
+
+// ForEachSliceWithContext is ForEachSlice but with a context.
+func ForEachSliceWithContext[I any](ctx context.Context, qlen int, p int, fn func([]I) error, s []I, opts ...Option) error {
+	if p <= 0 {
+		p = qlen
+		if p <= 0 {
+			p = defaultPoolSize
+		}
+	}
+	if p > len(s) {
+		p = len(s)
+	}
+	newOptions(opts).reject("ForEachSlice", optMaxErrors|optCancelOnError)
+
+	errs := make([]error, p)
+	err := ForNWithContext(ctx, qlen, p, func(i int) error {
+		lo, hi := chunk(len(s), p, i)
+		errs[i] = fn(s[lo:hi:hi])
+		return nil
+	}, opts...)
+	return errors.Join(append(errs, err)...)
+}
+
+// chunk returns the bounds of the i-th of p chunks of about equal length of n
+// elements. The first n % p chunks have one more element.
+func chunk(n, p, i int) (lo, hi int) {
+	size, rem := n/p, n%p
+	if i < rem {
+		return i * (size + 1), (i + 1) * (size + 1)
+	}
+	return i*size + rem, (i+1)*size + rem
+}
+
+// CollectByWorker is CollectUnordered but returns the results of each worker
+// in their own slice, in the order the worker processed them. Like
+// MapInPlace, workers claim arguments by index so results are not fanned in
+// over a channel.
+//
+// If an error is returned, new arguments will not be processed and execution
+// will return when all goroutines finish.
+//
+// CollectByWorker panics if given WithSeededOrder, as arguments are claimed
+// by index.
+func CollectByWorker[I any, R any](qlen int, fn func(I) (R, error), args []I, opts ...Option) ([][]R, error) {
+	return CollectByWorkerWithContext(context.Background(), qlen, fn, args, opts...)
+}
+
+// CollectByWorkerWithContext is CollectByWorker but with a context.
+func CollectByWorkerWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I, opts ...Option) ([][]R, error) {
+	o := newOptions(opts)
+	o.reject("CollectByWorker", optSeededOrder)
+	defer o.finished()
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+
+	fn = mapped(o, fn, len(args))
+
+	poolSize := qlen
+	if poolSize <= 0 {
+		poolSize = defaultPoolSize
+	}
+	if poolSize > len(args) {
+		poolSize = len(args)
+	}
+
+	results := make([][]R, poolSize)
+	var next atomic.Int64
+	var stopped atomic.Bool // an argument was not processed
+	var wg sync.WaitGroup
+	wg.Add(poolSize)
+	for w := 0; w < poolSize; w++ {
+		go func(w int) {
+			defer wg.Done()
+			rs := make([]R, 0, len(args)/poolSize+1)
+			defer func() { results[w] = rs }()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(args) {
+					return
+				}
+				if ctx.Err() != nil {
+					stopped.Store(true)
+					return
+				}
+				start, err := o.pool.begin(ctx)
+				if err != nil {
+					stopped.Store(true)
+					return
+				}
+				r, err := fn(args[i])
+				o.pool.end(start)
+				if err == ErrSkipped {
+					continue
+				} else if err != nil {
+					o.failed(err)
+					cancel()
+					return
+				}
+				rs = append(rs, r)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	o.mu.Lock()
+	err := o.err
+	o.mu.Unlock()
+	if err != nil {
+		return results, err
+	}
+	if stopped.Load() {
+		return results, ctx.Err()
+	}
+	return results, nil
+}
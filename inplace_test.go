@@ -0,0 +1,230 @@
+package goroutines
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMapInPlace(t *testing.T) {
+	s := append([]string(nil), testStrings...)
+	if err := MapInPlace(4, strings.ToUpper, s); err != nil {
+		t.Fatalf("Expected no error but received error=%v", err)
+	}
+	for i := range s {
+		if expect := strings.ToUpper(testStrings[i]); s[i] != expect {
+			t.Errorf("Expected element=%v but received element=%v", expect, s[i])
+		}
+	}
+	if err := MapInPlace(4, strings.ToUpper, nil); err != nil {
+		t.Errorf("Expected no error for empty slice but received error=%v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	n := append([]int(nil), testInts...)
+	err := MapInPlaceWithContext(ctx, 2, func(n int) int {
+		time.Sleep(5 * time.Millisecond)
+		return -n
+	}, n)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+	}
+	if n[0] != -1 || n[len(n)-1] != testInts[len(n)-1] {
+		t.Errorf("Expected partially mapped slice but received %v", n)
+	}
+}
+
+func TestForEachSlice(t *testing.T) {
+	tests := []struct {
+		name  string
+		p     int
+		sizes []int
+	}{
+		{name: "even", p: 3, sizes: []int{20, 20, 20}},
+		{name: "remainder", p: 7, sizes: []int{9, 9, 9, 9, 8, 8, 8}},
+		{name: "more parts than elements", p: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			sizes := make(map[int]int)
+			seen := make(map[int]bool)
+			err := ForEachSlice(4, tt.p, func(sub []int) error {
+				mu.Lock()
+				defer mu.Unlock()
+				sizes[len(sub)]++
+				for _, n := range sub {
+					if seen[n] {
+						t.Errorf("Expected disjoint sub-slices but received element=%v twice", n)
+					}
+					seen[n] = true
+				}
+				return nil
+			}, testInts)
+			if err != nil {
+				t.Fatalf("Expected no error but received error=%v", err)
+			}
+			if len(seen) != len(testInts) {
+				t.Errorf("Expected elements=%v but received elements=%v", len(testInts), len(seen))
+			}
+			expect := make(map[int]int)
+			for _, n := range tt.sizes {
+				expect[n]++
+			}
+			if tt.p > len(testInts) {
+				expect = map[int]int{1: len(testInts)}
+			}
+			for n, c := range expect {
+				if sizes[n] != c {
+					t.Errorf("Expected sizes=%v but received sizes=%v", expect, sizes)
+				}
+			}
+		})
+	}
+
+	err := ForEachSlice(2, 4, func(sub []int) error {
+		if sub[0] <= 30 {
+			return fmt.Errorf("sub-slice %v", sub[0])
+		}
+		return nil
+	}, testInts)
+	if err == nil || err.Error() != "sub-slice 1\nsub-slice 16" {
+		t.Errorf("Expected joined errors but received error=%v", err)
+	}
+}
+
+func TestCollectByWorker(t *testing.T) {
+	results, err := CollectByWorker(4, func(n int) (int, error) {
+		return n * 2, nil
+	}, testInts)
+	if err != nil {
+		t.Fatalf("Expected no error but received error=%v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("Expected workers=%v but received workers=%v", 4, len(results))
+	}
+	seen := make(map[int]bool)
+	for _, rs := range results {
+		for i, r := range rs {
+			if i > 0 && r <= rs[i-1] {
+				t.Errorf("Expected worker results in order of processing but received %v", rs)
+			}
+			seen[r] = true
+		}
+	}
+	if len(seen) != len(testInts) {
+		t.Errorf("Expected results=%v but received results=%v", len(testInts), len(seen))
+	}
+
+	if results, err := CollectByWorker(4, func(n int) (int, error) { return n, nil }, []int{}); err != nil || len(results) != 0 {
+		t.Errorf("Expected no results but received results=%v error=%v", results, err)
+	}
+
+	var total int
+	results, err = CollectByWorker(2, func(n int) (int, error) {
+		if n == 5 {
+			return 0, testErr
+		}
+		time.Sleep(time.Millisecond)
+		return n, nil
+	}, testInts)
+	for _, rs := range results {
+		total += len(rs)
+	}
+	if err != testErr || total >= len(testInts)-1 {
+		t.Errorf("Expected error=%v but received results=%v error=%v", testErr, total, err)
+	}
+}
+
+func TestCollectByWorkerOptions(t *testing.T) {
+	results, err := CollectByWorker(4, MapErrors(func(n int) (int, error) {
+		if n%10 == 0 {
+			return 0, testErr
+		}
+		return n, nil
+	}, func(_ int, err error) error {
+		return nil // skip
+	}), testInts)
+	var total int
+	for _, rs := range results {
+		total += len(rs)
+	}
+	if err != nil || total != 54 {
+		t.Errorf("Expected results=%v but received results=%v error=%v", 54, total, err)
+	}
+}
+
+func TestInPlaceUnsupportedOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{
+			name: "MapInPlace",
+			fn: func() {
+				_ = MapInPlace(2, func(n int) int { return n }, []int{1}, WithSeededOrder(1))
+			},
+		},
+		{
+			name: "MapInPlace WithMaxErrors",
+			fn: func() {
+				_ = MapInPlace(2, func(n int) int { return n }, []int{1}, WithMaxErrors(1))
+			},
+		},
+		{
+			name: "MapInPlace WithCancelOnError",
+			fn: func() {
+				_, opt := WithCancelOnError(context.Background())
+				_ = MapInPlace(2, func(n int) int { return n }, []int{1}, opt)
+			},
+		},
+		{
+			name: "ForEachSlice",
+			fn: func() {
+				_ = ForEachSlice(2, 2, func([]int) error { return nil }, []int{1}, WithMaxErrors(1))
+			},
+		},
+		{
+			name: "CollectByWorker",
+			fn: func() {
+				_, _ = CollectByWorker(2, func(n int) (int, error) { return n, nil }, []int{1}, WithSeededOrder(1))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expected panic for unsupported option")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
+
+func BenchmarkCollectByWorker(b *testing.B) {
+	args := make([]int, 100000)
+	for i := range args {
+		args[i] = i
+	}
+	fn := func(n int) (int, error) {
+		return n * 2, nil
+	}
+	b.Run("CollectUnordered", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = CollectUnordered(8, fn, args)
+		}
+	})
+	b.Run("CollectByWorker", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = CollectByWorker(8, fn, args)
+		}
+	})
+}
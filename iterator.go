@@ -0,0 +1,91 @@
+package goroutines
+
+import "context"
+
+// Iterator consumes results of the form returned by MapErr in the style of
+// bufio.Scanner, so that the end of results and errors are explicit.
+//
+//	it := MapErrIterator(qlen, fn, args)
+//	defer it.Close()
+//	for it.Next() {
+//		use(it.Value())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[R any] struct {
+	next   func() (R, error, bool)
+	cancel context.CancelFunc
+	v      R
+	err    error
+	done   bool
+}
+
+// NewIterator returns an Iterator over a function returning results like
+// MapErr, such as MapLines.
+func NewIterator[R any](next func() (R, error, bool)) *Iterator[R] {
+	return &Iterator[R]{next: next}
+}
+
+// MapErrIterator is MapErr but returns an Iterator.
+func MapErrIterator[I any, R any](qlen int, fn func(I) (R, error), args []I, opts ...Option) *Iterator[R] {
+	return MapErrIteratorWithContext(context.Background(), qlen, fn, args, opts...)
+}
+
+// MapErrIteratorWithContext is MapErrIterator but with a context.
+func MapErrIteratorWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I, opts ...Option) *Iterator[R] {
+	ctx, cancel := context.WithCancel(ctx)
+	it := NewIterator(MapErrWithContext(ctx, qlen, fn, args, opts...))
+	it.cancel = cancel
+	return it
+}
+
+// Next advances to the next result, returning false when results are
+// exhausted or an error occurs. Err then returns the error, if any.
+func (it *Iterator[R]) Next() bool {
+	if it.done {
+		return false
+	}
+	v, err, ok := it.next()
+	if !ok || err != nil {
+		var zero R
+		it.v, it.err, it.done = zero, err, true
+		it.stop()
+		return false
+	}
+	it.v = v
+	return true
+}
+
+// Value returns the current result.
+func (it *Iterator[R]) Value() R {
+	return it.v
+}
+
+// Err returns the error which ended iteration, or nil if results were
+// exhausted. A cancelled context is returned as the error of the context.
+func (it *Iterator[R]) Err() error {
+	return it.err
+}
+
+// Close stops iteration, cancelling remaining functions of MapErrIterator
+// and waiting for them to finish. Other iterators consume remaining results.
+// It is safe to call Close after iteration ends.
+func (it *Iterator[R]) Close() {
+	if it.done {
+		return
+	}
+	it.done = true
+	it.stop()
+	for _, _, ok := it.next(); ok; _, _, ok = it.next() {
+		// consume all remaining workers
+	}
+}
+
+// stop cancels the context of MapErrIterator, if any.
+func (it *Iterator[R]) stop() {
+	if it.cancel != nil {
+		it.cancel()
+		it.cancel = nil
+	}
+}
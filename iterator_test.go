@@ -0,0 +1,88 @@
+package goroutines
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIterator(t *testing.T) {
+	tests := []struct {
+		name   string
+		fail   int
+		ctx    func() (context.Context, context.CancelFunc)
+		expect int
+		err    error
+	}{
+		{name: "exhausted", fail: -1, expect: len(testInts)},
+		{name: "error", fail: 10, expect: 9, err: testErr},
+		{
+			name: "cancelled",
+			fail: -1,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 30*time.Millisecond)
+			},
+			err: context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.Background(), context.CancelFunc(func() {})
+			if tt.ctx != nil {
+				ctx, cancel = tt.ctx()
+			}
+			defer cancel()
+
+			it := MapErrIteratorWithContext(ctx, 4, func(n int) (int, error) {
+				if n == tt.fail {
+					return 0, testErr
+				}
+				time.Sleep(3 * time.Millisecond)
+				return n, nil
+			}, testInts)
+			defer it.Close()
+
+			n := 0
+			for it.Next() {
+				if it.Value() != testInts[n] {
+					t.Fatalf("Expected result=%v but received result=%v", testInts[n], it.Value())
+				}
+				n++
+			}
+			if it.Err() != tt.err {
+				t.Errorf("Expected error=%v but received error=%v", tt.err, it.Err())
+			}
+			if tt.ctx == nil && n != tt.expect {
+				t.Errorf("Expected results=%v but received results=%v", tt.expect, n)
+			}
+			if it.Next() {
+				t.Errorf("Expected no results after iteration ended")
+			}
+		})
+	}
+
+	var calls atomic.Int64
+	it := MapErrIterator(2, func(n int) (int, error) {
+		calls.Add(1)
+		time.Sleep(time.Millisecond)
+		return n, nil
+	}, testInts)
+	it.Next()
+	it.Close()
+	if n := calls.Load(); n >= int64(len(testInts)) {
+		t.Errorf("Expected Close to cancel remaining functions but received calls=%v", n)
+	}
+
+	lines := NewIterator(MapLines(context.Background(), 2, func(b []byte) (string, error) {
+		return strings.ToUpper(string(b)), nil
+	}, strings.NewReader("a\nb\n")))
+	var got []string
+	for lines.Next() {
+		got = append(got, lines.Value())
+	}
+	if lines.Err() != nil || strings.Join(got, "") != "AB" {
+		t.Errorf("Expected lines=AB but received lines=%v error=%v", got, lines.Err())
+	}
+}
@@ -0,0 +1,52 @@
+package goroutines
+
+import "context"
+
+// Join is a parallel inner hash join of two slices. Elements of bs are hashed
+// by keyB once, then each element of as is probed by keyA and combined with
+// every element of bs with an equal key. Results are returned in order of
+// as, then of bs.
+//
+// If an error is returned, new arguments will not be processed and execution
+// will return when all goroutines finish.
+func Join[A any, B any, K comparable, R any](qlen int, keyA func(A) K, keyB func(B) K, combine func(A, B) (R, error), as []A, bs []B, opts ...Option) ([]R, error) {
+	return JoinWithContext(context.Background(), qlen, keyA, keyB, combine, as, bs, opts...)
+}
+
+// JoinWithContext is Join but with a context.
+func JoinWithContext[A any, B any, K comparable, R any](ctx context.Context, qlen int, keyA func(A) K, keyB func(B) K, combine func(A, B) (R, error), as []A, bs []B, opts ...Option) ([]R, error) {
+	hashed := make(map[K][]B)
+	for _, b := range bs {
+		k := keyB(b)
+		hashed[k] = append(hashed[k], b)
+	}
+
+	joined, err := CollectWithContext(ctx, qlen, func(a A) ([]R, error) {
+		matches := hashed[keyA(a)]
+		if len(matches) == 0 {
+			return nil, nil
+		}
+		rs := make([]R, len(matches))
+		for i, b := range matches {
+			r, err := combine(a, b)
+			if err != nil {
+				return nil, err
+			}
+			rs[i] = r
+		}
+		return rs, nil
+	}, as, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var n int
+	for _, rs := range joined {
+		n += len(rs)
+	}
+	results := make([]R, 0, n)
+	for _, rs := range joined {
+		results = append(results, rs...)
+	}
+	return results, nil
+}
@@ -0,0 +1,47 @@
+package goroutines
+
+import (
+	"fmt"
+	"testing"
+)
+
+type testUser struct {
+	id   int
+	name string
+}
+
+type testOrder struct {
+	user int
+	item string
+}
+
+func TestJoin(t *testing.T) {
+	users := []testUser{{1, "ann"}, {2, "bob"}, {3, "cat"}}
+	orders := []testOrder{{2, "pen"}, {1, "cup"}, {2, "ink"}, {4, "hat"}}
+	userID := func(u testUser) int { return u.id }
+	orderUser := func(o testOrder) int { return o.user }
+	combine := func(u testUser, o testOrder) (string, error) {
+		if o.item == "" {
+			return "", testErr
+		}
+		return fmt.Sprintf("%s:%s", u.name, o.item), nil
+	}
+
+	r, err := Join(2, userID, orderUser, combine, users, orders)
+	expect := []string{"ann:cup", "bob:pen", "bob:ink"}
+	if err != nil || len(r) != len(expect) {
+		t.Fatalf("Expected results=%v but received results=%v error=%v", expect, r, err)
+	}
+	for i := range expect {
+		if r[i] != expect[i] {
+			t.Errorf("Expected result=%v but received result=%v", expect[i], r[i])
+		}
+	}
+
+	if r, err := Join(2, userID, orderUser, combine, users, nil); err != nil || len(r) != 0 {
+		t.Errorf("Expected no results but received results=%v error=%v", r, err)
+	}
+	if _, err := Join(2, userID, orderUser, combine, users, append(orders, testOrder{3, ""})); err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+}
@@ -0,0 +1,139 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Latch releases waiters once Done has been called n times.
+type Latch struct {
+	mu   sync.Mutex
+	n    int
+	done chan struct{}
+}
+
+// NewLatch returns a Latch which is released after n calls to Done.
+func NewLatch(n int) *Latch {
+	l := &Latch{n: n, done: make(chan struct{})}
+	if n <= 0 {
+		l.n = 0
+		close(l.done)
+	}
+	return l
+}
+
+// Done counts down the latch. Calls after it is released have no effect.
+func (l *Latch) Done() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.n == 0 {
+		return
+	}
+	l.n--
+	if l.n == 0 {
+		close(l.done)
+	}
+}
+
+// Count returns the number of calls to Done remaining.
+func (l *Latch) Count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.n
+}
+
+// Wait returns an error if the context is cancelled before the latch is
+// released.
+func (l *Latch) Wait(ctx context.Context) error {
+	select {
+	case <-l.done:
+		return nil
+	default:
+	}
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WaitTimeout returns true if the latch was released before timeout. A
+// negative timeout waits indefinitely.
+func (l *Latch) WaitTimeout(timeout time.Duration) bool {
+	if timeout < 0 {
+		return l.Wait(context.Background()) == nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return l.Wait(ctx) == nil
+}
+
+// Barrier releases n goroutines once all of them are waiting, then resets
+// for the next phase.
+type Barrier struct {
+	mu      sync.Mutex
+	n       int
+	waiting int
+	phase   chan struct{}
+}
+
+// NewBarrier returns a Barrier for n goroutines.
+func NewBarrier(n int) *Barrier {
+	if n <= 0 {
+		n = 1
+	}
+	return &Barrier{n: n, phase: make(chan struct{})}
+}
+
+// Await blocks until n goroutines are waiting, or returns an error if the
+// context is cancelled first, in which case the caller no longer counts
+// towards the current phase.
+func (b *Barrier) Await(ctx context.Context) error {
+	b.mu.Lock()
+	phase := b.phase
+	b.waiting++
+	if b.waiting == b.n {
+		b.waiting = 0
+		b.phase = make(chan struct{})
+		close(phase)
+		b.mu.Unlock()
+		return nil
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-phase:
+		return nil
+	case <-ctx.Done():
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	select {
+	case <-phase:
+		return nil // released while giving up
+	default:
+	}
+	b.waiting--
+	return ctx.Err()
+}
+
+// AwaitTimeout returns true if n goroutines were waiting before timeout. A
+// negative timeout waits indefinitely.
+func (b *Barrier) AwaitTimeout(timeout time.Duration) bool {
+	if timeout < 0 {
+		return b.Await(context.Background()) == nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return b.Await(ctx) == nil
+}
+
+// Waiting returns the number of goroutines waiting in the current phase.
+func (b *Barrier) Waiting() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.waiting
+}
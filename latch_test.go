@@ -0,0 +1,77 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLatch(t *testing.T) {
+	l := NewLatch(3)
+	if l.WaitTimeout(10 * time.Millisecond) {
+		t.Errorf("Expected wait to time out")
+	}
+	for i := 0; i < 3; i++ {
+		go l.Done()
+	}
+	if err := l.Wait(context.Background()); err != nil {
+		t.Errorf("Expected latch to be released but received error=%v", err)
+	}
+	l.Done() // no effect once released
+	if n := l.Count(); n != 0 {
+		t.Errorf("Expected count=%v but received count=%v", 0, n)
+	}
+	if !NewLatch(0).WaitTimeout(0) {
+		t.Errorf("Expected empty latch to be released")
+	}
+
+	l = NewLatch(1)
+	time.AfterFunc(10*time.Millisecond, l.Done)
+	if !l.WaitTimeout(-1) {
+		t.Errorf("Expected negative timeout to wait until released")
+	}
+}
+
+func TestBarrier(t *testing.T) {
+	const workers = 4
+	b := NewBarrier(workers)
+	var phase atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := int64(0); p < 3; p++ {
+				if n := phase.Load(); n/workers != p {
+					t.Errorf("Expected phase=%v but received phase=%v", p, n/workers)
+				}
+				if err := b.Await(context.Background()); err != nil {
+					t.Error(err)
+				}
+				phase.Add(1)
+				if err := b.Await(context.Background()); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if b.AwaitTimeout(10 * time.Millisecond) {
+		t.Errorf("Expected await to time out")
+	}
+	if n := b.Waiting(); n != 0 {
+		t.Errorf("Expected waiting=%v after timeout but received waiting=%v", 0, n)
+	}
+
+	b = NewBarrier(2)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = b.Await(context.Background())
+	}()
+	if !b.AwaitTimeout(-1) {
+		t.Errorf("Expected negative timeout to wait until released")
+	}
+}
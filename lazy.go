@@ -0,0 +1,49 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Lazy is a value computed on first use and cached until Reset. Errors are
+// not cached, so the next caller computes the value again.
+type Lazy[T any] struct {
+	mu   sync.Mutex
+	fn   func() (T, error)
+	once *Once[T]
+}
+
+// NewLazy returns a Lazy value computed by fn.
+func NewLazy[T any](fn func() (T, error)) *Lazy[T] {
+	return &Lazy[T]{
+		fn:   fn,
+		once: NewOnce(fn).WithRetry(),
+	}
+}
+
+func (l *Lazy[T]) current() *Once[T] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.once
+}
+
+// Get returns the value, computing it if needed, or an error if the context
+// is cancelled first. The computation continues in the background.
+func (l *Lazy[T]) Get(ctx context.Context) (T, error) {
+	return l.current().DoWithContext(ctx)
+}
+
+// GetTimeout is Get but returns ErrRunnerTimedout if the value is not
+// available before timeout.
+func (l *Lazy[T]) GetTimeout(timeout time.Duration) (T, error) {
+	return l.current().DoTimeout(timeout)
+}
+
+// Reset discards the cached value, so the next caller computes it again.
+// Callers waiting on a running computation still receive its result.
+func (l *Lazy[T]) Reset() {
+	l.mu.Lock()
+	l.once = NewOnce(l.fn).WithRetry()
+	l.mu.Unlock()
+}
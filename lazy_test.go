@@ -0,0 +1,39 @@
+package goroutines
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLazy(t *testing.T) {
+	var calls atomic.Int64
+	l := NewLazy(func() (int64, error) {
+		n := calls.Add(1)
+		if n == 1 {
+			return 0, testErr
+		}
+		time.Sleep(20 * time.Millisecond)
+		return n, nil
+	})
+
+	tests := []struct {
+		name   string
+		get    func() (int64, error)
+		expect int64
+		err    error
+	}{
+		{name: "error not cached", get: func() (int64, error) { return l.Get(context.Background()) }, err: testErr},
+		{name: "timeout", get: func() (int64, error) { return l.GetTimeout(time.Millisecond) }, err: ErrRunnerTimedout},
+		{name: "computed", get: func() (int64, error) { return l.Get(context.Background()) }, expect: 2},
+		{name: "cached", get: func() (int64, error) { return l.GetTimeout(0) }, expect: 2},
+		{name: "reset", get: func() (int64, error) { l.Reset(); return l.Get(context.Background()) }, expect: 3},
+	}
+	for _, tt := range tests {
+		v, err := tt.get()
+		if v != tt.expect || err != tt.err {
+			t.Errorf("%s: Expected result=%v error=%v but received result=%v error=%v", tt.name, tt.expect, tt.err, v, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package goroutines
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LeakTimeout is how long VerifyNone and Tracker.Verify wait for goroutines
+// to exit before reporting them as leaked.
+var LeakTimeout = time.Second
+
+// createdByPackage prefixes the stack trailer of goroutines started by this
+// package.
+const createdByPackage = "created by github.com/jake-dog/goroutines."
+
+// TestingT is the subset of testing.TB used to report leaked goroutines.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Tracker snapshots running goroutines so goroutines started afterwards can
+// be reported as leaked.
+type Tracker struct {
+	known map[uint64]bool
+}
+
+// TrackGoroutines snapshots the running goroutines.
+func TrackGoroutines() *Tracker {
+	tr := &Tracker{known: make(map[uint64]bool)}
+	for _, g := range stacks() {
+		tr.known[g.id] = true
+	}
+	return tr
+}
+
+// Leaked returns the stacks of goroutines started since the snapshot which
+// are still running, excluding the calling goroutine.
+func (tr *Tracker) Leaked() []string {
+	return leaked(func(g goroutineStack) bool {
+		return !tr.known[g.id]
+	})
+}
+
+// Verify fails the test if goroutines started since the snapshot are still
+// running after LeakTimeout.
+func (tr *Tracker) Verify(t TestingT) {
+	t.Helper()
+	verify(t, tr.Leaked)
+}
+
+// VerifyNone fails the test if goroutines started by this package, such as
+// Pool workers, Coalescer callers or Map dispatchers, are still running after
+// LeakTimeout. Use it at the end of a test, or with defer.
+func VerifyNone(t TestingT) {
+	t.Helper()
+	verify(t, func() []string {
+		return leaked(func(g goroutineStack) bool {
+			return strings.Contains(g.stack, createdByPackage)
+		})
+	})
+}
+
+// verify polls fn until it returns no stacks or LeakTimeout elapses.
+func verify(t TestingT, fn func() []string) {
+	t.Helper()
+	deadline := time.Now().Add(LeakTimeout)
+	wait := time.Millisecond
+	for {
+		found := fn()
+		if len(found) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Errorf("Found %d leaked goroutines:\n\n%s", len(found), strings.Join(found, "\n\n"))
+			return
+		}
+		time.Sleep(wait)
+		if wait < 100*time.Millisecond {
+			wait *= 2
+		}
+	}
+}
+
+type goroutineStack struct {
+	id    uint64
+	stack string
+}
+
+// leaked returns the stacks matching fn, excluding the calling goroutine.
+func leaked(fn func(goroutineStack) bool) []string {
+	var found []string
+	for i, g := range stacks() {
+		if i > 0 && fn(g) { // the calling goroutine is always first
+			found = append(found, g.stack)
+		}
+	}
+	return found
+}
+
+// stacks of all goroutines, starting with the calling goroutine.
+func stacks() []goroutineStack {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	var gs []goroutineStack
+	for _, s := range bytes.Split(buf, []byte("\n\n")) {
+		// goroutine 1 [running]:
+		header, _, _ := bytes.Cut(s, []byte(" ["))
+		id, err := strconv.ParseUint(string(bytes.TrimPrefix(header, []byte("goroutine "))), 10, 64)
+		if err != nil {
+			continue
+		}
+		gs = append(gs, goroutineStack{id: id, stack: string(s)})
+	}
+	return gs
+}
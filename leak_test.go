@@ -0,0 +1,63 @@
+package goroutines
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingT struct {
+	errs []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func TestTracker(t *testing.T) {
+	defer func(d time.Duration) { LeakTimeout = d }(LeakTimeout)
+	LeakTimeout = 50 * time.Millisecond
+
+	tr := TrackGoroutines()
+	for range Map(3, func(s string) string {
+		return s
+	}, testStrings) {
+	}
+	tr.Verify(t)
+
+	done := make(chan struct{})
+	go func() {
+		<-done
+	}()
+	rt := &recordingT{}
+	tr.Verify(rt)
+	if n := len(tr.Leaked()); len(rt.errs) != 1 || n != 1 {
+		t.Errorf("Expected leaked=%v but received leaked=%v errors=%v", 1, n, len(rt.errs))
+	}
+	close(done)
+	tr.Verify(t)
+}
+
+func TestVerifyNone(t *testing.T) {
+	defer func(d time.Duration) { LeakTimeout = d }(LeakTimeout)
+	LeakTimeout = 50 * time.Millisecond
+
+	tr := TrackGoroutines()
+	p := NewPool(2)
+	done := make(chan struct{})
+	f := Submit(p, func() (int, error) {
+		<-done
+		return 0, nil
+	})
+	rt := &recordingT{}
+	VerifyNone(rt)
+	if len(rt.errs) != 1 || !strings.Contains(rt.errs[0], "SubmitWithContext") {
+		t.Errorf("Expected running pool goroutine to be reported but received errors=%v", rt.errs)
+	}
+	close(done)
+	_, _ = f.Wait()
+	tr.Verify(t)
+}
@@ -0,0 +1,20 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrLeaseHeld occurs when another instance holds the lease to run a function
+var ErrLeaseHeld = errors.New("lease held by another instance")
+
+// Leaser coordinates runs of a function across processes, for example with a
+// lock in Redis or etcd, so only one instance runs the function at a time.
+type Leaser interface {
+	// TryAcquire the lease of key without waiting, returning false if it is
+	// held by another instance.
+	TryAcquire(ctx context.Context, key string) (bool, error)
+
+	// Release a lease acquired by TryAcquire.
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,81 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testLeaser struct {
+	mu   sync.Mutex
+	held map[string]bool
+	err  error
+}
+
+func (l *testLeaser) TryAcquire(_ context.Context, key string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return false, l.err
+	}
+	if l.held[key] {
+		return false, nil
+	}
+	l.held[key] = true
+	return true, nil
+}
+
+func (l *testLeaser) Release(_ context.Context, key string) error {
+	l.mu.Lock()
+	delete(l.held, key)
+	l.mu.Unlock()
+	return nil
+}
+
+func TestWithLeaser(t *testing.T) {
+	leaser := &testLeaser{held: make(map[string]bool)}
+	var calls atomic.Int64
+	release := make(chan struct{})
+	fn := func() (string, error) {
+		calls.Add(1)
+		<-release
+		return "foo", nil
+	}
+	q1 := CacheCoalesce(fn, time.Hour, 0).WithLeaser(leaser, "foo")
+	q2 := CacheCoalesce(fn, time.Hour, 0).WithLeaser(leaser, "foo")
+
+	r, _ := q1.RunChan()
+	for !q1.IsRunning() || calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := q2.Run(); err != ErrLeaseHeld {
+		t.Errorf("Expected error=%v but received error=%v", ErrLeaseHeld, err)
+	}
+	q2.PrimeWithTTL("bar", 0)
+	if v, err := q2.Run(); v != "bar" || err != nil {
+		t.Errorf("Expected stale result=%v but received result=%v error=%v", "bar", v, err)
+	}
+
+	close(release)
+	if v := <-r; v.V != "foo" || v.E != nil {
+		t.Errorf("Expected foo received=%v error=%v", v.V, v.E)
+	}
+	for q1.IsRunning() {
+		time.Sleep(time.Millisecond)
+	}
+	if v, err := q2.Run(); v != "foo" || err != nil {
+		t.Errorf("Expected foo after lease release but received result=%v error=%v", v, err)
+	}
+
+	leaser.mu.Lock()
+	leaser.err = testErr
+	leaser.mu.Unlock()
+	if v, err := q1.NoCache().Run(); v != "foo" || err != nil {
+		t.Errorf("Expected run without lease but received result=%v error=%v", v, err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("Expected calls=%v but received calls=%v", 3, n)
+	}
+}
@@ -0,0 +1,157 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// Service is a long-running component managed by a Lifecycle. Start blocks
+// while the service runs, and Stop requests that Start return.
+type Service interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// funcService adapts a function which runs until its context is cancelled.
+type funcService struct {
+	fn     func(context.Context) error
+	cancel func()
+}
+
+func (f *funcService) Start(ctx context.Context) error {
+	return f.fn(ctx)
+}
+
+func (f *funcService) Stop(_ context.Context) error {
+	f.cancel()
+	return nil
+}
+
+type lifecycleEntry struct {
+	name string
+	svc  Service
+	ctx  context.Context
+	done chan struct{}
+	err  error
+}
+
+// Lifecycle runs a group of services, shutting all of them down in reverse
+// order of registration when any fails, a signal is received, or the context
+// is cancelled.
+type Lifecycle struct {
+	timeout time.Duration
+	entries []*lifecycleEntry
+}
+
+// NewLifecycle returns a Lifecycle which allows up to timeout for all
+// services to stop, or waits indefinitely if timeout is not positive.
+func NewLifecycle(timeout time.Duration) *Lifecycle {
+	return &Lifecycle{timeout: timeout}
+}
+
+// Add a named service. Services must be added before Run.
+func (l *Lifecycle) Add(name string, svc Service) {
+	l.entries = append(l.entries, &lifecycleEntry{
+		name: name,
+		svc:  svc,
+		ctx:  context.Background(),
+	})
+}
+
+// AddFunc adds a named function which runs until its context is cancelled.
+func (l *Lifecycle) AddFunc(name string, fn func(context.Context) error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.entries = append(l.entries, &lifecycleEntry{
+		name: name,
+		svc:  &funcService{fn: fn, cancel: cancel},
+		ctx:  ctx,
+	})
+}
+
+// Run starts the services one at a time in order of registration and blocks
+// until the context is cancelled, one of the signals is received, or a
+// service fails. Services which were not started by then are skipped, and
+// the started services are stopped in reverse order. The first failure, and
+// any errors while stopping, are joined in the result.
+//
+// If the timeout expires while waiting for a service to stop, its error is
+// context.DeadlineExceeded. The remaining services are still stopped, with
+// the expired context, but Run returns without waiting for their Start to
+// return, and Start goroutines which have not returned are abandoned.
+func (l *Lifecycle) Run(ctx context.Context, signals ...os.Signal) error {
+	if len(signals) > 0 {
+		var stop func()
+		ctx, stop = signal.NotifyContext(ctx, signals...)
+		defer stop()
+	}
+
+	var errs []error
+	failed := make(chan *lifecycleEntry, len(l.entries))
+	started := 0
+	for _, e := range l.entries {
+		select {
+		case <-ctx.Done():
+		case f := <-failed:
+			errs = append(errs, fmt.Errorf("%s: %w", f.name, f.err))
+		default:
+			l.start(e, failed)
+			started++
+			continue
+		}
+		break
+	}
+
+	if errs == nil {
+		select {
+		case <-ctx.Done():
+		case e := <-failed:
+			errs = append(errs, fmt.Errorf("%s: %w", e.name, e.err))
+		}
+	}
+
+	stopCtx := context.Background()
+	if l.timeout > 0 {
+		var cancel func()
+		stopCtx, cancel = context.WithTimeout(stopCtx, l.timeout)
+		defer cancel()
+	}
+
+	var expired bool
+	for i := started - 1; i >= 0; i-- {
+		e := l.entries[i]
+		if err := e.svc.Stop(stopCtx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: stop: %w", e.name, err))
+		}
+		if expired {
+			continue // Start is abandoned
+		}
+		select {
+		case <-e.done:
+		case <-stopCtx.Done():
+			expired = true
+			errs = append(errs, fmt.Errorf("%s: %w", e.name, stopCtx.Err()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// start the service in a goroutine, and return once it has been started.
+func (l *Lifecycle) start(e *lifecycleEntry, failed chan<- *lifecycleEntry) {
+	e.done = make(chan struct{})
+	running := make(chan struct{})
+	go func() {
+		defer close(e.done)
+		e.err = protect(func() error {
+			close(running)
+			return e.svc.Start(e.ctx)
+		})
+		if e.err != nil {
+			failed <- e
+		}
+	}()
+	<-running
+}
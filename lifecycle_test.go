@@ -0,0 +1,140 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testService struct {
+	name    string
+	mu      *sync.Mutex
+	order   *[]string
+	starts  *[]string
+	stopc   chan struct{}
+	failErr error
+	stopErr error
+	hang    bool
+}
+
+func (s *testService) Start(_ context.Context) error {
+	s.mu.Lock()
+	*s.starts = append(*s.starts, s.name)
+	s.mu.Unlock()
+	if s.failErr != nil {
+		time.Sleep(10 * time.Millisecond)
+		return s.failErr
+	}
+	<-s.stopc
+	return nil
+}
+
+func (s *testService) Stop(_ context.Context) error {
+	s.mu.Lock()
+	*s.order = append(*s.order, s.name)
+	s.mu.Unlock()
+	if !s.hang {
+		close(s.stopc)
+	}
+	return s.stopErr
+}
+
+func TestLifecycle(t *testing.T) {
+	stopErr := errors.New("test stop error")
+	tests := []struct {
+		name       string
+		services   []*testService
+		cancelTime time.Duration
+		cancelled  bool
+		timeout    time.Duration
+		expectErrs []error
+		order      []string
+	}{
+		{
+			name:       "cancelled context stops in reverse order",
+			services:   []*testService{{name: "a"}, {name: "b"}, {name: "c"}},
+			cancelTime: 10 * time.Millisecond,
+			order:      []string{"fn", "c", "b", "a"},
+		},
+		{
+			name:       "first failure stops all",
+			services:   []*testService{{name: "a"}, {name: "b", failErr: testErr}, {name: "c", stopErr: stopErr}},
+			expectErrs: []error{testErr, stopErr},
+			order:      []string{"fn", "c", "b", "a"},
+		},
+		{
+			name:       "deadline bounded shutdown",
+			services:   []*testService{{name: "a"}, {name: "b", hang: true}, {name: "c"}},
+			cancelTime: 10 * time.Millisecond,
+			timeout:    20 * time.Millisecond,
+			expectErrs: []error{context.DeadlineExceeded},
+			order:      []string{"fn", "c", "b", "a"},
+		},
+		{
+			name:      "cancelled before start",
+			services:  []*testService{{name: "a"}, {name: "b"}},
+			cancelled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var order, starts []string
+			l := NewLifecycle(tt.timeout)
+			for _, s := range tt.services {
+				s.mu, s.order, s.starts, s.stopc = &mu, &order, &starts, make(chan struct{})
+				l.Add(s.name, s)
+			}
+			l.AddFunc("fn", func(ctx context.Context) error {
+				mu.Lock()
+				starts = append(starts, "fn")
+				mu.Unlock()
+				<-ctx.Done()
+				mu.Lock()
+				order = append(order, "fn")
+				mu.Unlock()
+				return nil
+			})
+
+			ctx := context.Background()
+			if tt.cancelTime > 0 {
+				var cancel func()
+				ctx, cancel = context.WithCancel(context.Background())
+				time.AfterFunc(tt.cancelTime, cancel)
+			}
+			if tt.cancelled {
+				var cancel func()
+				ctx, cancel = context.WithCancel(context.Background())
+				cancel()
+			}
+			err := l.Run(ctx)
+			for _, e := range tt.expectErrs {
+				if !errors.Is(err, e) {
+					t.Errorf("Expected error=%v in received error=%v", e, err)
+				}
+			}
+			if len(tt.expectErrs) == 0 && err != nil {
+				t.Errorf("Expected error=%v but received error=%v", nil, err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if len(order) != len(tt.order) {
+				t.Fatalf("Expected stop order=%v but received order=%v", tt.order, order)
+			}
+			for i := range order {
+				if order[i] != tt.order[i] {
+					t.Errorf("Expected stop order=%v but received order=%v", tt.order, order)
+				}
+			}
+			expectStarts := len(tt.services) + 1
+			if tt.cancelled {
+				expectStarts = 0
+			}
+			if len(starts) != expectStarts {
+				t.Errorf("Expected started=%v but received started=%v", expectStarts, len(starts))
+			}
+		})
+	}
+}
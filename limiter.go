@@ -0,0 +1,166 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter limits the rate of events.
+type Limiter interface {
+	// Allow reports whether an event may happen now, consuming a token.
+	Allow() bool
+
+	// Wait blocks until an event may happen, or returns an error if the
+	// context is cancelled first.
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket is a Limiter which allows events at rate per second, with
+// bursts of up to burst events.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	clock  Clock
+}
+
+var _ Limiter = (*TokenBucket)(nil)
+
+// NewTokenBucket returns a full TokenBucket allowing rate events per second
+// and bursts of up to burst events. Events are unlimited when rate is not
+// positive.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		clock:  RealClock,
+	}
+}
+
+// NewLeakyBucket returns a Limiter spacing events evenly at rate per second,
+// without bursts.
+func NewLeakyBucket(rate float64) *TokenBucket {
+	return NewTokenBucket(rate, 1)
+}
+
+// WithClock sets the Clock of the bucket, such as a FakeClock in tests.
+func (b *TokenBucket) WithClock(clock Clock) *TokenBucket {
+	b.mu.Lock()
+	b.clock = clock
+	b.last = time.Time{}
+	b.mu.Unlock()
+	return b
+}
+
+// advance refills tokens up to now, must be called with lock held.
+func (b *TokenBucket) advance(now time.Time) {
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+}
+
+// Allow reports whether an event may happen now, consuming a token.
+func (b *TokenBucket) Allow() bool {
+	if b.rate <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance(b.clock.Now())
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Reservation is a token reserved by TokenBucket.Reserve.
+type Reservation struct {
+	b    *TokenBucket
+	at   time.Time
+	once sync.Once
+}
+
+// Delay returns how long to wait before the reserved event may happen.
+func (r *Reservation) Delay() time.Duration {
+	if r.b == nil {
+		return 0
+	}
+	r.b.mu.Lock()
+	now := r.b.clock.Now()
+	r.b.mu.Unlock()
+	if d := r.at.Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Cancel returns the reserved token to the bucket, if the event did not
+// happen. The bucket never holds more than burst tokens.
+func (r *Reservation) Cancel() {
+	if r.b == nil {
+		return
+	}
+	r.once.Do(func() {
+		r.b.mu.Lock()
+		r.b.advance(r.b.clock.Now())
+		r.b.tokens++
+		if r.b.tokens > r.b.burst {
+			r.b.tokens = r.b.burst
+		}
+		r.b.mu.Unlock()
+	})
+}
+
+// Reserve a token, which may not be available yet. The event may happen after
+// the Delay of the Reservation.
+func (b *TokenBucket) Reserve() *Reservation {
+	if b.rate <= 0 {
+		return &Reservation{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clock.Now()
+	b.advance(now)
+	b.tokens--
+	at := now
+	if b.tokens < 0 {
+		at = now.Add(time.Duration(-b.tokens / b.rate * float64(time.Second)))
+	}
+	return &Reservation{b: b, at: at}
+}
+
+// Wait blocks until an event may happen, or returns an error if the context
+// is cancelled first, in which case no token is consumed.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	r := b.Reserve()
+	d := r.Delay()
+	if d == 0 {
+		return nil
+	}
+	b.mu.Lock()
+	clock := b.clock
+	b.mu.Unlock()
+	if err := sleep(ctx, clock, d); err != nil {
+		r.Cancel()
+		return err
+	}
+	return nil
+}
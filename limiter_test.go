@@ -0,0 +1,91 @@
+package goroutines
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	b := NewTokenBucket(10, 3).WithClock(clock)
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		allowed int
+	}{
+		{name: "burst", allowed: 3},
+		{name: "partial refill", advance: 150 * time.Millisecond, allowed: 1},
+		{name: "refill capped at burst", advance: time.Hour, allowed: 3},
+	}
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		n := 0
+		for b.Allow() {
+			n++
+		}
+		if n != tt.allowed {
+			t.Errorf("%s: Expected allowed=%v but received allowed=%v", tt.name, tt.allowed, n)
+		}
+	}
+
+	r := b.Reserve()
+	if d := r.Delay(); d != 100*time.Millisecond {
+		t.Errorf("Expected delay=%v but received delay=%v", 100*time.Millisecond, d)
+	}
+	r.Cancel()
+	r.Cancel() // idempotent
+
+	done := make(chan error)
+	go func() {
+		done <- b.Wait(context.Background())
+	}()
+	for clock.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(100 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Errorf("Expected wait to succeed but received error=%v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		done <- b.Wait(ctx)
+	}()
+	for clock.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected error=%v but received error=%v", context.Canceled, err)
+	}
+	clock.Advance(100 * time.Millisecond)
+	if !b.Allow() || b.Allow() {
+		t.Errorf("Expected cancelled wait to return its token")
+	}
+
+	r = b.Reserve()
+	clock.Advance(time.Hour)
+	b.Reserve().Cancel()
+	r.Cancel()
+	if b.tokens != 3 {
+		t.Errorf("Expected cancel capped at burst tokens=%v but received tokens=%v", 3, b.tokens)
+	}
+}
+
+func TestLeakyBucket(t *testing.T) {
+	b := NewLeakyBucket(100)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := b.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond || elapsed > allowedVariance+40*time.Millisecond {
+		t.Errorf("Expected events spaced evenly over %v but took=%v", 40*time.Millisecond, elapsed)
+	}
+	if !NewTokenBucket(0, 0).Allow() {
+		t.Errorf("Expected unlimited bucket to allow events")
+	}
+}
@@ -0,0 +1,66 @@
+package goroutines
+
+import (
+	"context"
+)
+
+type envelope[T any, R any] struct {
+	msg T
+	f   *Future[R]
+}
+
+// Mailbox processes messages one at a time in a single goroutine, so state
+// owned by the handler function is accessed serially without locks.
+type Mailbox[T any, R any] struct {
+	q *Queue[envelope[T, R]]
+}
+
+// NewMailbox returns a running Mailbox which buffers up to capacity messages
+// and handles each with fn.
+func NewMailbox[T any, R any](capacity int, fn func(T) (R, error)) *Mailbox[T, R] {
+	return &Mailbox[T, R]{
+		q: NewQueue(capacity, 1, func(e envelope[T, R]) {
+			var v R
+			err := protect(func() (err error) {
+				v, err = fn(e.msg)
+				return
+			})
+			if e.f != nil {
+				e.f.resolve(v, err)
+			}
+		}),
+	}
+}
+
+// Send a message without waiting for the result.
+func (m *Mailbox[T, R]) Send(msg T) error {
+	return m.q.Submit(envelope[T, R]{msg: msg})
+}
+
+// SendWithContext is Send but waiting for buffer space may be aborted by the
+// context.
+func (m *Mailbox[T, R]) SendWithContext(ctx context.Context, msg T) error {
+	return m.q.SubmitWithContext(ctx, envelope[T, R]{msg: msg})
+}
+
+// Call sends a message and returns a Future of the handler's result.
+func (m *Mailbox[T, R]) Call(msg T) *Future[R] {
+	return m.CallWithContext(context.Background(), msg)
+}
+
+// CallWithContext is Call but waiting for buffer space may be aborted by the
+// context.
+func (m *Mailbox[T, R]) CallWithContext(ctx context.Context, msg T) *Future[R] {
+	f := newFuture[R]()
+	if err := m.q.SubmitWithContext(ctx, envelope[T, R]{msg: msg, f: f}); err != nil {
+		var v R
+		f.resolve(v, err)
+	}
+	return f
+}
+
+// Close stops accepting messages and waits for buffered messages to be
+// handled.
+func (m *Mailbox[T, R]) Close() {
+	m.q.Close()
+}
@@ -0,0 +1,74 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMailbox(t *testing.T) {
+	// Unsynchronized state owned by the handler
+	counts := make(map[string]int)
+	m := NewMailbox(5, func(s string) (int, error) {
+		if s == "panic" {
+			panic("test panic")
+		}
+		if s == "" {
+			return 0, testErr
+		}
+		counts[s]++
+		return counts[s], nil
+	})
+
+	futures := make([]*Future[int], 0, len(testStrings))
+	for _, s := range testStrings {
+		if err := m.Send(s); err != nil {
+			t.Fatalf("Unexpected error=%v", err)
+		}
+		futures = append(futures, m.Call(s))
+	}
+	for i, f := range futures {
+		v, err := f.Wait()
+		if err != nil {
+			t.Errorf("Unexpected error=%v", err)
+		}
+		expect := 2
+		if i >= len(testStrings)/2 {
+			expect = 4
+		}
+		if v != expect {
+			t.Errorf("Expected count=%v for %v but received count=%v", expect, testStrings[i], v)
+		}
+	}
+
+	if _, err := m.Call("").Wait(); err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+	var perr *PanicError
+	if _, err := m.Call("panic").Wait(); !errors.As(err, &perr) {
+		t.Errorf("Expected panic error but received error=%v", err)
+	}
+
+	m.Close()
+	if err := m.Send("a"); err != ErrQueueClosed {
+		t.Errorf("Expected error=%v but received error=%v", ErrQueueClosed, err)
+	}
+	if _, err := m.Call("a").Wait(); err != ErrQueueClosed {
+		t.Errorf("Expected error=%v but received error=%v", ErrQueueClosed, err)
+	}
+}
+
+func TestFutureWaitWithContext(t *testing.T) {
+	f := newFuture[string]()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.WaitWithContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+	}
+	f.resolve("foo", nil)
+	<-f.Done()
+	if v, err := f.WaitWithContext(context.Background()); v != "foo" || err != nil {
+		t.Errorf("Expected foo received=%v error=%v", v, err)
+	}
+}
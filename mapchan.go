@@ -0,0 +1,266 @@
+package goroutines
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sync"
+)
+
+type chanJob[I any, R any] struct {
+	e   I
+	out chan R
+}
+
+// MapChan applys function to each element received from in, returning a
+// channel of results in the order received, or as they complete with
+// WithOrder(Unordered). At most qlen elements are processed or buffered at
+// once. The results channel is closed when in is closed and all results are
+// consumed, or the context is cancelled.
+//
+// Elements of a channel cannot be shuffled before they are received, so
+// MapChan panics with WithSeededOrder, and with options of mapping functions
+// over slices, which are WithMaxErrors, WithMaxErrorRate and WithPool.
+func MapChan[I any, R any](ctx context.Context, qlen int, fn func(I) R, in <-chan I, opts ...Option) <-chan R {
+	o := newOptions(opts)
+	o.reject("MapChan", streamRejects)
+	return mapChan(ctx, qlen, fn, in, o)
+}
+
+// MapChanUnordered is MapChan but results are returned as they complete.
+func MapChanUnordered[I any, R any](ctx context.Context, qlen int, fn func(I) R, in <-chan I, opts ...Option) <-chan R {
+	return MapChan(ctx, qlen, fn, in, unordered(opts)...)
+}
+
+// streamRejects are the options which mapping functions over channels and
+// readers do not support.
+const streamRejects = optSeededOrder | optMaxErrors | optPool
+
+// mapChan maps elements received from in by the OrderPolicy of o.
+func mapChan[I any, R any](ctx context.Context, qlen int, fn func(I) R, in <-chan I, o *options) <-chan R {
+	if o.timeout > 0 && !o.timed {
+		ctx, o.stop = o.context(ctx) // cancelled when results are closed
+	}
+	if o.order.ordered {
+		return mapChanOrdered(ctx, qlen, fn, in, o)
+	}
+	return mapChanUnordered(ctx, qlen, fn, in, o)
+}
+
+func mapChanOrdered[I any, R any](ctx context.Context, qlen int, fn func(I) R, in <-chan I, o *options) <-chan R {
+	poolSize := qlen
+	if poolSize <= 0 {
+		poolSize = defaultPoolSize
+	}
+
+	jobs := make(chan chanJob[I, R])
+	pending := make(chan chan R, poolSize)
+	results := make(chan R)
+
+	var wg sync.WaitGroup
+	wg.Add(poolSize)
+	for i := 0; i < poolSize; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				j.out <- fn(j.e)
+			}
+		}()
+	}
+
+	go func() { // dispatch elements in order
+		defer close(pending)
+		defer close(jobs)
+		for {
+			var e I
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok = <-in:
+				if !ok {
+					return
+				}
+			}
+			out := make(chan R, 1)
+			select {
+			case <-ctx.Done():
+				return
+			case pending <- out: // bounds elements buffered for ordering
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- chanJob[I, R]{e, out}:
+			}
+		}
+	}()
+
+	go func() { // collect results in order
+		defer o.finished()
+		defer o.stopped()
+		defer close(results)
+		defer wg.Wait()
+		for out := range pending {
+			var r R
+			select {
+			case <-ctx.Done():
+				return
+			case r = <-out:
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case results <- r:
+			}
+		}
+	}()
+
+	return results
+}
+
+func mapChanUnordered[I any, R any](ctx context.Context, qlen int, fn func(I) R, in <-chan I, o *options) <-chan R {
+	poolSize := qlen
+	if poolSize <= 0 {
+		poolSize = defaultPoolSize
+	}
+
+	results := make(chan R, poolSize)
+	var wg sync.WaitGroup
+	wg.Add(poolSize)
+	for i := 0; i < poolSize; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				var e I
+				var ok bool
+				select {
+				case <-ctx.Done():
+					return
+				case e, ok = <-in:
+					if !ok {
+						return
+					}
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case results <- fn(e):
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+		o.stopped()
+		o.finished()
+	}()
+	return results
+}
+
+// MapLines applys function to each line read from r, returning results in
+// the order of lines, or as they complete with WithOrder(Unordered). Call the
+// returned function until bool is false to consume all results. If an error
+// is returned, new lines will not be processed, and an error reading r is
+// returned after all results. Options are supported as by MapChan.
+func MapLines[R any](ctx context.Context, qlen int, fn func([]byte) (R, error), r io.Reader, opts ...Option) func() (R, error, bool) {
+	o := newOptions(opts)
+	o.reject("MapLines", streamRejects)
+	return mapLines(ctx, qlen, fn, r, o)
+}
+
+// MapLinesUnordered is MapLines but results are returned as they complete.
+func MapLinesUnordered[R any](ctx context.Context, qlen int, fn func([]byte) (R, error), r io.Reader, opts ...Option) func() (R, error, bool) {
+	return MapLines(ctx, qlen, fn, r, unordered(opts)...)
+}
+
+func mapLines[R any](ctx context.Context, qlen int, fn func([]byte) (R, error), r io.Reader, o *options) func() (R, error, bool) {
+	scanner := bufio.NewScanner(r)
+	return mapReader(ctx, qlen, fn, func() ([]byte, error) {
+		if scanner.Scan() {
+			return append([]byte(nil), scanner.Bytes()...), nil
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}, o)
+}
+
+// mapReader applys function to each element returned by read until it
+// returns an error, by the OrderPolicy of o. io.EOF is not returned to the
+// caller.
+func mapReader[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), read func() (I, error), o *options) func() (R, error, bool) {
+	ctx, stop := o.context(ctx)
+	cancel := func() {
+		stop()
+		o.finished()
+	}
+
+	var readErr error
+	elems := make(chan I)
+	go func() {
+		defer close(elems)
+		for {
+			e, err := read()
+			if err != nil {
+				if err != io.EOF {
+					readErr = err // read after elems is closed
+				}
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case elems <- e:
+			}
+		}
+	}()
+
+	results := mapChan(ctx, qlen, func(e I) *F[R] {
+		v, err := fn(e)
+		if err != nil {
+			o.failed(err)
+		}
+		return NewF(v, err)
+	}, elems, o)
+
+	return chanIterator(ctx, cancel, results, func() error {
+		for range elems {
+			// wait for reader to stop
+		}
+		return readErr
+	})
+}
+
+// chanIterator returns results like MapErr, followed by the error of final,
+// if any, once results are consumed.
+func chanIterator[R any](ctx context.Context, cancel func(), results <-chan *F[R], final func() error) func() (R, error, bool) {
+	var done bool
+	return func() (vn R, errn error, ok bool) {
+		if done {
+			return
+		}
+		var r *F[R]
+		r, ok = <-results
+		if !ok {
+			done = true
+			err := ctx.Err()
+			cancel()
+			if err == nil {
+				err = final()
+			}
+			return vn, err, err != nil
+		}
+		vn, errn = r.Return()
+		if errn != nil {
+			cancel()
+			for range results {
+				// consume all remaining workers
+			}
+			done = true
+		}
+		return vn, errn, ok
+	}
+}
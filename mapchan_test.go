@@ -0,0 +1,198 @@
+package goroutines
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type errReader struct{}
+
+func (errReader) Read(_ []byte) (int, error) {
+	return 0, testErr
+}
+
+func TestMapChan(t *testing.T) {
+	double := func(n int) int {
+		time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+		return n * 2
+	}
+	feed := func() <-chan int {
+		in := make(chan int)
+		go func() {
+			defer close(in)
+			for _, n := range testInts {
+				in <- n
+			}
+		}()
+		return in
+	}
+
+	var got []int
+	for r := range MapChan(context.Background(), 4, double, feed()) {
+		got = append(got, r)
+	}
+	if len(got) != len(testInts) {
+		t.Fatalf("Expected results=%v but received results=%v", len(testInts), len(got))
+	}
+	for i, n := range testInts {
+		if got[i] != n*2 {
+			t.Errorf("Expected result=%v at index=%v but received result=%v", n*2, i, got[i])
+		}
+	}
+
+	got = got[:0]
+	for r := range MapChanUnordered(context.Background(), 4, double, feed()) {
+		got = append(got, r)
+	}
+	sort.Ints(got)
+	for i, n := range testInts {
+		if got[i] != n*2 {
+			t.Errorf("Expected result=%v but received result=%v", n*2, got[i])
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := MapChan(ctx, 4, double, make(chan int))
+	cancel()
+	if _, ok := <-results; ok {
+		t.Errorf("Expected results closed after cancel")
+	}
+}
+
+func TestMapChanOptions(t *testing.T) {
+	in := make(chan int, len(testInts))
+	for _, n := range testInts {
+		in <- n
+	}
+	close(in)
+	var got []int
+	for r := range MapChan(context.Background(), 4, func(n int) int { return n }, in, WithOrder(Unordered)) {
+		got = append(got, r)
+	}
+	if len(got) != len(testInts) {
+		t.Errorf("Expected results=%v but received results=%v", len(testInts), len(got))
+	}
+
+	next := MapLines(context.Background(), 1, func(b []byte) (string, error) {
+		time.Sleep(50 * time.Millisecond)
+		return string(b), nil
+	}, strings.NewReader("a\nb\nc\n"), WithOperationTimeout(10*time.Millisecond))
+	var err error
+	for _, e, ok := next(); ok; _, e, ok = next() {
+		err = e
+	}
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+	}
+
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{
+			name: "MapChan WithMaxErrors",
+			fn: func() {
+				MapChan(context.Background(), 2, func(n int) int { return n }, make(chan int), WithMaxErrors(1))
+			},
+		},
+		{
+			name: "MapLines WithSeededOrder",
+			fn: func() {
+				MapLines(context.Background(), 2, func(b []byte) ([]byte, error) { return b, nil },
+					strings.NewReader(""), WithSeededOrder(1))
+			},
+		},
+		{
+			name: "ProcessJSONL WithPool",
+			fn: func() {
+				_ = ProcessJSONL(context.Background(), 2, func(n int) (int, error) { return n, nil },
+					strings.NewReader(""), func(int) error { return nil }, WithPool(NewPool(1)))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expected panic for unsupported option")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
+
+func TestMapLines(t *testing.T) {
+	var input strings.Builder
+	for _, n := range testInts {
+		input.WriteString(strconv.Itoa(n) + "\n")
+	}
+	parse := func(b []byte) (int, error) {
+		time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+		if bytes.Equal(b, []byte("bad")) {
+			return 0, errors.New("bad line")
+		}
+		return strconv.Atoi(string(b))
+	}
+
+	tests := []struct {
+		name    string
+		ordered bool
+	}{
+		{name: "ordered", ordered: true},
+		{name: "unordered", ordered: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapFn := MapLinesUnordered[int]
+			if tt.ordered {
+				mapFn = MapLines[int]
+			}
+			var got []int
+			next := mapFn(context.Background(), 4, parse, strings.NewReader(input.String()))
+			for v, err, ok := next(); ok; v, err, ok = next() {
+				if err != nil {
+					t.Fatalf("Expected no error but received error=%v", err)
+				}
+				got = append(got, v)
+			}
+			if !tt.ordered {
+				sort.Ints(got)
+			}
+			if len(got) != len(testInts) {
+				t.Fatalf("Expected results=%v but received results=%v", len(testInts), len(got))
+			}
+			for i, n := range testInts {
+				if got[i] != n {
+					t.Errorf("Expected result=%v at index=%v but received result=%v", n, i, got[i])
+				}
+			}
+
+			next = mapFn(context.Background(), 4, parse, strings.NewReader("1\nbad\n3\n"))
+			var err error
+			for _, e, ok := next(); ok; _, e, ok = next() {
+				if e != nil {
+					err = e
+				}
+			}
+			if err == nil || err.Error() != "bad line" {
+				t.Errorf("Expected error=bad line but received error=%v", err)
+			}
+
+			next = mapFn(context.Background(), 4, parse, errReader{})
+			if _, err, ok := next(); !ok || err != testErr {
+				t.Errorf("Expected read error=%v but received error=%v", testErr, err)
+			}
+			if _, _, ok := next(); ok {
+				t.Errorf("Expected no results after read error")
+			}
+		})
+	}
+}
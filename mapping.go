@@ -5,7 +5,10 @@ package goroutines
 import (
 	"context"
 	"errors"
+	"math/rand"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 var (
@@ -40,14 +43,95 @@ func (p *F[T]) Return() (T, error) {
 	return p.V, p.E
 }
 
+// source of arguments by index, so generated arguments need no slice
+type source[I any] struct {
+	n  int
+	at func(int) I
+}
+
+func sliceSource[I any](args []I) source[I] {
+	return source[I]{len(args), func(i int) I { return args[i] }}
+}
+
+func rangeSource(n int) source[int] {
+	if n < 0 {
+		n = 0
+	}
+	return source[int]{n, func(i int) int { return i }}
+}
+
+// shuffledSource orders arguments by a pseudo-random permutation of seed.
+func shuffledSource[I any](args source[I], seed int64) source[I] {
+	idx := rand.New(rand.NewSource(seed)).Perm(args.n)
+	return source[I]{args.n, func(i int) I { return args.at(idx[i]) }}
+}
+
+// errSignal is raised by the first error of a function, after which new
+// arguments are not dispatched.
+type errSignal struct {
+	once sync.Once
+	c    chan struct{}
+}
+
+func newErrSignal() *errSignal {
+	return &errSignal{c: make(chan struct{})}
+}
+
+func (s *errSignal) raise() {
+	s.once.Do(func() { close(s.c) })
+}
+
+// done returns a channel closed when raised, or nil if s is nil.
+func (s *errSignal) done() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
+	return s.c
+}
+
 type runnable[I any, R any] struct {
 	f       func(any) any
 	input   chan I
+	shards  []chan I // input of each worker when sharded
+	route   func(I) int
 	output  chan R
 	workers int
+	pool    *Pool
+	keep    func(R) bool // suppresses duplicate results if set
+}
+
+// shard the input of n workers by route.
+func (r *runnable[I, R]) shard(n int, route func(I) int) {
+	if route == nil {
+		return
+	}
+	r.route = route
+	r.shards = make([]chan I, n)
+	for i := range r.shards {
+		r.shards[i] = make(chan I, r.workers) // never blocks ordered mapping
+	}
 }
 
-func (r *runnable[I, R]) run(ctx context.Context, wg *sync.WaitGroup) {
+// in returns the input channel of the worker for an argument.
+func (r *runnable[I, R]) in(d I) chan I {
+	if r.route == nil {
+		return r.input
+	}
+	return r.shards[r.route(d)]
+}
+
+func (r *runnable[I, R]) closeInput() {
+	close(r.input)
+	for _, c := range r.shards {
+		close(c)
+	}
+}
+
+func (r *runnable[I, R]) run(ctx context.Context, wg *sync.WaitGroup, w int) {
+	input := r.input
+	if r.shards != nil {
+		input = r.shards[w]
+	}
 OuterLoop:
 	for {
 		var d I
@@ -55,17 +139,28 @@ OuterLoop:
 		select {
 		case <-ctx.Done():
 			break OuterLoop
-		case d, ok = <-r.input:
+		case d, ok = <-input:
 			if !ok {
 				break OuterLoop
 			}
 		}
 
+		start, err := r.pool.begin(ctx)
+		if err != nil {
+			break OuterLoop
+		}
+		v := r.f(d).(R)
+		t := r.pool.end(start)
+		if r.keep != nil && !r.keep(v) {
+			continue // duplicate result
+		}
+
 		select {
 		case <-ctx.Done():
 			break OuterLoop
-		case r.output <- r.f(d).(R):
+		case r.output <- v:
 		}
+		r.pool.delivered(t)
 	}
 	wg.Done()
 }
@@ -85,13 +180,13 @@ func newRunnable[I any, R any](qlen int, fn func(I) R) *runnable[I, R] {
 // All results must be consumed or goroutines may leak.
 //
 // MapWithContext is preferred in cases where all results are not consumed.
-func Map[I any, R any](qlen int, fn func(I) R, args []I) <-chan R {
-	return MapWithContext(context.Background(), qlen, fn, args)
+func Map[I any, R any](qlen int, fn func(I) R, args []I, opts ...Option) <-chan R {
+	return MapWithContext(context.Background(), qlen, fn, args, opts...)
 }
 
 // MapUnordered is Map but results are returned as they complete.
-func MapUnordered[I any, R any](qlen int, fn func(I) R, args []I) <-chan R {
-	return MapUnorderedWithContext(context.Background(), qlen, fn, args)
+func MapUnordered[I any, R any](qlen int, fn func(I) R, args []I, opts ...Option) <-chan R {
+	return MapUnorderedWithContext(context.Background(), qlen, fn, args, opts...)
 }
 
 // MapErr is an error aware Map.
@@ -101,39 +196,39 @@ func MapUnordered[I any, R any](qlen int, fn func(I) R, args []I) <-chan R {
 // Call the returned function until bool is false to consume all results.
 // If an error is returned, new arguments will not be processed and execution
 // will return when all goroutines finish.
-func MapErr[I any, R any](qlen int, fn func(I) (R, error), args []I) func() (R, error, bool) {
-	return MapErrWithContext(context.Background(), qlen, fn, args)
+func MapErr[I any, R any](qlen int, fn func(I) (R, error), args []I, opts ...Option) func() (R, error, bool) {
+	return MapErrWithContext(context.Background(), qlen, fn, args, opts...)
 }
 
 // MapErrUnordered is MapErr but results are returned as they complete.
-func MapErrUnordered[I any, R any](qlen int, fn func(I) (R, error), args []I) func() (R, error, bool) {
-	return MapErrUnorderedWithContext(context.Background(), qlen, fn, args)
+func MapErrUnordered[I any, R any](qlen int, fn func(I) (R, error), args []I, opts ...Option) func() (R, error, bool) {
+	return MapErrUnorderedWithContext(context.Background(), qlen, fn, args, opts...)
 }
 
 // ForEach applys function to each element of slice.
 //
 // If an error is returned, new arguments will not be processed and execution
 // will return when all goroutines finish.
-func ForEach[I any](qlen int, fn func(I) error, args []I) error {
-	return ForEachWithContext(context.Background(), qlen, fn, args)
+func ForEach[I any](qlen int, fn func(I) error, args []I, opts ...Option) error {
+	return ForEachWithContext(context.Background(), qlen, fn, args, opts...)
 }
 
 // ForEachUnordered is ForEach but elements are processed in random order.
-func ForEachUnordered[I any](qlen int, fn func(I) error, args []I) error {
-	return ForEachUnorderedWithContext(context.Background(), qlen, fn, args)
+func ForEachUnordered[I any](qlen int, fn func(I) error, args []I, opts ...Option) error {
+	return ForEachUnorderedWithContext(context.Background(), qlen, fn, args, opts...)
 }
 
 // Search with Map, returning the result if ErrSearchSuccess.
 //
 // If an error is returned, new arguments will not be processed and execution
 // will return when all goroutines finish.
-func Search[I any, R any](qlen int, fn func(I) (R, error), args []I) (R, error) {
-	return SearchWithContext(context.Background(), qlen, fn, args)
+func Search[I any, R any](qlen int, fn func(I) (R, error), args []I, opts ...Option) (R, error) {
+	return SearchWithContext(context.Background(), qlen, fn, args, opts...)
 }
 
 // SearchUnordered is Search but results are searched as they complete.
-func SearchUnordered[I any, R any](qlen int, fn func(I) (R, error), args []I) (R, error) {
-	return SearchUnorderedWithContext(context.Background(), qlen, fn, args)
+func SearchUnordered[I any, R any](qlen int, fn func(I) (R, error), args []I, opts ...Option) (R, error) {
+	return SearchUnorderedWithContext(context.Background(), qlen, fn, args, opts...)
 }
 
 // Reduce returns a single value as the result of Map
@@ -141,43 +236,59 @@ func SearchUnordered[I any, R any](qlen int, fn func(I) (R, error), args []I) (R
 //
 // If an error is returned, new arguments will not be processed and execution
 // will return when all goroutines finish.
-func Reduce[I any, R any](qlen int, fn func(I) (R, error), fni func(R, R) (R, error), args []I) (R, error) {
-	return ReduceWithContext(context.Background(), qlen, fn, fni, args)
+func Reduce[I any, R any](qlen int, fn func(I) (R, error), fni func(R, R) (R, error), args []I, opts ...Option) (R, error) {
+	return ReduceWithContext(context.Background(), qlen, fn, fni, args, opts...)
 }
 
 // ReduceUnordered is Reduce but results are processed as they complete.
-func ReduceUnordered[I any, R any](qlen int, fn func(I) (R, error), fni func(R, R) (R, error), args []I) (R, error) {
-	return ReduceUnorderedWithContext(context.Background(), qlen, fn, fni, args)
+func ReduceUnordered[I any, R any](qlen int, fn func(I) (R, error), fni func(R, R) (R, error), args []I, opts ...Option) (R, error) {
+	return ReduceUnorderedWithContext(context.Background(), qlen, fn, fni, args, opts...)
 }
 
 // Collect is Map but returns a slice instead of a channel.
 //
 // If an error is returned, new arguments will not be processed and execution
 // will return when all goroutines finish.
-func Collect[I any, R any](qlen int, fn func(I) (R, error), args []I) ([]R, error) {
-	return CollectWithContext(context.Background(), qlen, fn, args)
+func Collect[I any, R any](qlen int, fn func(I) (R, error), args []I, opts ...Option) ([]R, error) {
+	return CollectWithContext(context.Background(), qlen, fn, args, opts...)
 }
 
 // CollectWithContext is Collect but with a context.
-func CollectWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I) ([]R, error) {
-	return InjectWithContext(ctx, qlen, make([]R, 0, len(args)), fn, func(a []R, b R) ([]R, error) {
-		return append(a, b), nil
-	}, args)
+func CollectWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I, opts ...Option) ([]R, error) {
+	return collect(ctx, qlen, fn, sliceSource(args), newOptions(opts), nil)
+}
+
+// CollectInto is Collect but writes each result into dst by the index of its
+// argument, reusing dst if it has capacity for all results. Results are
+// written as they complete, so on error dst contains the results of any
+// functions which completed.
+func CollectInto[I any, R any](dst []R, qlen int, fn func(I) (R, error), args []I, opts ...Option) ([]R, error) {
+	return CollectIntoWithContext(context.Background(), dst, qlen, fn, args, opts...)
+}
+
+// CollectIntoWithContext is CollectInto but with a context.
+func CollectIntoWithContext[I any, R any](ctx context.Context, dst []R, qlen int, fn func(I) (R, error), args []I, opts ...Option) ([]R, error) {
+	if cap(dst) < len(args) {
+		dst = make([]R, len(args))
+	}
+	dst = dst[:len(args)]
+	return dst, ForNWithContext(ctx, qlen, len(args), func(i int) (err error) {
+		dst[i], err = fn(args[i])
+		return
+	}, unordered(opts)...)
 }
 
 // CollectUnordered is MapUnordered but returns a slice instead of a channel.
 //
 // If an error is returned, new arguments will not be processed and execution
 // will return when all goroutines finish.
-func CollectUnordered[I any, R any](qlen int, fn func(I) (R, error), args []I) ([]R, error) {
-	return CollectUnorderedWithContext(context.Background(), qlen, fn, args)
+func CollectUnordered[I any, R any](qlen int, fn func(I) (R, error), args []I, opts ...Option) ([]R, error) {
+	return CollectUnorderedWithContext(context.Background(), qlen, fn, args, opts...)
 }
 
 // CollectUnorderedWithContext is CollectUnordered but with a context.
-func CollectUnorderedWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I) ([]R, error) {
-	return InjectUnorderedWithContext(ctx, qlen, make([]R, 0, len(args)), fn, func(a []R, b R) ([]R, error) {
-		return append(a, b), nil
-	}, args)
+func CollectUnorderedWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I, opts ...Option) ([]R, error) {
+	return CollectWithContext(ctx, qlen, fn, args, unordered(opts)...)
 }
 
 // Inject is like Reduce except an initial value can be supplied.
@@ -185,115 +296,203 @@ func CollectUnorderedWithContext[I any, R any](ctx context.Context, qlen int, fn
 //
 // If an error is returned, new arguments will not be processed and execution
 // will return when all goroutines finish.
-func Inject[I any, R any, A any](qlen int, a A, fn func(I) (R, error), fni func(A, R) (A, error), args []I) (A, error) {
-	return InjectWithContext(context.Background(), qlen, a, fn, fni, args)
+func Inject[I any, R any, A any](qlen int, a A, fn func(I) (R, error), fni func(A, R) (A, error), args []I, opts ...Option) (A, error) {
+	return InjectWithContext(context.Background(), qlen, a, fn, fni, args, opts...)
 }
 
 // InjectUnordered is Inject but results are processed as they complete.
-func InjectUnordered[I any, R any, A any](qlen int, a A, fn func(I) (R, error), fni func(A, R) (A, error), args []I) (A, error) {
-	return InjectUnorderedWithContext(context.Background(), qlen, a, fn, fni, args)
+func InjectUnordered[I any, R any, A any](qlen int, a A, fn func(I) (R, error), fni func(A, R) (A, error), args []I, opts ...Option) (A, error) {
+	return InjectUnorderedWithContext(context.Background(), qlen, a, fn, fni, args, opts...)
 }
 
 // ForEachWithContext applys function to each element of slice.
-func ForEachWithContext[I any](ctx context.Context, qlen int, fn func(I) error, args []I) error {
-	_, err := search(ctx, true, qlen, func(e I) (any, error) {
-		return nil, fn(e)
-	}, args)
-	if err == ErrSearchFailure {
+func ForEachWithContext[I any](ctx context.Context, qlen int, fn func(I) error, args []I, opts ...Option) error {
+	return forEach(ctx, qlen, fn, sliceSource(args), newOptions(opts), nil)
+}
+
+// ForEachCount is ForEach but also returns the number of functions which were
+// called, including any returning an error. When an error is returned, this
+// is the amount of work which ran before execution stopped.
+func ForEachCount[I any](qlen int, fn func(I) error, args []I, opts ...Option) (int, error) {
+	return ForEachCountWithContext(context.Background(), qlen, fn, args, opts...)
+}
+
+// ForEachCountWithContext is ForEachCount but with a context.
+func ForEachCountWithContext[I any](ctx context.Context, qlen int, fn func(I) error, args []I, opts ...Option) (int, error) {
+	var n atomic.Int64
+	err := ForEachWithContext(ctx, qlen, func(e I) error {
+		defer n.Add(1)
+		return fn(e)
+	}, args, opts...)
+	return int(n.Load()), err
+}
+
+// ForEachDLQ is ForEach but an error does not stop other arguments from
+// being processed. The arguments whose function returned an error are
+// returned in order, with their errors joined in the same order, so they can
+// be retried later.
+func ForEachDLQ[I any](qlen int, fn func(I) error, args []I, opts ...Option) ([]I, error) {
+	return ForEachDLQWithContext(context.Background(), qlen, fn, args, opts...)
+}
+
+// ForEachDLQWithContext is ForEachDLQ but with a context. If the context is
+// cancelled, arguments which were not processed are not returned and the
+// error of the context is joined to the errors.
+func ForEachDLQWithContext[I any](ctx context.Context, qlen int, fn func(I) error, args []I, opts ...Option) ([]I, error) {
+	var mu sync.Mutex
+	failed := make(map[int]error)
+	err := ForNWithContext(ctx, qlen, len(args), func(i int) error {
+		if err := fn(args[i]); err != nil && err != ErrSkipped {
+			mu.Lock()
+			failed[i] = err
+			mu.Unlock()
+		}
 		return nil
-	} else if err == nil {
-		return ErrSearchSuccess
+	}, unordered(opts)...)
+
+	idx := make([]int, 0, len(failed))
+	for i := range failed {
+		idx = append(idx, i)
 	}
-	return err
+	sort.Ints(idx)
+	dlq := make([]I, len(idx))
+	errs := make([]error, len(idx), len(idx)+1)
+	for j, i := range idx {
+		dlq[j], errs[j] = args[i], failed[i]
+	}
+	return dlq, errors.Join(append(errs, err)...)
 }
 
-func ForEachUnorderedWithContext[I any](ctx context.Context, qlen int, fn func(I) error, args []I) error {
-	_, err := search(ctx, false, qlen, func(e I) (any, error) {
-		return nil, fn(e)
-	}, args)
-	if err == ErrSearchFailure {
-		return nil
-	} else if err == nil {
-		return ErrSearchSuccess
-	}
-	return err
+// ForEachUnorderedWithContext is an unordered version of ForEachWithContext.
+func ForEachUnorderedWithContext[I any](ctx context.Context, qlen int, fn func(I) error, args []I, opts ...Option) error {
+	return ForEachWithContext(ctx, qlen, fn, args, unordered(opts)...)
+}
+
+// ForN applys function to each index in [0, n) without an argument slice.
+//
+// If an error is returned, new indexes will not be processed and execution
+// will return when all goroutines finish.
+func ForN(qlen int, n int, fn func(i int) error, opts ...Option) error {
+	return ForNWithContext(context.Background(), qlen, n, fn, opts...)
+}
+
+// ForNWithContext is ForN but with a context.
+func ForNWithContext(ctx context.Context, qlen int, n int, fn func(i int) error, opts ...Option) error {
+	return forEach(ctx, qlen, fn, rangeSource(n), newOptions(opts), nil)
+}
+
+// CollectN is Collect over each index in [0, n) without an argument slice.
+func CollectN[R any](qlen int, n int, fn func(i int) (R, error), opts ...Option) ([]R, error) {
+	return CollectNWithContext(context.Background(), qlen, n, fn, opts...)
+}
+
+// CollectNWithContext is CollectN but with a context.
+func CollectNWithContext[R any](ctx context.Context, qlen int, n int, fn func(i int) (R, error), opts ...Option) ([]R, error) {
+	return collect(ctx, qlen, fn, rangeSource(n), newOptions(opts), nil)
 }
 
 // MapWithContext is Map but with a context.
 // In all cases where processing of result channel may abort early, the context
 // should be cancelled to avoid goroutine leaks.
-func MapWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) R, args []I) <-chan R {
-	return mapI(ctx, qlen, fn, args, nil)
+func MapWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) R, args []I, opts ...Option) <-chan R {
+	return mapOrder(ctx, qlen, fn, sliceSource(args), nil, newOptions(opts), nil)
 }
 
 // MapUnorderedWithContext is an unordered version of MapWithContext
-func MapUnorderedWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) R, args []I) <-chan R {
-	return mapUnordered(ctx, qlen, fn, args, nil)
+func MapUnorderedWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) R, args []I, opts ...Option) <-chan R {
+	return MapWithContext(ctx, qlen, fn, args, unordered(opts)...)
 }
 
 // MapErrWithContext is MapErr but with a context.
 // In all cases where processing of result channel may abort early, the context
 // should be cancelled to avoid goroutine leaks.
-func MapErrWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I) func() (R, error, bool) {
-	return mapErr(ctx, true, qlen, fn, args)
+func MapErrWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I, opts ...Option) func() (R, error, bool) {
+	return mapErr(ctx, qlen, fn, sliceSource(args), newOptions(opts), nil)
 }
 
 // MapErrUnorderedWithContext is an unordered version of MapErrWithContext
-func MapErrUnorderedWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I) func() (R, error, bool) {
-	return mapErr(ctx, false, qlen, fn, args)
+func MapErrUnorderedWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I, opts ...Option) func() (R, error, bool) {
+	return MapErrWithContext(ctx, qlen, fn, args, unordered(opts)...)
 }
 
 // SearchWithContext is Search but with a context.
-func SearchWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I) (R, error) {
-	return search(ctx, true, qlen, fn, args)
+func SearchWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I, opts ...Option) (R, error) {
+	return search(ctx, qlen, fn, sliceSource(args), newOptions(opts), nil)
 }
 
 // SearchUnorderedWithContext is an unordered version of SearchWithContext.
-func SearchUnorderedWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I) (R, error) {
-	return search(ctx, false, qlen, fn, args)
+func SearchUnorderedWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I, opts ...Option) (R, error) {
+	return SearchWithContext(ctx, qlen, fn, args, unordered(opts)...)
 }
 
 // ReduceWithContext is Reduce but with a context.
-func ReduceWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), fni func(R, R) (R, error), args []I) (R, error) {
+func ReduceWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), fni func(R, R) (R, error), args []I, opts ...Option) (R, error) {
 	a := new(R)
-	return inject(ctx, true, qlen, *a, fn, fni, args)
+	return inject(ctx, qlen, *a, fn, withoutContext(fni), sliceSource(args), newOptions(opts), nil)
 }
 
 // ReduceUnorderedWithContext is an unordered version of ReduceWithContext.
-func ReduceUnorderedWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), fni func(R, R) (R, error), args []I) (R, error) {
-	a := new(R)
-	return inject(ctx, false, qlen, *a, fn, fni, args)
+func ReduceUnorderedWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), fni func(R, R) (R, error), args []I, opts ...Option) (R, error) {
+	return ReduceWithContext(ctx, qlen, fn, fni, args, unordered(opts)...)
 }
 
 // InjectWithContext is Inject but with a context.
-func InjectWithContext[I any, R any, A any](ctx context.Context, qlen int, a A, fn func(I) (R, error), fni func(A, R) (A, error), args []I) (A, error) {
-	return inject(ctx, true, qlen, a, fn, fni, args)
+func InjectWithContext[I any, R any, A any](ctx context.Context, qlen int, a A, fn func(I) (R, error), fni func(A, R) (A, error), args []I, opts ...Option) (A, error) {
+	return inject(ctx, qlen, a, fn, withoutContext(fni), sliceSource(args), newOptions(opts), nil)
 }
 
 // InjectUnorderedWithContext is an unordered version of InjectWithContext.
-func InjectUnorderedWithContext[I any, R any, A any](ctx context.Context, qlen int, a A, fn func(I) (R, error), fni func(A, R) (A, error), args []I) (A, error) {
-	return inject(ctx, false, qlen, a, fn, fni, args)
+func InjectUnorderedWithContext[I any, R any, A any](ctx context.Context, qlen int, a A, fn func(I) (R, error), fni func(A, R) (A, error), args []I, opts ...Option) (A, error) {
+	return InjectWithContext(ctx, qlen, a, fn, fni, args, unordered(opts)...)
+}
+
+// InjectContext is InjectWithContext but the reduction function "fni" also
+// receives a context, which is cancelled when the context is cancelled or
+// any function returns an error. A slow reduction can then stop early rather
+// than continue until all results are drained.
+func InjectContext[I any, R any, A any](ctx context.Context, qlen int, a A, fn func(I) (R, error), fni func(context.Context, A, R) (A, error), args []I, opts ...Option) (A, error) {
+	return inject(ctx, qlen, a, fn, fni, sliceSource(args), newOptions(opts), nil)
+}
+
+// forEach applys function to each argument.
+func forEach[I any](ctx context.Context, qlen int, fn func(I) error, args source[I], o *options, h *hooks[I, any]) error {
+	_, err := search(ctx, qlen, func(e I) (any, error) {
+		return nil, fn(e)
+	}, args, o, h)
+	if err == ErrSearchFailure {
+		return nil
+	} else if err == nil {
+		return ErrSearchSuccess
+	}
+	return err
 }
 
-func search[I any, R any](ctx context.Context, ordered bool, qlen int, fn func(I) (R, error), args []I) (R, error) {
+// collect returns the results of function for each argument.
+func collect[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args source[I], o *options, h *hooks[I, R]) ([]R, error) {
+	return inject(ctx, qlen, make([]R, 0, args.n), fn, withoutContext(func(a []R, b R) ([]R, error) {
+		return append(a, b), nil
+	}), args, o, h)
+}
+
+func search[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args source[I], o *options, h *hooks[I, R]) (R, error) {
 	var v R
 	var err error
-	hasError := make(chan error, len(args))
-	ctx, cancel := context.WithCancel(ctx)
+	hasError := newErrSignal()
+	ctx, cancel := o.context(ctx)
 	defer cancel()
+	defer o.finished()
 
-	mapFn := mapUnordered[I, *F[R]]
-	if ordered {
-		mapFn = mapI[I, *F[R]]
-	}
-
-	results := mapFn(ctx, qlen, func(in I) *F[R] {
+	fn = mapped(o, fn, args.n)
+	results := mapOrder(ctx, qlen, func(in I) *F[R] {
 		vn, errn := fn(in)
-		if errn != nil {
-			hasError <- errn
+		if errn != nil && errn != ErrSkipped {
+			if errn != ErrSearchSuccess {
+				o.failed(errn)
+			}
+			hasError.raise()
 		}
 		return NewF(vn, errn)
-	}, args, hasError)
+	}, args, hasError, o, resultHooks(h))
 	for r := range results {
 		if err != nil {
 			cancel()
@@ -301,18 +500,20 @@ func search[I any, R any](ctx context.Context, ordered bool, qlen int, fn func(I
 		} else {
 			select {
 			case <-ctx.Done():
-				err = ctx.Err()
+				err = o.firstErr(ctx.Err())
 				continue
 			default:
 			}
 		}
-		v, err = r.Return()
+		if v, err = r.Return(); err != ErrSearchSuccess {
+			err = o.firstErr(err)
+		}
 	}
 
 	if err == nil {
 		select {
 		case <-ctx.Done():
-			return v, ctx.Err()
+			return v, o.firstErr(ctx.Err())
 		default:
 		}
 		return v, ErrSearchFailure
@@ -322,69 +523,76 @@ func search[I any, R any](ctx context.Context, ordered bool, qlen int, fn func(I
 	return v, err
 }
 
-func inject[I any, R any, A any](ctx context.Context, ordered bool, qlen int, a A, fn func(I) (R, error), fni func(A, R) (A, error), args []I) (A, error) {
+// withoutContext adapts a reduction function which does not use the context.
+func withoutContext[A any, R any](fni func(A, R) (A, error)) func(context.Context, A, R) (A, error) {
+	return func(_ context.Context, a A, v R) (A, error) {
+		return fni(a, v)
+	}
+}
+
+func inject[I any, R any, A any](ctx context.Context, qlen int, a A, fn func(I) (R, error), fni func(context.Context, A, R) (A, error), args source[I], o *options, h *hooks[I, R]) (A, error) {
 	var v R
 	var err error
-	hasError := make(chan error, len(args))
-	ctx, cancel := context.WithCancel(ctx)
+	hasError := newErrSignal()
+	ctx, cancel := o.context(ctx)
 	defer cancel()
+	defer o.finished()
 
-	mapFn := mapUnordered[I, *F[R]]
-	if ordered {
-		mapFn = mapI[I, *F[R]]
-	}
-
-	results := mapFn(ctx, qlen, func(in I) *F[R] {
+	fn = mapped(o, fn, args.n)
+	results := mapOrder(ctx, qlen, func(in I) *F[R] {
 		vn, errn := fn(in)
-		if errn != nil {
-			hasError <- errn
+		if errn != nil && errn != ErrSkipped {
+			o.failed(errn)
+			hasError.raise()
 		}
 		return NewF(vn, errn)
-	}, args, hasError)
+	}, args, hasError, o, resultHooks(h))
 	for r := range results {
 		if err != nil {
 			continue // consume all results
 		}
 		v, err = r.Return()
 		if err != nil {
+			err = o.firstErr(err)
 			cancel()
 			continue
 		} else {
 			select {
 			case <-ctx.Done():
-				err = ctx.Err()
+				err = o.firstErr(ctx.Err())
 				continue
 			default:
 			}
 		}
-		a, err = fni(a, v)
+		if a, err = fni(ctx, a, v); err != nil {
+			err = o.firstErr(err)
+			o.failed(err)
+			cancel()
+		}
 	}
 	if err == nil {
 		select {
 		case <-ctx.Done():
-			return a, ctx.Err()
+			return a, o.firstErr(ctx.Err())
 		default:
 		}
 	}
 	return a, err
 }
 
-func mapErr[I any, R any](ctx context.Context, ordered bool, qlen int, fn func(I) (R, error), args []I) func() (R, error, bool) {
-	hasError := make(chan error, len(args))
-	ctx, cancel := context.WithCancel(ctx)
+func mapErr[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args source[I], o *options, h *hooks[I, R]) func() (R, error, bool) {
+	hasError := newErrSignal()
+	ctx, cancel := o.context(ctx)
 
-	mapFn := mapUnordered[I, *F[R]]
-	if ordered {
-		mapFn = mapI[I, *F[R]]
-	}
-
-	results := mapFn(ctx, qlen, func(in I) *F[R] {
+	fn = mapped(o, fn, args.n)
+	results := mapOrder(ctx, qlen, func(in I) *F[R] {
 		vn, errn := fn(in)
-		if errn != nil {
-			hasError <- errn
+		if errn != nil && errn != ErrSkipped {
+			o.failed(errn)
+			hasError.raise()
 		}
 		return NewF(vn, errn)
-	}, args, hasError)
+	}, args, hasError, o, resultHooks(h))
 
 	return func(ctx context.Context, results <-chan *F[R], cancel func()) func() (R, error, bool) {
 		var done bool // closure for completion
@@ -396,9 +604,10 @@ func mapErr[I any, R any](ctx context.Context, ordered bool, qlen int, fn func(I
 			r, ok = <-results
 			if !ok || r == nil {
 				done = true
+				defer o.finished()
 				select {
 				case <-ctx.Done():
-					return vn, ctx.Err(), true
+					return vn, o.firstErr(ctx.Err()), true
 				default:
 					cancel()
 				}
@@ -406,29 +615,48 @@ func mapErr[I any, R any](ctx context.Context, ordered bool, qlen int, fn func(I
 			}
 			vn, errn = r.Return()
 			if errn != nil {
+				errn = o.firstErr(errn)
 				cancel()
 				for range results {
 					// consume all remaining workers
 				}
 				done = true
+				o.finished()
 			}
 			return vn, errn, ok
 		}
 	}(ctx, results, cancel)
 }
 
-func mapUnordered[I any, R any](ctx context.Context, qlen int, fn func(I) R, args []I, hasError <-chan error) <-chan R {
+// mapOrder maps arguments as determined by the OrderPolicy of options.
+func mapOrder[I any, R any](ctx context.Context, qlen int, fn func(I) R, args source[I], hasError *errSignal, o *options, h *hooks[I, R]) <-chan R {
+	if o.timeout > 0 && !o.timed {
+		ctx, o.stop = o.context(ctx) // cancelled when results are closed
+	}
+	if o.seeded {
+		args = shuffledSource(args, o.seed)
+	}
+	if o.order.ordered {
+		return mapI(ctx, qlen, fn, args, hasError, o, h)
+	}
+	return mapUnordered(ctx, qlen, fn, args, hasError, o, h)
+}
+
+func mapUnordered[I any, R any](ctx context.Context, qlen int, fn func(I) R, args source[I], hasError *errSignal, o *options, h *hooks[I, R]) <-chan R {
 	// Save a bit on recompute
 	poolSize := qlen
 	if poolSize <= 0 {
 		poolSize = defaultPoolSize
 	}
+	errored := hasError.done()
 
 	rn := newRunnable(poolSize, fn)
+	rn.pool = o.pool
+	rn.keep = h.keeper()
 
 	go func() {
 		// Save a bit on recompute
-		argsLen := len(args)
+		argsLen := args.n
 
 		// Only fill pool as much as needed
 		startSize := poolSize
@@ -438,46 +666,48 @@ func mapUnordered[I any, R any](ctx context.Context, qlen int, fn func(I) R, arg
 
 		var wg sync.WaitGroup
 		wg.Add(startSize)
+		rn.shard(startSize, h.route(startSize))
 
-		// Startup the pool and fill it with work
+		// Startup the pool, before any early exit waits for its runners
 		for i := 0; i < startSize; i++ {
-			go rn.run(ctx, &wg) // start runners
-			select {
-			case <-hasError:
-				goto EarlyExit
-			case <-ctx.Done():
-				goto EarlyExit
-			case rn.input <- args[i]:
-			}
+			go rn.run(ctx, &wg, i)
 		}
 
-		for _, arg := range args[startSize:argsLen] {
+		for i := 0; i < argsLen; i++ {
+			e := args.at(i)
 			select {
-			case <-hasError:
+			case <-errored:
 				goto EarlyExit
 			case <-ctx.Done():
 				goto EarlyExit
-			case rn.input <- arg: // send until done
+			case rn.in(e) <- e: // send until done
 			}
 		}
 
 	EarlyExit:
-		close(rn.input)
+		rn.closeInput()
 		wg.Wait()
 		close(rn.output)
+		o.stopped()
 	}()
 
 	return rn.output
 }
 
-func mapI[I any, R any](ctx context.Context, qlen int, fn func(I) R, args []I, hasError <-chan error) <-chan R {
+func mapI[I any, R any](ctx context.Context, qlen int, fn func(I) R, args source[I], hasError *errSignal, o *options, h *hooks[I, R]) <-chan R {
 	// Save a bit on recompute
 	poolSize := qlen
 	if poolSize <= 0 {
 		poolSize = defaultPoolSize
 	}
+	errored := hasError.done()
 
 	results := make(chan R, poolSize)
+	sizeOf, maxBuffered := h.sizer()
+	keep := h.keeper()
+	if sizeOf != nil {
+		results = make(chan R) // buffered results are counted by the collector
+	}
 
 	rn := newRunnable(poolSize, func(in *ordE[I]) *ordE[R] {
 		return &ordE[R]{
@@ -485,12 +715,13 @@ func mapI[I any, R any](ctx context.Context, qlen int, fn func(I) R, args []I, h
 			n: in.n,
 		}
 	})
+	rn.pool = o.pool
 
 	go func(buf []*ordE[R]) {
 		_ = buf[poolSize-1] // Eliminate bounds check
 
 		// Save a bit on recompute
-		argsLen := len(args)
+		argsLen := args.n
 
 		// Only fill pool as much as needed
 		startSize := poolSize
@@ -500,26 +731,40 @@ func mapI[I any, R any](ctx context.Context, qlen int, fn func(I) R, args []I, h
 
 		var wg sync.WaitGroup
 		wg.Add(startSize)
+		if route := h.route(startSize); route != nil {
+			rn.shard(startSize, func(in *ordE[I]) int { return route(in.e) })
+		}
 
-		// Startup the pool and fill it with work
+		// Startup the pool and fill it with work. When results are sized
+		// only one is dispatched until their average size is known.
+		idx := startSize
+		var sizes []int
+		if sizeOf != nil {
+			sizes = make([]int, poolSize)
+			if idx > 1 {
+				idx = 1
+			}
+		}
 		for i := 0; i < startSize; i++ {
-			go rn.run(ctx, &wg) // start runners
-			rn.input <- &ordE[I]{args[i], i}
+			go rn.run(ctx, &wg, i) // start runners
+			if i < idx {
+				e := &ordE[I]{args.at(i), i}
+				rn.in(e) <- e
+			}
 		}
 
-		if startSize == argsLen {
-			close(rn.input) // all inputs are buffered
+		if idx == argsLen {
+			rn.closeInput() // all inputs are buffered
 		}
 
-		var readn, cidx int
-		idx := startSize
+		var readn, cidx, buffered, sized int
 	OuterLoopContext:
 		for readn < argsLen {
 			var r *ordE[R]
 			select {
 			case <-ctx.Done():
 				if idx < argsLen {
-					close(rn.input)
+					rn.closeInput()
 				}
 				break OuterLoopContext
 			case r = <-rn.output:
@@ -527,33 +772,56 @@ func mapI[I any, R any](ctx context.Context, qlen int, fn func(I) R, args []I, h
 			readn++
 
 			// Add current element to results, or buffer if out-of-sequence
+			t := o.pool.now()
+			size := 0
+			if sizeOf != nil {
+				size = sizeOf(r.e)
+				sized += size
+			}
 			if r.n == cidx {
-				results <- r.e
+				if keep == nil || keep(r.e) {
+					results <- r.e
+				}
 				cidx++
 			} else {
 				buf[r.n%poolSize] = r
+				if sizeOf != nil {
+					sizes[r.n%poolSize] = size
+					buffered += size
+				}
 			}
 
 			// Check for any buffered results to return
 			for buf[cidx%poolSize] != nil {
-				results <- buf[cidx%poolSize].e
+				if e := buf[cidx%poolSize].e; keep == nil || keep(e) {
+					results <- e
+				}
 				buf[cidx%poolSize] = nil
+				if sizeOf != nil {
+					buffered -= sizes[cidx%poolSize]
+				}
 				cidx++
 			}
+			o.pool.delivered(t)
 
-			// Top off the pool
+			// Top off the pool, unless buffered and running results are
+			// expected to exceed their limit by the average result size
 			for idx < argsLen && cidx+poolSize > idx {
+				if sizeOf != nil && idx > readn && buffered+(idx-readn)*(sized/readn) >= maxBuffered {
+					break // the next result is running, so buffered results drain
+				}
+				e := &ordE[I]{args.at(idx), idx}
 				select {
-				case <-hasError:
+				case <-errored:
 					argsLen = idx
 				case <-ctx.Done():
-					close(rn.input)
+					rn.closeInput()
 					break OuterLoopContext
-				case rn.input <- &ordE[I]{args[idx], idx}:
+				case rn.in(e) <- e:
 				}
 				idx++
 				if idx >= argsLen {
-					close(rn.input) // Close early to terminate workers
+					rn.closeInput() // Close early to terminate workers
 				}
 			}
 		}
@@ -563,6 +831,7 @@ func mapI[I any, R any](ctx context.Context, qlen int, fn func(I) R, args []I, h
 		// Cleanup and signal readers
 		close(rn.output)
 		close(results)
+		o.stopped()
 	}(make([]*ordE[R], poolSize))
 
 	return results
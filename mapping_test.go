@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -1306,3 +1307,280 @@ func TestForEach(t *testing.T) {
 		})
 	}
 }
+
+func TestForN(t *testing.T) {
+	tests := []struct {
+		name  string
+		n     int
+		fail  int
+		total int64
+		err   error
+	}{
+		{name: "without an error", n: 100, fail: -1, total: 4950},
+		{name: "empty range", n: 0, fail: -1},
+		{name: "with an error", n: 100, fail: 10, err: testErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var total int64
+			err := ForN(3, tt.n, func(i int) error {
+				if i == tt.fail {
+					return testErr
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				total += int64(i)
+				return nil
+			})
+			if err != tt.err {
+				t.Fatalf("Expected error=%v but received error=%v", tt.err, err)
+			}
+			if err == nil && total != tt.total {
+				t.Errorf("Expected total=%v but found=%v", tt.total, total)
+			}
+		})
+	}
+}
+
+func TestCollectN(t *testing.T) {
+	results, err := CollectN(4, 1000, func(i int) (string, error) {
+		return strconv.Itoa(i), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1000 {
+		t.Fatalf("Expected results=%v but received results=%v", 1000, len(results))
+	}
+	for i, r := range results {
+		if r != strconv.Itoa(i) {
+			t.Fatalf("Expected result=%v at index=%v but received result=%v", i, i, r)
+		}
+	}
+
+	if _, err := CollectN(4, 1000, func(i int) (string, error) {
+		if i == 500 {
+			return "", testErr
+		}
+		return "", nil
+	}); err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+}
+
+func TestForEachCount(t *testing.T) {
+	tests := []struct {
+		name   string
+		fail   int
+		expect func(int) bool
+	}{
+		{name: "without an error", fail: -1, expect: func(n int) bool { return n == len(testInts) }},
+		{name: "with an error", fail: 10, expect: func(n int) bool { return n >= 10 && n < len(testInts) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int64
+			n, err := ForEachCount(3, func(n int) error {
+				calls.Add(1)
+				time.Sleep(time.Millisecond)
+				if n == tt.fail {
+					return testErr
+				}
+				return nil
+			}, testInts)
+			if (err != nil) != (tt.fail > 0) {
+				t.Errorf("Expected error=%v but received error=%v", tt.fail > 0, err)
+			}
+			if !tt.expect(n) || int64(n) != calls.Load() {
+				t.Errorf("Expected count=%v to match calls=%v", n, calls.Load())
+			}
+		})
+	}
+}
+
+func TestForEachDLQ(t *testing.T) {
+	dlq, err := ForEachDLQ(4, func(n int) error {
+		if n%20 == 0 {
+			return fmt.Errorf("failed %d", n)
+		}
+		return nil
+	}, testInts)
+	if len(dlq) != 3 || dlq[0] != 20 || dlq[1] != 40 || dlq[2] != 60 {
+		t.Errorf("Expected dead letters=%v but received dead letters=%v", []int{20, 40, 60}, dlq)
+	}
+	if err == nil || err.Error() != "failed 20\nfailed 40\nfailed 60" {
+		t.Errorf("Expected joined errors but received error=%v", err)
+	}
+
+	if dlq, err := ForEachDLQ(4, func(n int) error { return nil }, testInts); err != nil || len(dlq) != 0 {
+		t.Errorf("Expected no dead letters but received dead letters=%v error=%v", dlq, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ForEachDLQWithContext(ctx, 4, func(n int) error { return nil }, testInts); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error=%v but received error=%v", context.Canceled, err)
+	}
+}
+
+func TestCollectInto(t *testing.T) {
+	dst := make([]string, 0, len(testInts))
+	r, err := CollectInto(dst, 4, func(n int) (string, error) {
+		return strconv.Itoa(n), nil
+	}, testInts)
+	if err != nil || len(r) != len(testInts) || &r[0] != &dst[:1][0] {
+		t.Fatalf("Expected results in dst but received results=%v error=%v", len(r), err)
+	}
+	for i, n := range testInts {
+		if r[i] != strconv.Itoa(n) {
+			t.Errorf("Expected result=%v at index=%v but received result=%v", n, i, r[i])
+		}
+	}
+
+	r, err = CollectInto(nil, 4, func(n int) (string, error) {
+		if n == 3 {
+			return "", testErr
+		}
+		return strconv.Itoa(n), nil
+	}, testInts[:5])
+	if err != testErr || len(r) != 5 {
+		t.Errorf("Expected error=%v but received results=%v error=%v", testErr, len(r), err)
+	}
+}
+
+func BenchmarkCollectInto(b *testing.B) {
+	args := make([]int, 100000)
+	for i := range args {
+		args[i] = i
+	}
+	fn := func(n int) (int, error) {
+		return n * 2, nil
+	}
+	b.Run("Collect", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = Collect(8, fn, args)
+		}
+	})
+	b.Run("CollectInto", func(b *testing.B) {
+		b.ReportAllocs()
+		dst := make([]int, len(args))
+		for i := 0; i < b.N; i++ {
+			dst, _ = CollectInto(dst, 8, fn, args)
+		}
+	})
+}
+
+func TestOrderedError(t *testing.T) {
+	errA, errB := errors.New("first argument"), errors.New("first to fail")
+	fn := func(n int) (int, error) {
+		switch n {
+		case 10:
+			time.Sleep(20 * time.Millisecond)
+			return 0, errA
+		case 12:
+			return 0, errB
+		}
+		return n, nil
+	}
+
+	tests := []struct {
+		name   string
+		run    func() error
+		expect error
+	}{
+		{
+			name: "Collect",
+			run: func() error {
+				_, err := Collect(8, fn, testInts)
+				return err
+			},
+			expect: errA,
+		},
+		{
+			name: "Inject with cancel on error",
+			run: func() error {
+				ctx, opt := WithCancelOnError(context.Background())
+				_, err := InjectWithContext(ctx, 8, 0, fn, func(a, b int) (int, error) {
+					return a + b, nil
+				}, testInts, opt)
+				return err
+			},
+			expect: errB,
+		},
+		{
+			name: "ForEach",
+			run: func() error {
+				return ForEach(8, func(n int) error {
+					_, err := fn(n)
+					return err
+				}, testInts)
+			},
+			expect: errA,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 3; i++ {
+				if err := tt.run(); err != tt.expect {
+					t.Fatalf("Expected error=%v but received error=%v", tt.expect, err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkForEachLarge(b *testing.B) {
+	args := make([]int, 1000000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = ForEach(8, func(int) error { return nil }, args)
+	}
+}
+
+func TestInjectContext(t *testing.T) {
+	flush := func(ctx context.Context, a int, b int) (int, error) {
+		select {
+		case <-time.After(20 * time.Millisecond):
+			return a + b, nil
+		case <-ctx.Done():
+			return a, ctx.Err()
+		}
+	}
+	identity := func(n int) (int, error) {
+		return n, nil
+	}
+
+	if v, err := InjectContext(context.Background(), 4, 0, identity, flush, testInts[:5]); err != nil || v != 15 {
+		t.Errorf("Expected result=%v but received result=%v error=%v", 15, v, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := InjectContext(ctx, 4, 0, identity, flush, testInts); err != context.DeadlineExceeded {
+		t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+	}
+	if d := time.Since(start); d > 30*time.Millisecond+allowedVariance {
+		t.Errorf("Expected fold to stop on cancellation but took %v", d)
+	}
+
+	ctx, opt := WithCancelOnError(context.Background())
+	_, err := InjectContext(ctx, 4, 0, func(n int) (int, error) {
+		if n == 3 {
+			time.Sleep(10 * time.Millisecond)
+			return 0, testErr
+		}
+		return n, nil
+	}, func(ctx context.Context, a int, b int) (int, error) {
+		if b == 1 {
+			<-ctx.Done()
+			return a, ctx.Err()
+		}
+		return a + b, nil
+	}, testInts, opt)
+	if err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+}
@@ -0,0 +1,40 @@
+package goroutines
+
+import (
+	"context"
+	"io"
+)
+
+// MapToWriter calls fn on each element of slice concurrently, writing each
+// result to w in the order of args. At most qlen results are buffered while
+// waiting for earlier results.
+//
+// If an error is returned, new arguments will not be processed and execution
+// will return when all goroutines finish. Options are supported as by MapErr,
+// so results are written as they complete with WithOrder(Unordered).
+func MapToWriter[I any](qlen int, fn func(I) ([]byte, error), args []I, w io.Writer, opts ...Option) error {
+	return MapToWriterWithContext(context.Background(), qlen, fn, args, w, opts...)
+}
+
+// MapToWriterWithContext is MapToWriter but with a context.
+func MapToWriterWithContext[I any](ctx context.Context, qlen int, fn func(I) ([]byte, error), args []I, w io.Writer, opts ...Option) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	next := MapErrWithContext(ctx, qlen, fn, args, opts...)
+	for {
+		b, err, ok := next()
+		if !ok {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			cancel()
+			for _, _, ok := next(); ok; _, _, ok = next() {
+				// consume all remaining workers
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package goroutines
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type limitWriter struct {
+	n int
+}
+
+func (w *limitWriter) Write(b []byte) (int, error) {
+	if w.n <= 0 {
+		return 0, testErr
+	}
+	w.n--
+	return len(b), nil
+}
+
+func TestMapToWriter(t *testing.T) {
+	encode := func(n int) ([]byte, error) {
+		time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+		if n < 0 {
+			return nil, errors.New("negative")
+		}
+		return []byte(strconv.Itoa(n) + "\n"), nil
+	}
+
+	var buf bytes.Buffer
+	if err := MapToWriter(4, encode, testInts, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var expect strings.Builder
+	for _, n := range testInts {
+		expect.WriteString(strconv.Itoa(n) + "\n")
+	}
+	if buf.String() != expect.String() {
+		t.Errorf("Expected ordered output=%q but received output=%q", expect.String(), buf.String())
+	}
+
+	if err := MapToWriter(4, encode, testInts, &limitWriter{n: 10}); err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+	if err := MapToWriter(4, encode, []int{1, 2, -1, 4}, &buf); err == nil || err.Error() != "negative" {
+		t.Errorf("Expected encoding error but received error=%v", err)
+	}
+}
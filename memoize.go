@@ -0,0 +1,23 @@
+package goroutines
+
+import (
+	"math"
+	"time"
+)
+
+// Memoize returns a function which caches the successful results of fn by
+// key for ttl, or forever when ttl is not positive. Concurrent calls with
+// the same key are coalesced, and keys unused for ttl are evicted. The
+// returned function is safe for concurrent use, such as the fn of Map or
+// Collect, sharing its cache across calls.
+func Memoize[K comparable, V any](fn func(K) (V, error), ttl time.Duration) func(K) (V, error) {
+	return memoize(fn, ttl).Run
+}
+
+// memoize returns the CoalescerGroup of Memoize.
+func memoize[K comparable, V any](fn func(K) (V, error), ttl time.Duration) *CoalescerGroup[K, V] {
+	if ttl <= 0 {
+		return CacheCoalesceGroup(fn, math.MaxInt64, 0)
+	}
+	return CacheCoalesceGroup(fn, ttl, 0).WithIdleEviction(ttl)
+}
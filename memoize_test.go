@@ -0,0 +1,61 @@
+package goroutines
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoize(t *testing.T) {
+	tests := []struct {
+		name  string
+		ttl   time.Duration
+		sleep time.Duration
+		calls int64
+	}{
+		{name: "forever", sleep: 20 * time.Millisecond, calls: 15},
+		{name: "expired", ttl: 50 * time.Millisecond, sleep: 60 * time.Millisecond, calls: 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int64
+			fn := Memoize(func(s string) (int, error) {
+				calls.Add(1)
+				return len(s), nil
+			}, tt.ttl)
+
+			for i := 0; i < 2; i++ {
+				results, err := Collect(5, fn, testStrings)
+				if err != nil || len(results) != len(testStrings) {
+					t.Fatalf("Expected results=%v but received results=%v error=%v", len(testStrings), len(results), err)
+				}
+				time.Sleep(tt.sleep)
+			}
+			// testStrings has 15 distinct values, concurrent duplicates
+			// may either coalesce or hit the cache.
+			if n := calls.Load(); n != tt.calls {
+				t.Errorf("Expected calls=%v but received calls=%v", tt.calls, n)
+			}
+		})
+	}
+}
+
+func TestMemoizeEviction(t *testing.T) {
+	g := memoize(func(s string) (int, error) {
+		return len(s), nil
+	}, 20*time.Millisecond)
+
+	for _, s := range testStrings {
+		_, _ = g.Run(s)
+	}
+	if n := g.Len(); n != 15 {
+		t.Errorf("Expected keys=%v but received keys=%v", 15, n)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if v, err := g.Run("foo"); err != nil || v != 3 {
+		t.Errorf("Expected result=%v but received result=%v error=%v", 3, v, err)
+	}
+	if n := g.Len(); n != 1 {
+		t.Errorf("Expected expired keys to be evicted but received keys=%v", n)
+	}
+}
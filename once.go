@@ -0,0 +1,108 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Once calls a function returning (T, error) once, returning its result to
+// every caller. Unlike sync.Once callers may bound their wait, and failures
+// may be retried.
+type Once[T any] struct {
+	mu      sync.Mutex
+	fn      func() (T, error)
+	retry   bool
+	done    bool
+	running chan struct{}
+	v       T
+	err     error
+}
+
+// NewOnce returns a Once calling fn.
+func NewOnce[T any](fn func() (T, error)) *Once[T] {
+	return &Once[T]{fn: fn}
+}
+
+// WithRetry calls the function again on the next call after it fails, rather
+// than returning the error to every later caller.
+func (o *Once[T]) WithRetry() *Once[T] {
+	o.mu.Lock()
+	o.retry = true
+	o.mu.Unlock()
+	return o
+}
+
+// Do calls the function if it has not been called, otherwise waits for its
+// result.
+func (o *Once[T]) Do() (T, error) {
+	return o.DoWithContext(context.Background())
+}
+
+// DoTimeout is Do but returns ErrRunnerTimedout if the result is not
+// available before timeout. The function continues running in the
+// background. A negative timeout waits indefinitely.
+func (o *Once[T]) DoTimeout(timeout time.Duration) (T, error) {
+	if timeout < 0 {
+		return o.Do()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	v, err := o.DoWithContext(ctx)
+	if err == context.DeadlineExceeded && ctx.Err() != nil {
+		return v, ErrRunnerTimedout
+	}
+	return v, err
+}
+
+// DoWithContext is Do but returns an error if the context is cancelled
+// before the result is available. The function continues running in the
+// background.
+func (o *Once[T]) DoWithContext(ctx context.Context) (T, error) {
+	o.mu.Lock()
+	if o.done {
+		defer o.mu.Unlock()
+		return o.v, o.err
+	}
+	running := o.running
+	if running == nil {
+		running = make(chan struct{})
+		o.running = running
+		go o.call(running)
+	}
+	o.mu.Unlock()
+
+	select {
+	case <-running:
+	case <-ctx.Done():
+		v := new(T)
+		return *v, ctx.Err()
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.v, o.err
+}
+
+// Done returns true if the function has been called and its result will be
+// returned to later callers.
+func (o *Once[T]) Done() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.done
+}
+
+func (o *Once[T]) call(running chan struct{}) {
+	var v T
+	err := protect(func() (err error) {
+		v, err = o.fn()
+		return
+	})
+
+	o.mu.Lock()
+	o.v, o.err = v, err
+	o.done = err == nil || !o.retry
+	o.running = nil
+	o.mu.Unlock()
+	close(running)
+}
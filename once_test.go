@@ -0,0 +1,69 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOnce(t *testing.T) {
+	tests := []struct {
+		name   string
+		retry  bool
+		expect []error
+		calls  int64
+	}{
+		{name: "sticky error", expect: []error{testErr, testErr}, calls: 1},
+		{name: "retry error", retry: true, expect: []error{testErr, nil}, calls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int64
+			o := NewOnce(func() (string, error) {
+				if calls.Add(1) == 1 {
+					time.Sleep(20 * time.Millisecond)
+					return "", testErr
+				}
+				return "foo", nil
+			})
+			if tt.retry {
+				o.WithRetry()
+			}
+
+			if _, err := o.DoTimeout(time.Millisecond); err != ErrRunnerTimedout {
+				t.Errorf("Expected error=%v but received error=%v", ErrRunnerTimedout, err)
+			}
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := o.Do(); err != tt.expect[0] {
+						t.Errorf("Expected error=%v but received error=%v", tt.expect[0], err)
+					}
+				}()
+			}
+			wg.Wait()
+			v, err := o.DoWithContext(context.Background())
+			if err != tt.expect[1] || (err == nil && v != "foo") {
+				t.Errorf("Expected error=%v but received result=%v error=%v", tt.expect[1], v, err)
+			}
+			if n := calls.Load(); n != tt.calls {
+				t.Errorf("Expected calls=%v but received calls=%v", tt.calls, n)
+			}
+			if !o.Done() {
+				t.Errorf("Expected once to be done")
+			}
+		})
+	}
+
+	o := NewOnce(func() (string, error) {
+		time.Sleep(10 * time.Millisecond)
+		return "foo", nil
+	})
+	if v, err := o.DoTimeout(-1); err != nil || v != "foo" {
+		t.Errorf("Expected negative timeout to wait for result but received result=%v error=%v", v, err)
+	}
+}
@@ -0,0 +1,401 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Option configures mapping functions such as Map, ForEach and Collect.
+//
+// Settings which use functions of the argument or result types of a mapping
+// function, such as a key to shard arguments by, are parameters of the
+// mapping functions which support them, such as ForEachSharded, so their
+// types are checked by the compiler.
+type Option func(*options)
+
+type options struct {
+	order  OrderPolicy
+	seeded bool // arguments are shuffled by seed
+	seed   int64
+	cancel context.CancelCauseFunc
+
+	pool *Pool
+
+	tolerant     bool // failures are skipped until the limit is exceeded
+	maxErrors    int
+	maxErrorRate float64
+	failures     []error // tolerated failures
+
+	timeout time.Duration
+	timed   bool // operation context has the timeout
+	stop    context.CancelFunc
+	clock   Clock
+
+	mu  sync.Mutex
+	err error // first error returned by a function
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{order: Ordered}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// unordered appends WithOrder(Unordered) to a copy of opts.
+func unordered(opts []Option) []Option {
+	return append(opts[:len(opts):len(opts)], WithOrder(Unordered))
+}
+
+// OrderPolicy determines the order in which mapping functions return
+// results.
+type OrderPolicy struct {
+	ordered bool
+}
+
+var (
+	// Ordered returns results in the order of arguments. This is the default.
+	// Error aware mapping functions return the error of the first failing
+	// argument in order, regardless of which failed first, unless the error
+	// cancelled the context of the mapping function. See WithCancelOnError.
+	Ordered = OrderPolicy{ordered: true}
+
+	// Unordered returns results as they complete.
+	Unordered = OrderPolicy{}
+)
+
+// WithSeededOrder dispatches arguments in a pseudo-random permutation
+// determined by seed, so tests of consumers sensitive to order can be
+// reproduced. Ordered mapping functions return results in the permuted
+// order. Unordered results also complete in the permuted order when qlen is
+// one, otherwise they depend on scheduling of the functions.
+func WithSeededOrder(seed int64) Option {
+	return func(o *options) {
+		o.seeded = true
+		o.seed = seed
+	}
+}
+
+// WithOrder sets the OrderPolicy of a mapping function. Unordered variants,
+// such as CollectUnordered, always use Unordered.
+func WithOrder(p OrderPolicy) Option {
+	return func(o *options) {
+		o.order = p
+	}
+}
+
+// WithCancelOnError returns a context derived from ctx and an Option which
+// cancels the context with the first error returned by a function, so
+// in-flight functions using the context stop immediately, like errgroup. The
+// context is also cancelled when the mapping function returns, or for MapErr
+// once all results are consumed. Map does not return errors, so the Option
+// has no effect on Map.
+func WithCancelOnError(ctx context.Context) (context.Context, Option) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return ctx, func(o *options) {
+		o.cancel = cancel
+	}
+}
+
+// failed records the first error returned by a function.
+func (o *options) failed(err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.err == nil {
+		o.err = err
+		if o.cancel != nil {
+			o.cancel(err)
+		}
+	}
+}
+
+// finished is called once all results are returned.
+func (o *options) finished() {
+	if o.cancel != nil {
+		o.cancel(nil)
+	}
+}
+
+// firstErr returns the first error returned by a function if err is due to
+// the context it cancelled, otherwise err. Ordered functions then return the
+// error of the first failing argument, rather than of a sibling it cancelled.
+func (o *options) firstErr(err error) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.cancel != nil && o.err != nil && errors.Is(err, context.Canceled) {
+		return o.err
+	}
+	return err
+}
+
+// WithOperationTimeout limits the duration of an entire mapping function,
+// including dispatch, functions and collection of results. When exceeded
+// error aware mapping functions return context.DeadlineExceeded, and Map
+// closes its results. The context passed to the mapping function is not
+// affected.
+func WithOperationTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithClock sets the Clock of WithOperationTimeout, such as a FakeClock in
+// tests.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// context derives the context of an operation, with the deadline of
+// WithOperationTimeout unless an enclosing operation has it.
+func (o *options) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.timeout > 0 && !o.timed {
+		o.timed = true
+		return withClockTimeout(ctx, o.clock, o.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// stopped cancels the context of an operation derived by Map.
+func (o *options) stopped() {
+	if o.stop != nil {
+		o.stop()
+	}
+}
+
+// optionSet is a set of options given to a mapping function, so functions
+// which do not support an option can reject it.
+type optionSet uint
+
+const (
+	optUnordered optionSet = 1 << iota
+	optSeededOrder
+	optCancelOnError
+	optMaxErrors
+	optPool
+)
+
+// optionNames are the names of the options in an optionSet, by bit.
+var optionNames = [...]string{"WithOrder(Unordered)", "WithSeededOrder", "WithCancelOnError", "WithMaxErrors", "WithPool"}
+
+// given returns the options which were given.
+func (o *options) given() optionSet {
+	var set optionSet
+	if !o.order.ordered {
+		set |= optUnordered
+	}
+	if o.seeded {
+		set |= optSeededOrder
+	}
+	if o.cancel != nil {
+		set |= optCancelOnError
+	}
+	if o.tolerant {
+		set |= optMaxErrors
+	}
+	if o.pool != nil {
+		set |= optPool
+	}
+	return set
+}
+
+// reject panics if any of the unsupported options were given to a mapping
+// function, rather than ignoring them.
+func (o *options) reject(fn string, unsupported optionSet) {
+	set := o.given() & unsupported
+	for i, name := range optionNames {
+		if set&(1<<i) != 0 {
+			panic(fmt.Sprintf("%s does not support %s", fn, name))
+		}
+	}
+}
+
+// ErrSkipped is returned by a function to skip its argument, so its result
+// is not returned, as if the argument was not given. Mapping functions which
+// cannot skip results, such as CollectDedup, CollectResumable and mapping
+// functions over channels and readers, return it as an error.
+var ErrSkipped = errors.New("skipped argument")
+
+// MapErrors returns fn with mapper applied to each error it returns, with the
+// argument of the function, so errors can be wrapped with the argument or
+// translated in one place. The error returned by mapper replaces it, and if
+// mapper returns nil ErrSkipped is returned in its place, skipping the
+// argument. Functions over indexes, such as ForN, pass the index as the
+// argument.
+func MapErrors[I any, R any](fn func(I) (R, error), mapper func(I, error) error) func(I) (R, error) {
+	return func(in I) (R, error) {
+		v, err := fn(in)
+		if err == nil || err == ErrSearchSuccess || err == ErrSkipped {
+			return v, err
+		}
+		if err = mapper(in, err); err == nil {
+			return v, ErrSkipped
+		}
+		return v, err
+	}
+}
+
+// unskipped returns fn with ErrSkipped wrapped as an error, for mapping
+// functions which cannot skip results.
+func unskipped[I any, R any](name string, fn func(I) (R, error)) func(I) (R, error) {
+	return func(in I) (R, error) {
+		v, err := fn(in)
+		if err == ErrSkipped {
+			err = fmt.Errorf("%s cannot skip arguments: %w", name, err)
+		}
+		return v, err
+	}
+}
+
+// mapped returns fn with failures tolerated by WithMaxErrors of n arguments,
+// where skipped failures return ErrSkipped.
+func mapped[I any, R any](o *options, fn func(I) (R, error), n int) func(I) (R, error) {
+	if !o.tolerant {
+		return fn
+	}
+	limit := o.errorLimit(n)
+	return func(in I) (R, error) {
+		v, err := fn(in)
+		if err == nil || err == ErrSearchSuccess || err == ErrSkipped {
+			return v, err
+		}
+		return v, o.tolerate(err, limit)
+	}
+}
+
+// hooks are settings of a mapping function which use functions of its
+// argument or result types, given by the mapping functions which support
+// them. A nil *hooks has no settings.
+type hooks[I any, R any] struct {
+	shardHash   func(I) uint64 // routes arguments to workers
+	keep        func(R) bool   // reports if a result is returned
+	sizeOf      func(R) int    // size of results held by ordered functions
+	maxBuffered int            // limit of the size of held results
+}
+
+// route returns a function routing arguments to one of n workers by the hash
+// of their shard key, or nil if arguments are not sharded.
+func (h *hooks[I, R]) route(n int) func(I) int {
+	if h == nil || h.shardHash == nil || n <= 0 {
+		return nil
+	}
+	return func(e I) int {
+		return int(h.shardHash(e) % uint64(n))
+	}
+}
+
+// keeper returns a function reporting if a result should be returned, or nil
+// if all results are returned.
+func (h *hooks[I, R]) keeper() func(R) bool {
+	if h == nil {
+		return nil
+	}
+	return h.keep
+}
+
+// sizer returns the function sizing results and their limit, or nil if
+// results are not limited.
+func (h *hooks[I, R]) sizer() (func(R) int, int) {
+	if h == nil {
+		return nil, 0
+	}
+	return h.sizeOf, h.maxBuffered
+}
+
+// resultHooks returns the hooks of h for the results of error aware mapping
+// functions, which are not returned when skipped, and otherwise apply to
+// their value.
+func resultHooks[I any, R any](h *hooks[I, R]) *hooks[I, *F[R]] {
+	r := &hooks[I, *F[R]]{keep: func(f *F[R]) bool {
+		return f.E != ErrSkipped
+	}}
+	if h == nil {
+		return r
+	}
+	r.shardHash, r.maxBuffered = h.shardHash, h.maxBuffered
+	if keep := h.keep; keep != nil {
+		r.keep = func(f *F[R]) bool {
+			if f.E == nil {
+				return keep(f.V)
+			}
+			return f.E != ErrSkipped
+		}
+	}
+	if sizeOf := h.sizeOf; sizeOf != nil {
+		r.sizeOf = func(f *F[R]) int {
+			if f.E == nil {
+				return sizeOf(f.V)
+			}
+			return 0
+		}
+	}
+	return r
+}
+
+// distinctBy returns a function reporting if the key of a result was not
+// seen before.
+func distinctBy[R any, K comparable](key func(R) K) func(R) bool {
+	var mu sync.Mutex
+	seen := make(map[K]struct{})
+	return func(r R) bool {
+		k := key(r)
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := seen[k]; ok {
+			return false
+		}
+		seen[k] = struct{}{}
+		return true
+	}
+}
+
+// ErrTooManyErrors is joined with the failures of a mapping function which
+// exceeded the limit of WithMaxErrors or WithMaxErrorRate.
+var ErrTooManyErrors = errors.New("too many errors")
+
+// WithMaxErrors tolerates up to n failed functions. Failed results are
+// skipped, as if their argument was not given, and other arguments continue
+// to be processed. Once more than n functions fail, new arguments are not
+// processed and ErrTooManyErrors is returned joined with every failure.
+func WithMaxErrors(n int) Option {
+	return func(o *options) {
+		o.tolerant = true
+		o.maxErrors = n
+		o.maxErrorRate = 0
+	}
+}
+
+// WithMaxErrorRate is WithMaxErrors but tolerates failure of up to the given
+// fraction of the arguments, such as 0.01 for one percent.
+func WithMaxErrorRate(frac float64) Option {
+	return func(o *options) {
+		o.tolerant = true
+		o.maxErrors = 0
+		o.maxErrorRate = frac
+	}
+}
+
+// errorLimit returns the number of failures tolerated of n arguments.
+func (o *options) errorLimit(n int) int {
+	if o.maxErrorRate > 0 {
+		return int(o.maxErrorRate * float64(n))
+	}
+	return o.maxErrors
+}
+
+// tolerate a failure, returning ErrSkipped while within the limit, otherwise
+// ErrTooManyErrors joined with every failure.
+func (o *options) tolerate(err error, limit int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.failures = append(o.failures, err)
+	if len(o.failures) <= limit {
+		return ErrSkipped
+	}
+	return errors.Join(append([]error{ErrTooManyErrors}, o.failures...)...)
+}
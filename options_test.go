@@ -0,0 +1,360 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithCancelOnError(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(ctx context.Context, fn func(int) (int, error), opt Option) error
+	}{
+		{
+			name: "ForEach",
+			run: func(ctx context.Context, fn func(int) (int, error), opt Option) error {
+				return ForEachWithContext(ctx, 4, func(n int) error {
+					_, err := fn(n)
+					return err
+				}, testInts, opt)
+			},
+		},
+		{
+			name: "CollectUnordered",
+			run: func(ctx context.Context, fn func(int) (int, error), opt Option) error {
+				_, err := CollectUnorderedWithContext(ctx, 4, fn, testInts, opt)
+				return err
+			},
+		},
+		{
+			name: "MapErr",
+			run: func(ctx context.Context, fn func(int) (int, error), opt Option) (err error) {
+				next := MapErrWithContext(ctx, 4, fn, testInts, opt)
+				for _, e, ok := next(); ok; _, e, ok = next() {
+					if e != nil {
+						err = e
+					}
+				}
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, opt := WithCancelOnError(context.Background())
+			start := time.Now()
+			err := tt.run(ctx, func(n int) (int, error) {
+				if n == 3 {
+					return 0, testErr
+				}
+				select {
+				case <-ctx.Done():
+					return 0, ctx.Err()
+				case <-time.After(time.Second):
+					return n, nil
+				}
+			}, opt)
+			if err != testErr {
+				t.Errorf("Expected error=%v but received error=%v", testErr, err)
+			}
+			if elapsed := time.Since(start); elapsed > allowedVariance {
+				t.Errorf("Expected in-flight functions to be cancelled but elapsed=%v", elapsed)
+			}
+			if cause := context.Cause(ctx); cause != testErr {
+				t.Errorf("Expected cause=%v but received cause=%v", testErr, cause)
+			}
+		})
+	}
+
+	ctx, opt := WithCancelOnError(context.Background())
+	if err := ForEachWithContext(ctx, 4, func(n int) error {
+		return ctx.Err()
+	}, testInts, opt); err != nil {
+		t.Errorf("Expected no error but received error=%v", err)
+	}
+	if ctx.Err() == nil {
+		t.Errorf("Expected context cancelled on return")
+	}
+}
+
+func TestWithOrder(t *testing.T) {
+	double := func(n int) (int, error) {
+		time.Sleep(time.Duration(n%5) * time.Millisecond)
+		return n * 2, nil
+	}
+
+	tests := []struct {
+		name    string
+		policy  OrderPolicy
+		ordered bool
+		first   int
+		step    int
+	}{
+		{name: "ordered", policy: Ordered, ordered: true, first: 2, step: 2},
+		{name: "unordered", policy: Unordered},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r, err := Collect(4, double, testInts, WithOrder(tt.policy))
+			if err != nil || len(r) != len(testInts) {
+				t.Fatalf("Expected results=%v but received results=%v error=%v", len(testInts), len(r), err)
+			}
+			if !tt.ordered {
+				return
+			}
+			for i := range r {
+				if expect := tt.first + i*tt.step; r[i] != expect {
+					t.Fatalf("Expected result=%v at index=%v but received result=%v", expect, i, r[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWithOperationTimeout(t *testing.T) {
+	slow := func(n int) (int, error) {
+		time.Sleep(20 * time.Millisecond)
+		return n, nil
+	}
+	tests := []struct {
+		name string
+		run  func(opt Option) error
+	}{
+		{
+			name: "ForEach",
+			run: func(opt Option) error {
+				return ForEach(2, func(n int) error {
+					_, err := slow(n)
+					return err
+				}, testInts, opt)
+			},
+		},
+		{
+			name: "CollectUnordered",
+			run: func(opt Option) error {
+				_, err := CollectUnordered(2, slow, testInts, opt)
+				return err
+			},
+		},
+		{
+			name: "MapErr",
+			run: func(opt Option) (err error) {
+				next := MapErr(2, slow, testInts, opt)
+				for _, e, ok := next(); ok; _, e, ok = next() {
+					err = e
+				}
+				return err
+			},
+		},
+		{
+			name: "Map",
+			run: func(opt Option) error {
+				n := 0
+				for range Map(2, func(n int) int {
+					time.Sleep(20 * time.Millisecond)
+					return n
+				}, testInts, opt) {
+					n++
+				}
+				if n == len(testInts) {
+					return nil
+				}
+				return context.DeadlineExceeded
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			start := time.Now()
+			if err := tt.run(WithOperationTimeout(50 * time.Millisecond)); err != context.DeadlineExceeded {
+				t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+			}
+			if elapsed := time.Since(start); elapsed > 50*time.Millisecond+allowedVariance {
+				t.Errorf("Expected timeout=%v but received elapsed=%v", 50*time.Millisecond, elapsed)
+			}
+		})
+	}
+
+	if _, err := Collect(4, func(n int) (int, error) { return n, nil }, testInts, WithOperationTimeout(time.Second)); err != nil {
+		t.Errorf("Expected no error but received error=%v", err)
+	}
+}
+
+func TestWithOperationTimeoutClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	block := make(chan struct{})
+	defer close(block)
+	done := make(chan error)
+	go func() {
+		done <- ForEach(2, func(n int) error {
+			select {
+			case <-block:
+			case <-time.After(time.Second):
+			}
+			return nil
+		}, testInts, WithOperationTimeout(time.Minute), WithClock(clock))
+	}()
+	for clock.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Minute)
+	if err := <-done; err != context.DeadlineExceeded {
+		t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestMapErrors(t *testing.T) {
+	errSkip := errors.New("skip")
+	fn := func(n int) (int, error) {
+		switch {
+		case n%10 == 0:
+			return 0, errSkip
+		case n == 35:
+			return 0, testErr
+		}
+		return n, nil
+	}
+	mapper := MapErrors(fn, func(n int, err error) error {
+		if err == errSkip {
+			return nil
+		}
+		return fmt.Errorf("argument %d: %w", n, err)
+	})
+
+	r, err := Collect(4, mapper, testInts[:30])
+	if err != nil || len(r) != 27 {
+		t.Fatalf("Expected results=%v but received results=%v error=%v", 27, len(r), err)
+	}
+	for _, n := range r {
+		if n%10 == 0 {
+			t.Errorf("Expected skipped result=%v not to be returned", n)
+		}
+	}
+
+	_, err = Collect(4, mapper, testInts)
+	if !errors.Is(err, testErr) || err.Error() != "argument 35: "+testErr.Error() {
+		t.Errorf("Expected mapped error but received error=%v", err)
+	}
+
+	var n int
+	next := MapErrUnordered(4, mapper, testInts[:30])
+	for _, err, ok := next(); ok; _, err, ok = next() {
+		if err != nil {
+			t.Errorf("Expected no error but received error=%v", err)
+		}
+		n++
+	}
+	if n != 27 {
+		t.Errorf("Expected results=%v but received results=%v", 27, n)
+	}
+
+	if err := ForEach(4, func(n int) error {
+		_, err := mapper(n)
+		return err
+	}, testInts[:30]); err != nil {
+		t.Errorf("Expected no error but received error=%v", err)
+	}
+
+	_, err = CollectDedup(4, mapper, testInts[:30])
+	if !errors.Is(err, ErrSkipped) || err == ErrSkipped {
+		t.Errorf("Expected wrapped error=%v but received error=%v", ErrSkipped, err)
+	}
+}
+
+func TestWithSeededOrder(t *testing.T) {
+	run := func(seed int64) []int {
+		var seen []int
+		err := ForEachUnordered(1, func(n int) error {
+			seen = append(seen, n)
+			return nil
+		}, testInts, WithSeededOrder(seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return seen
+	}
+
+	a, b, c := run(1), run(1), run(2)
+	if len(a) != len(testInts) {
+		t.Fatalf("Expected arguments=%v but received arguments=%v", len(testInts), len(a))
+	}
+	var sameAsB, sameAsC, sorted = true, true, true
+	for i := range a {
+		sameAsB = sameAsB && a[i] == b[i]
+		sameAsC = sameAsC && a[i] == c[i]
+		sorted = sorted && a[i] == testInts[i]
+	}
+	if !sameAsB {
+		t.Errorf("Expected equal seeds to process in the same order but received %v and %v", a, b)
+	}
+	if sameAsC || sorted {
+		t.Errorf("Expected seeds to permute arguments differently but received %v and %v", a, c)
+	}
+
+	r, err := Collect(4, func(n int) (int, error) { return n, nil }, testInts, WithSeededOrder(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range r {
+		if r[i] != a[i] {
+			t.Fatalf("Expected ordered results in permuted order=%v but received %v", a, r)
+		}
+	}
+}
+
+func TestWithMaxErrors(t *testing.T) {
+	fn := func(n int) (int, error) {
+		if n%10 == 0 {
+			return 0, fmt.Errorf("failed %d", n)
+		}
+		return n, nil
+	}
+	tests := []struct {
+		name   string
+		opt    Option
+		failed bool
+	}{
+		{name: "within max errors", opt: WithMaxErrors(6)},
+		{name: "exceeds max errors", opt: WithMaxErrors(5), failed: true},
+		{name: "within max error rate", opt: WithMaxErrorRate(0.1)},
+		{name: "exceeds max error rate", opt: WithMaxErrorRate(0.05), failed: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r, err := Collect(4, fn, testInts, tt.opt)
+			if tt.failed {
+				if !errors.Is(err, ErrTooManyErrors) || !strings.Contains(err.Error(), "failed 10") {
+					t.Errorf("Expected error=%v joined with failures but received error=%v", ErrTooManyErrors, err)
+				}
+				return
+			}
+			if err != nil || len(r) != 54 {
+				t.Fatalf("Expected results=%v but received results=%v error=%v", 54, len(r), err)
+			}
+			for _, n := range r {
+				if n%10 == 0 {
+					t.Errorf("Expected failed result=%v not to be returned", n)
+				}
+			}
+		})
+	}
+
+	if err := ForEach(4, func(n int) error {
+		_, err := fn(n)
+		return err
+	}, testInts, WithMaxErrors(6)); err != nil {
+		t.Errorf("Expected no error but received error=%v", err)
+	}
+}
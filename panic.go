@@ -0,0 +1,39 @@
+package goroutines
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is returned in place of a panic recovered from a function
+// executed in a goroutine managed by this package.
+type PanicError struct {
+	// Value passed to panic.
+	Value any
+
+	// Stack of the panicking goroutine.
+	Stack []byte
+}
+
+// Error describes the recovered panic value.
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("recovered panic: %v", p.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (p *PanicError) Unwrap() error {
+	if err, ok := p.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// protect calls fn, converting a panic into a PanicError.
+func protect(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,212 @@
+package goroutines
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pool is shared by mapping functions with WithPool, limiting the functions
+// running at once across all of them and collecting statistics of their
+// workers.
+type Pool struct {
+	sem        *TimedMutex
+	dispatched atomic.Uint64
+	completed  atomic.Uint64
+	busy       atomic.Int64
+	blocked    atomic.Int64
+
+	mu     sync.Mutex
+	paused chan struct{} // closed on resume, nil if not paused
+}
+
+// PoolStats are counters of a Pool. See Pool.Stats.
+//
+// Blocked much greater than Busy indicates the consumer of results is slower
+// than the functions, and larger buffers or more workers will not help.
+type PoolStats struct {
+	Tasks   uint64        // functions completed
+	Busy    time.Duration // total time running functions
+	Blocked time.Duration // total time blocked delivering results to the consumer
+	Waiting time.Duration // total time waiting for the limit of the pool
+	Running int           // functions currently running
+	Queued  int           // functions currently waiting for the limit of the pool
+}
+
+// NewPool returns a Pool running at most n functions at once, or unlimited
+// functions if n is not positive.
+func NewPool(n int) *Pool {
+	return &Pool{sem: NewVariableTimedMutex(poolLimit(n))}
+}
+
+// poolLimit returns the limit of the semaphore of a pool of size n.
+func poolLimit(n int) int {
+	if n <= 0 {
+		return math.MaxInt
+	}
+	return n
+}
+
+// Resize changes how many functions the pool runs at once, or makes it
+// unlimited if n is not positive. When the pool shrinks, running functions
+// finish and surplus workers then wait until the running functions are
+// below the new limit. Mapping functions using the pool are not restarted.
+func (p *Pool) Resize(n int) {
+	p.sem.SetLimit(poolLimit(n))
+}
+
+// Limit returns how many functions the pool runs at once, or 0 if unlimited.
+func (p *Pool) Limit() int {
+	if n := p.sem.Limit(); n != math.MaxInt {
+		return n
+	}
+	return 0
+}
+
+// WithPool runs the functions of a mapping function in the given Pool.
+func WithPool(p *Pool) Option {
+	return func(o *options) {
+		o.pool = p
+	}
+}
+
+// Stats returns the counters of the pool.
+func (p *Pool) Stats() PoolStats {
+	stats := PoolStats{
+		Tasks:   p.completed.Load(),
+		Busy:    time.Duration(p.busy.Load()),
+		Blocked: time.Duration(p.blocked.Load()),
+		Running: p.InFlight(),
+	}
+	ms := p.sem.Stats()
+	stats.Waiting = ms.WaitTotal
+	stats.Queued = ms.Waiting
+	return stats
+}
+
+// InFlight returns the number of functions currently running in the pool.
+func (p *Pool) InFlight() int {
+	completed := p.completed.Load() // never exceeds dispatched when loaded first
+	return int(p.dispatched.Load() - completed)
+}
+
+// Dispatched returns the number of functions started in the pool.
+func (p *Pool) Dispatched() uint64 {
+	return p.dispatched.Load()
+}
+
+// Completed returns the number of functions completed in the pool.
+func (p *Pool) Completed() uint64 {
+	return p.completed.Load()
+}
+
+// Pause stops the pool starting functions until Resume is called. Running
+// functions finish, and their workers then wait to start the next. Mapping
+// functions are not cancelled, so no completed work is lost.
+func (p *Pool) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused == nil {
+		p.paused = make(chan struct{})
+	}
+}
+
+// Resume starts functions of a paused pool again.
+func (p *Pool) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused != nil {
+		close(p.paused)
+		p.paused = nil
+	}
+}
+
+// Paused returns true if the pool is paused.
+func (p *Pool) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused != nil
+}
+
+// Submit runs fn in its own goroutine once the pool has room, sharing the
+// limit of the pool with mapping functions using it. A panic in fn is
+// returned as a PanicError. A nil pool runs fn without a limit.
+func Submit[T any](p *Pool, fn func() (T, error)) *Future[T] {
+	return SubmitWithContext(context.Background(), p, fn)
+}
+
+// SubmitWithContext is Submit but with a context. If the context is
+// cancelled before fn starts, fn is not run and the future resolves with the
+// error of the context.
+func SubmitWithContext[T any](ctx context.Context, p *Pool, fn func() (T, error)) *Future[T] {
+	f := newFuture[T]()
+	go func() {
+		var v T
+		start, err := p.begin(ctx)
+		if err == nil {
+			err = protect(func() (err error) {
+				v, err = fn()
+				return
+			})
+			p.end(start)
+		}
+		f.resolve(v, err)
+	}()
+	return f
+}
+
+// begin a function, returning its start time. A nil pool does nothing.
+func (p *Pool) begin(ctx context.Context) (time.Time, error) {
+	if p == nil {
+		return time.Time{}, nil
+	}
+	for {
+		p.mu.Lock()
+		paused := p.paused
+		p.mu.Unlock()
+		if paused == nil {
+			break
+		}
+		select {
+		case <-paused:
+		case <-ctx.Done():
+			return time.Time{}, ctx.Err()
+		}
+	}
+	if err := p.sem.AcquireWithContext(ctx, 1); err != nil {
+		return time.Time{}, err
+	}
+	p.dispatched.Add(1)
+	return time.Now(), nil
+}
+
+// end a function started at the given time, returning the time it ended.
+func (p *Pool) end(start time.Time) time.Time {
+	if p == nil {
+		return time.Time{}
+	}
+	now := time.Now()
+	p.sem.Release(1)
+	p.completed.Add(1)
+	p.busy.Add(int64(now.Sub(start)))
+	return now
+}
+
+// now returns the time before delivering a result.
+func (p *Pool) now() time.Time {
+	if p == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// delivered records the time blocked delivering a result since the given
+// time.
+func (p *Pool) delivered(since time.Time) {
+	if p == nil {
+		return
+	}
+	p.blocked.Add(int64(time.Since(since)))
+}
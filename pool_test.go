@@ -0,0 +1,244 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolStats(t *testing.T) {
+	tests := []struct {
+		name         string
+		work         time.Duration
+		consume      time.Duration
+		slowConsumer bool
+	}{
+		{name: "slow function", work: 5 * time.Millisecond},
+		{name: "slow consumer", consume: 5 * time.Millisecond, slowConsumer: true},
+	}
+	for _, tt := range tests {
+		for _, order := range []OrderPolicy{Ordered, Unordered} {
+			p := NewPool(0)
+			for range Map(4, func(n int) int {
+				time.Sleep(tt.work)
+				return n
+			}, testInts[:20], WithPool(p), WithOrder(order)) {
+				time.Sleep(tt.consume)
+			}
+			stats := p.Stats()
+			if stats.Tasks != 20 {
+				t.Errorf("Expected tasks=%v but received tasks=%v", 20, stats.Tasks)
+			}
+			if (stats.Blocked > stats.Busy) != tt.slowConsumer {
+				t.Errorf("%s: Expected slow consumer=%v but received busy=%v blocked=%v", tt.name, tt.slowConsumer, stats.Busy, stats.Blocked)
+			}
+		}
+	}
+}
+
+func TestPoolLimit(t *testing.T) {
+	p := NewPool(2)
+	var running, peak atomic.Int64
+	fn := func(n int) (int, error) {
+		r := running.Add(1)
+		for {
+			if m := peak.Load(); r <= m || peak.CompareAndSwap(m, r) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		return n, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := Collect(4, fn, testInts, WithPool(p)); err != nil {
+				t.Errorf("Expected no error but received error=%v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := peak.Load(); n != 2 {
+		t.Errorf("Expected peak=%v but received peak=%v", 2, n)
+	}
+	if stats := p.Stats(); stats.Tasks != 180 || stats.Running != 0 || stats.Waiting == 0 {
+		t.Errorf("Expected tasks=%v running=%v and waiting but received stats=%+v", 180, 0, stats)
+	}
+}
+
+func TestPoolCounters(t *testing.T) {
+	p := NewPool(2)
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- ForEach(4, func(n int) error {
+			<-release
+			return nil
+		}, testInts[:6], WithPool(p))
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if n, d, c := p.InFlight(), p.Dispatched(), p.Completed(); n != 2 || d != 2 || c != 0 {
+		t.Errorf("Expected in flight=2 dispatched=2 completed=0 but received in flight=%v dispatched=%v completed=%v", n, d, c)
+	}
+	if q := p.Stats().Queued; q != 2 {
+		t.Errorf("Expected queued=%v but received queued=%v", 2, q)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n, d, c := p.InFlight(), p.Dispatched(), p.Completed(); n != 0 || d != 6 || c != 6 {
+		t.Errorf("Expected in flight=0 dispatched=6 completed=6 but received in flight=%v dispatched=%v completed=%v", n, d, c)
+	}
+}
+
+func TestPoolPause(t *testing.T) {
+	p := NewPool(0)
+	p.Pause()
+	p.Pause() // idempotent
+	if !p.Paused() {
+		t.Fatalf("Expected pool to be paused")
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- ForEach(4, func(n int) error {
+			time.Sleep(2 * time.Millisecond)
+			return nil
+		}, testInts, WithPool(p))
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if d := p.Dispatched(); d != 0 {
+		t.Errorf("Expected dispatched=%v while paused but received dispatched=%v", 0, d)
+	}
+
+	p.Resume()
+	time.Sleep(5 * time.Millisecond)
+	p.Pause()
+	time.Sleep(5 * time.Millisecond) // running functions finish
+	c := p.Completed()
+	if c == 0 || c == uint64(len(testInts)) || p.InFlight() != 0 {
+		t.Errorf("Expected partial completion while paused but received completed=%v in flight=%v", c, p.InFlight())
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := p.Completed(); n != c {
+		t.Errorf("Expected completed=%v while paused but received completed=%v", c, n)
+	}
+
+	p.Resume()
+	p.Resume() // idempotent
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if c := p.Completed(); c != uint64(len(testInts)) {
+		t.Errorf("Expected completed=%v but received completed=%v", len(testInts), c)
+	}
+
+	p.Pause()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := ForEachWithContext(ctx, 4, func(n int) error { return nil }, testInts, WithPool(p)); err != context.DeadlineExceeded {
+		t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestPoolResize(t *testing.T) {
+	p := NewPool(0)
+	if n := p.Limit(); n != 0 {
+		t.Errorf("Expected limit=%v but received limit=%v", 0, n)
+	}
+	p.Resize(2)
+
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- ForEach(8, func(n int) error {
+			<-release
+			return nil
+		}, testInts[:16], WithPool(p))
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if n := p.InFlight(); n != 2 {
+		t.Errorf("Expected in flight=%v but received in flight=%v", 2, n)
+	}
+	p.Resize(6)
+	time.Sleep(20 * time.Millisecond)
+	if n, l := p.InFlight(), p.Limit(); n != 6 || l != 6 {
+		t.Errorf("Expected in flight=%v limit=%v but received in flight=%v limit=%v", 6, 6, n, l)
+	}
+
+	p.Resize(1)
+	for i := 0; i < 6; i++ {
+		release <- struct{}{}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := p.InFlight(); n != 1 {
+		t.Errorf("Expected in flight=%v after shrinking but received in flight=%v", 1, n)
+	}
+
+	p.Resize(0)
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n := p.Limit(); n != 0 {
+		t.Errorf("Expected limit=%v but received limit=%v", 0, n)
+	}
+}
+
+func TestPoolSubmit(t *testing.T) {
+	p := NewPool(1)
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- ForEach(2, func(n int) error {
+			<-release
+			return nil
+		}, testInts[:2], WithPool(p))
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	f := Submit(p, func() (string, error) {
+		return "foo", nil
+	})
+	select {
+	case <-f.Done():
+		t.Fatalf("Expected submitted function to wait for the pool")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	if v, err := f.Wait(); err != nil || v != "foo" {
+		t.Errorf("Expected result=%v but received result=%v error=%v", "foo", v, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if c := p.Completed(); c != 3 {
+		t.Errorf("Expected completed=%v but received completed=%v", 3, c)
+	}
+
+	var perr *PanicError
+	if _, err := Submit(p, func() (int, error) { panic("boom") }).Wait(); !errors.As(err, &perr) {
+		t.Errorf("Expected panic error but received error=%v", err)
+	}
+	if _, err := Submit(nil, func() (int, error) { return 0, testErr }).Wait(); err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+
+	p.Pause()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := SubmitWithContext(ctx, p, func() (int, error) { return 1, nil }).Wait(); err != context.Canceled {
+		t.Errorf("Expected error=%v but received error=%v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,169 @@
+package goroutines
+
+import (
+	"container/heap"
+	"math"
+	"sync"
+	"time"
+)
+
+type priorityTask struct {
+	fn   func()
+	rank int64  // higher runs first
+	seq  uint64 // order of submission among equal ranks
+}
+
+type priorityTasks []priorityTask
+
+func (t priorityTasks) Len() int { return len(t) }
+func (t priorityTasks) Less(i, j int) bool {
+	if t[i].rank != t[j].rank {
+		return t[i].rank > t[j].rank
+	}
+	return t[i].seq < t[j].seq
+}
+func (t priorityTasks) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
+func (t *priorityTasks) Push(x any)   { *t = append(*t, x.(priorityTask)) }
+func (t *priorityTasks) Pop() any {
+	old := *t
+	task := old[len(old)-1]
+	old[len(old)-1] = priorityTask{}
+	*t = old[:len(old)-1]
+	return task
+}
+
+// PriorityPool runs submitted functions in a limited number of goroutines,
+// always starting the pending function with the highest priority next.
+// Functions with equal priority start in order of submission.
+type PriorityPool struct {
+	mu      sync.Mutex
+	limit   int
+	running int
+	tasks   priorityTasks
+	seq     uint64
+	aging   time.Duration
+	epoch   time.Time // start of aging
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// NewPriorityPool returns a PriorityPool running at most n functions at once.
+// Workers less than one uses the default pool size.
+func NewPriorityPool(n int) *PriorityPool {
+	if n <= 0 {
+		n = defaultPoolSize
+	}
+	return &PriorityPool{limit: n}
+}
+
+// WithAging raises the priority of pending functions by one for each
+// duration d they wait, so low priority functions are not starved by a
+// steady stream of higher priority ones. Aging only applies to functions
+// submitted afterwards. Aged priorities saturate rather than overflow, so
+// functions whose priority saturates start in order of submission.
+func (p *PriorityPool) WithAging(d time.Duration) *PriorityPool {
+	p.mu.Lock()
+	p.aging = d
+	if p.epoch.IsZero() {
+		p.epoch = time.Now()
+	}
+	p.mu.Unlock()
+	return p
+}
+
+// Submit queues fn with the given priority, where larger numbers run first.
+// ErrQueueClosed is returned if the pool is closed.
+func (p *PriorityPool) Submit(priority int, fn func()) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrQueueClosed
+	}
+
+	// Every pending function ages at the same rate, so ranking by priority
+	// in nanoseconds of aging less the nanoseconds since the epoch at
+	// submission orders them as if their priority grew while waiting.
+	rank := int64(priority)
+	if p.aging > 0 {
+		rank = saturatingSub(saturatingMul(rank, int64(p.aging)), int64(time.Since(p.epoch)))
+	}
+	heap.Push(&p.tasks, priorityTask{fn: fn, rank: rank, seq: p.seq})
+	p.seq++
+
+	if p.running < p.limit {
+		p.running++
+		p.wg.Add(1)
+		go p.work()
+	}
+	return nil
+}
+
+// Len returns the number of functions waiting to start.
+func (p *PriorityPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.tasks)
+}
+
+// Close stops accepting functions and waits for pending functions to finish.
+func (p *PriorityPool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// work runs pending functions until there are none.
+func (p *PriorityPool) work() {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		if len(p.tasks) == 0 {
+			p.running--
+			p.mu.Unlock()
+			return
+		}
+		task := heap.Pop(&p.tasks).(priorityTask)
+		p.mu.Unlock()
+		task.fn()
+	}
+}
+
+// SubmitPriority is PriorityPool.Submit for a function with a result. A
+// panic in fn is returned as a PanicError.
+func SubmitPriority[T any](p *PriorityPool, priority int, fn func() (T, error)) *Future[T] {
+	f := newFuture[T]()
+	err := p.Submit(priority, func() {
+		var v T
+		err := protect(func() (err error) {
+			v, err = fn()
+			return
+		})
+		f.resolve(v, err)
+	})
+	if err != nil {
+		f.resolve(*new(T), err)
+	}
+	return f
+}
+
+// saturatingMul returns a*b clamped to the range of int64, where b is
+// positive.
+func saturatingMul(a, b int64) int64 {
+	switch {
+	case a > math.MaxInt64/b:
+		return math.MaxInt64
+	case a < math.MinInt64/b:
+		return math.MinInt64
+	}
+	return a * b
+}
+
+// saturatingSub returns a-b clamped to the range of int64, where b is not
+// negative.
+func saturatingSub(a, b int64) int64 {
+	if a < math.MinInt64+b {
+		return math.MinInt64
+	}
+	return a - b
+}
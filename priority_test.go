@@ -0,0 +1,103 @@
+package goroutines
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPriorityPool(t *testing.T) {
+	p := NewPriorityPool(1)
+	release := make(chan struct{})
+	_ = p.Submit(0, func() { <-release })
+	time.Sleep(10 * time.Millisecond) // blocks the only worker
+
+	var mu sync.Mutex
+	var order []int
+	for i, priority := range []int{1, 5, 3, 5, 0} {
+		i := i
+		_ = p.Submit(priority, func() {
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+		})
+	}
+	if n := p.Len(); n != 5 {
+		t.Errorf("Expected pending=%v but received pending=%v", 5, n)
+	}
+	close(release)
+
+	f := SubmitPriority(p, 0, func() (int, error) { return 7, nil })
+	if v, err := f.Wait(); err != nil || v != 7 {
+		t.Errorf("Expected result=%v but received result=%v error=%v", 7, v, err)
+	}
+	p.Close()
+
+	expect := []int{1, 3, 2, 0, 4}
+	for i := range expect {
+		if order[i] != expect[i] {
+			t.Fatalf("Expected order=%v but received order=%v", expect, order)
+		}
+	}
+	if err := p.Submit(0, func() {}); err != ErrQueueClosed {
+		t.Errorf("Expected error=%v but received error=%v", ErrQueueClosed, err)
+	}
+	if _, err := SubmitPriority(p, 0, func() (int, error) { return 0, nil }).Wait(); err != ErrQueueClosed {
+		t.Errorf("Expected error=%v but received error=%v", ErrQueueClosed, err)
+	}
+}
+
+func TestPriorityPoolAging(t *testing.T) {
+	p := NewPriorityPool(1).WithAging(10 * time.Millisecond)
+	release := make(chan struct{})
+	_ = p.Submit(0, func() { <-release })
+	time.Sleep(10 * time.Millisecond) // blocks the only worker
+
+	var mu sync.Mutex
+	var order []string
+	record := func(s string) func() {
+		return func() {
+			mu.Lock()
+			order = append(order, s)
+			mu.Unlock()
+		}
+	}
+	_ = p.Submit(0, record("old"))
+	time.Sleep(50 * time.Millisecond)
+	_ = p.Submit(2, record("new"))
+	close(release)
+	p.Close()
+
+	if len(order) != 2 || order[0] != "old" {
+		t.Errorf("Expected aged function to run first but received order=%v", order)
+	}
+}
+
+func TestPriorityPoolAgingSaturates(t *testing.T) {
+	p := NewPriorityPool(1).WithAging(time.Hour)
+	p.epoch = p.epoch.Add(-100 * 365 * 24 * time.Hour) // a long wait
+	release := make(chan struct{})
+	_ = p.Submit(0, func() { <-release })
+	time.Sleep(10 * time.Millisecond) // blocks the only worker
+
+	var mu sync.Mutex
+	var order []int
+	for _, priority := range []int{0, math.MaxInt, math.MinInt, 1} {
+		priority := priority
+		_ = p.Submit(priority, func() {
+			mu.Lock()
+			order = append(order, priority)
+			mu.Unlock()
+		})
+	}
+	close(release)
+	p.Close()
+
+	expect := []int{math.MaxInt, 1, 0, math.MinInt}
+	for i := range expect {
+		if order[i] != expect[i] {
+			t.Fatalf("Expected order=%v but received order=%v", expect, order)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package goroutines
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+)
+
+// ProcessJSONL decodes each line of r as JSON and applys function to the
+// decoded value in a pool of qlen workers, passing results to consume in the
+// order of lines, or as they complete with WithOrder(Unordered). Blank lines
+// are skipped. Processing stops on the first error returned by decoding, fn,
+// consume, or reading r. Options are supported as by MapChan.
+func ProcessJSONL[T any, R any](ctx context.Context, qlen int, fn func(T) (R, error), r io.Reader, consume func(R) error, opts ...Option) error {
+	o := newOptions(opts)
+	o.reject("ProcessJSONL", streamRejects)
+	scanner := bufio.NewScanner(r)
+	return process(ctx, qlen, func(b []byte) (R, error) {
+		var v T
+		if err := json.Unmarshal(b, &v); err != nil {
+			var rn R
+			return rn, err
+		}
+		return fn(v)
+	}, func() ([]byte, error) {
+		for scanner.Scan() {
+			if b := bytes.TrimSpace(scanner.Bytes()); len(b) > 0 {
+				return append([]byte(nil), b...), nil
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}, consume, o)
+}
+
+// ProcessCSV applys function to each record of r in a pool of qlen workers,
+// passing results to consume in the order of records, or as they complete
+// with WithOrder(Unordered). Records are parsed sequentially, since quoted
+// fields may span lines, so fn should perform any decoding of fields.
+// Processing stops on the first error returned by parsing, fn, or consume.
+// Options are supported as by MapChan.
+func ProcessCSV[R any](ctx context.Context, qlen int, fn func([]string) (R, error), r *csv.Reader, consume func(R) error, opts ...Option) error {
+	o := newOptions(opts)
+	o.reject("ProcessCSV", streamRejects)
+	r.ReuseRecord = false // records are retained by workers
+	return process(ctx, qlen, fn, r.Read, consume, o)
+}
+
+// process maps elements returned by read by the OrderPolicy of o, passing
+// results to consume.
+func process[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), read func() (I, error), consume func(R) error, o *options) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	next := mapReader(ctx, qlen, fn, read, o)
+	for v, err, ok := next(); ok; v, err, ok = next() {
+		if err == nil {
+			err = consume(v)
+		}
+		if err != nil {
+			cancel()
+			for _, _, ok := next(); ok; _, _, ok = next() {
+				// consume all remaining workers
+			}
+			return err
+		}
+	}
+	return nil
+}
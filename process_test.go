@@ -0,0 +1,107 @@
+package goroutines
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testRecord struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestProcessJSONL(t *testing.T) {
+	var input strings.Builder
+	for i, s := range testStrings {
+		input.WriteString(`{"name":"` + s + `","count":` + strconv.Itoa(i) + "}\n\n")
+	}
+	describe := func(r testRecord) (string, error) {
+		if r.Count < 0 {
+			return "", errors.New("negative count")
+		}
+		return r.Name + strconv.Itoa(r.Count), nil
+	}
+
+	var got []string
+	err := ProcessJSONL(context.Background(), 4, describe, strings.NewReader(input.String()), func(s string) error {
+		got = append(got, s)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but received error=%v", err)
+	}
+	if len(got) != len(testStrings) {
+		t.Fatalf("Expected results=%v but received results=%v", len(testStrings), len(got))
+	}
+	for i, s := range testStrings {
+		if expect := s + strconv.Itoa(i); got[i] != expect {
+			t.Errorf("Expected result=%v but received result=%v", expect, got[i])
+		}
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		consume func(string) error
+		expect  string
+	}{
+		{
+			name:    "decode error",
+			input:   "{\"name\":\"a\"}\n{bad}\n",
+			consume: func(string) error { return nil },
+			expect:  "invalid character 'b' looking for beginning of object key string",
+		},
+		{
+			name:    "function error",
+			input:   "{\"name\":\"a\",\"count\":-1}\n",
+			consume: func(string) error { return nil },
+			expect:  "negative count",
+		},
+		{
+			name:    "consumer error",
+			input:   input.String(),
+			consume: func(string) error { return testErr },
+			expect:  testErr.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ProcessJSONL(context.Background(), 4, describe, strings.NewReader(tt.input), tt.consume)
+			if err == nil || err.Error() != tt.expect {
+				t.Errorf("Expected error=%v but received error=%v", tt.expect, err)
+			}
+		})
+	}
+}
+
+func TestProcessCSV(t *testing.T) {
+	input := "a,1\n\"multi\nline\",2\nc,3\n"
+	sum := 0
+	var names []string
+	err := ProcessCSV(context.Background(), 2, func(rec []string) (testRecord, error) {
+		n, err := strconv.Atoi(rec[1])
+		return testRecord{Name: rec[0], Count: n}, err
+	}, csv.NewReader(strings.NewReader(input)), func(r testRecord) error {
+		names = append(names, r.Name)
+		sum += r.Count
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but received error=%v", err)
+	}
+	if sum != 6 || len(names) != 3 || names[1] != "multi\nline" {
+		t.Errorf("Expected sum=%v names=%q but received sum=%v names=%q", 6, []string{"a", "multi\nline", "c"}, sum, names)
+	}
+
+	err = ProcessCSV(context.Background(), 2, func(rec []string) (int, error) {
+		return strconv.Atoi(rec[1])
+	}, csv.NewReader(strings.NewReader("a,1\nb,2,3\n")), func(int) error { return nil })
+	var perr *csv.ParseError
+	if !errors.As(err, &perr) {
+		t.Errorf("Expected parse error but received error=%v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package goroutines
+
+import "context"
+
+type product[A any, B any] struct {
+	a A
+	b B
+}
+
+// productSource of the cross product of two slices, in order of as then bs.
+// Pairs are created as they are dispatched rather than all at once.
+func productSource[A any, B any](as []A, bs []B) source[product[A, B]] {
+	if len(bs) == 0 {
+		return source[product[A, B]]{0, nil}
+	}
+	return source[product[A, B]]{len(as) * len(bs), func(i int) product[A, B] {
+		return product[A, B]{as[i/len(bs)], bs[i%len(bs)]}
+	}}
+}
+
+// MapProduct is MapErr over each pair of the cross product of as and bs, in
+// order of as then bs. Pairs are not materialized, so the cross product only
+// uses memory for the results held by the pool.
+// All results must be consumed or goroutines may leak.
+//
+// Call the returned function until bool is false to consume all results.
+// If an error is returned, new pairs will not be processed.
+func MapProduct[A any, B any, R any](qlen int, fn func(A, B) (R, error), as []A, bs []B, opts ...Option) func() (R, error, bool) {
+	return MapProductWithContext(context.Background(), qlen, fn, as, bs, opts...)
+}
+
+// MapProductUnordered is MapProduct but results are returned as they complete.
+func MapProductUnordered[A any, B any, R any](qlen int, fn func(A, B) (R, error), as []A, bs []B, opts ...Option) func() (R, error, bool) {
+	return MapProductUnorderedWithContext(context.Background(), qlen, fn, as, bs, opts...)
+}
+
+// MapProductWithContext is MapProduct but with a context.
+func MapProductWithContext[A any, B any, R any](ctx context.Context, qlen int, fn func(A, B) (R, error), as []A, bs []B, opts ...Option) func() (R, error, bool) {
+	return mapErr(ctx, qlen, func(p product[A, B]) (R, error) {
+		return fn(p.a, p.b)
+	}, productSource(as, bs), newOptions(opts), nil)
+}
+
+// MapProductUnorderedWithContext is an unordered version of
+// MapProductWithContext.
+func MapProductUnorderedWithContext[A any, B any, R any](ctx context.Context, qlen int, fn func(A, B) (R, error), as []A, bs []B, opts ...Option) func() (R, error, bool) {
+	return MapProductWithContext(ctx, qlen, fn, as, bs, unordered(opts)...)
+}
@@ -0,0 +1,67 @@
+package goroutines
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestMapProduct(t *testing.T) {
+	as := []int{1, 2, 3}
+	bs := []int{10, 20}
+	multiply := func(a, b int) (int, error) {
+		return a * b, nil
+	}
+	expect := []int{10, 20, 20, 40, 30, 60}
+
+	tests := []struct {
+		name    string
+		next    func() (int, error, bool)
+		ordered bool
+	}{
+		{name: "ordered", next: MapProduct(2, multiply, as, bs), ordered: true},
+		{name: "unordered", next: MapProductUnordered(2, multiply, as, bs)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r []int
+			for v, err, ok := tt.next(); ok; v, err, ok = tt.next() {
+				if err != nil {
+					t.Fatalf("Expected no error but received error=%v", err)
+				}
+				r = append(r, v)
+			}
+			want := append([]int(nil), expect...)
+			if !tt.ordered {
+				sort.Ints(r)
+				sort.Ints(want)
+			}
+			if len(r) != len(want) {
+				t.Fatalf("Expected results=%v but received results=%v", want, r)
+			}
+			for i := range want {
+				if r[i] != want[i] {
+					t.Errorf("Expected result=%v but received result=%v", want[i], r[i])
+				}
+			}
+		})
+	}
+
+	if _, _, ok := MapProduct(2, multiply, as, nil)(); ok {
+		t.Errorf("Expected no results for empty product")
+	}
+	next := MapProduct(2, func(a, b int) (int, error) {
+		if a == 2 {
+			return 0, testErr
+		}
+		return a * b, nil
+	}, as, bs)
+	var err error
+	for _, e, ok := next(); ok; _, e, ok = next() {
+		if e != nil && err == nil {
+			err = e
+		}
+	}
+	if err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+}
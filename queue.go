@@ -0,0 +1,159 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrQueueFull occurs when a bounded queue rejects an item
+	ErrQueueFull = errors.New("queue is full")
+
+	// ErrQueueClosed occurs when submitting to a closed queue
+	ErrQueueClosed = errors.New("queue is closed")
+)
+
+// OverflowPolicy determines what happens when a bounded queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits until space is available.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropOldest discards the oldest queued item to make room.
+	OverflowDropOldest
+
+	// OverflowReject returns ErrQueueFull without queueing the item.
+	OverflowReject
+)
+
+// Queue is a bounded work queue processed by a fixed number of workers.
+// Unlike the mapping functions, items may be submitted continuously for the
+// lifetime of the queue.
+type Queue[T any] struct {
+	mu      sync.RWMutex
+	fn      func(T)
+	c       chan T
+	policy  OverflowPolicy
+	closed  bool
+	dropped atomic.Uint64
+	wg      sync.WaitGroup
+}
+
+// NewQueue returns a running Queue which buffers up to capacity items and
+// calls fn with each item in one of workers goroutines. Capacity less than
+// one is treated as one, and workers less than one uses the default pool size.
+func NewQueue[T any](capacity, workers int, fn func(T)) *Queue[T] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	if workers <= 0 {
+		workers = defaultPoolSize
+	}
+	q := &Queue[T]{
+		fn: fn,
+		c:  make(chan T, capacity),
+	}
+	q.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+// WithOverflow sets the policy applied by Submit when the queue is full.
+func (q *Queue[T]) WithOverflow(policy OverflowPolicy) *Queue[T] {
+	q.mu.Lock()
+	q.policy = policy
+	q.mu.Unlock()
+	return q
+}
+
+// Submit queues an item according to the overflow policy.
+func (q *Queue[T]) Submit(v T) error {
+	return q.SubmitWithContext(context.Background(), v)
+}
+
+// SubmitWithContext is Submit but blocking may be aborted by the context.
+func (q *Queue[T]) SubmitWithContext(ctx context.Context, v T) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	switch q.policy {
+	case OverflowReject:
+		select {
+		case q.c <- v:
+			return nil
+		default:
+			return ErrQueueFull
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case q.c <- v:
+				return nil
+			default:
+			}
+			select {
+			case <-q.c:
+				q.dropped.Add(1)
+			default:
+			}
+		}
+	}
+
+	select {
+	case q.c <- v:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TrySubmit queues an item only if space is immediately available.
+func (q *Queue[T]) TrySubmit(v T) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return false
+	}
+	select {
+	case q.c <- v:
+		return true
+	default:
+		return false
+	}
+}
+
+// Len returns the number of queued items not yet picked up by a worker.
+func (q *Queue[T]) Len() int {
+	return len(q.c)
+}
+
+// Dropped returns the number of items discarded by OverflowDropOldest.
+func (q *Queue[T]) Dropped() uint64 {
+	return q.dropped.Load()
+}
+
+// Close stops accepting items and waits for queued items to be processed.
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.c)
+	}
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+func (q *Queue[T]) work() {
+	defer q.wg.Done()
+	for v := range q.c {
+		q.fn(v)
+	}
+}
@@ -0,0 +1,122 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T)
+	}{
+		{
+			name: "processes all submitted items",
+			fn: func(t *testing.T) {
+				var total atomic.Int64
+				q := NewQueue(5, 3, func(n int) {
+					total.Add(int64(n))
+				})
+				for _, n := range testInts {
+					if err := q.Submit(n); err != nil {
+						t.Fatalf("Unexpected error=%v", err)
+					}
+				}
+				q.Close()
+				if v := total.Load(); v != 1830 {
+					t.Errorf("Expected total=%v but received total=%v", 1830, v)
+				}
+			},
+		},
+		{
+			name: "reject when full",
+			fn: func(t *testing.T) {
+				release := make(chan struct{})
+				q := NewQueue(1, 1, func(_ int) {
+					<-release
+				}).WithOverflow(OverflowReject)
+				_ = q.Submit(1) // picked up by worker
+				time.Sleep(10 * time.Millisecond)
+				if err := q.Submit(2); err != nil {
+					t.Errorf("Expected error=%v but received error=%v", nil, err)
+				}
+				if err := q.Submit(3); err != ErrQueueFull {
+					t.Errorf("Expected error=%v but received error=%v", ErrQueueFull, err)
+				}
+				if q.TrySubmit(4) {
+					t.Errorf("Expected TrySubmit to fail on full queue")
+				}
+				close(release)
+				q.Close()
+			},
+		},
+		{
+			name: "drop oldest when full",
+			fn: func(t *testing.T) {
+				var mu sync.Mutex
+				var seen []int
+				release := make(chan struct{})
+				q := NewQueue(2, 1, func(n int) {
+					<-release
+					mu.Lock()
+					seen = append(seen, n)
+					mu.Unlock()
+				}).WithOverflow(OverflowDropOldest)
+				_ = q.Submit(1) // picked up by worker
+				time.Sleep(10 * time.Millisecond)
+				for _, n := range []int{2, 3, 4, 5} {
+					if err := q.Submit(n); err != nil {
+						t.Errorf("Expected error=%v but received error=%v", nil, err)
+					}
+				}
+				close(release)
+				q.Close()
+				if d := q.Dropped(); d != 2 {
+					t.Errorf("Expected dropped=%v but received dropped=%v", 2, d)
+				}
+				if len(seen) != 3 || seen[1] != 4 || seen[2] != 5 {
+					t.Errorf("Expected processed=%v but received processed=%v", []int{1, 4, 5}, seen)
+				}
+			},
+		},
+		{
+			name: "blocking submit with context",
+			fn: func(t *testing.T) {
+				release := make(chan struct{})
+				q := NewQueue(1, 1, func(_ int) {
+					<-release
+				})
+				_ = q.Submit(1)
+				time.Sleep(10 * time.Millisecond)
+				_ = q.Submit(2)
+				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+				defer cancel()
+				if err := q.SubmitWithContext(ctx, 3); err != context.DeadlineExceeded {
+					t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+				}
+				close(release)
+				q.Close()
+			},
+		},
+		{
+			name: "submit after close",
+			fn: func(t *testing.T) {
+				q := NewQueue(1, 1, func(_ int) {})
+				q.Close()
+				q.Close() // idempotent
+				if err := q.Submit(1); err != ErrQueueClosed {
+					t.Errorf("Expected error=%v but received error=%v", ErrQueueClosed, err)
+				}
+				if q.TrySubmit(1) {
+					t.Errorf("Expected TrySubmit to fail on closed queue")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, tt.fn)
+	}
+}
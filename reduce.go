@@ -0,0 +1,58 @@
+package goroutines
+
+import (
+	"context"
+	"time"
+)
+
+// ReduceStream is Reduce but the running accumulator is sent on the returned
+// channel after every n results, and after any result at least interval
+// since the last emission. A zero n or interval disables that trigger.
+// The channel is closed when the reduction finishes, and the returned
+// function waits for the final accumulator, discarding any emissions not
+// yet received.
+//
+// Emissions are unbuffered, so the reduction waits until each is received.
+// Callers must drain the channel or call the returned function, otherwise the
+// reduction and its goroutines leak.
+func ReduceStream[I any, R any](qlen int, n int, interval time.Duration, fn func(I) (R, error), fni func(R, R) (R, error), args []I, opts ...Option) (<-chan R, func() (R, error)) {
+	return ReduceStreamWithContext(context.Background(), qlen, n, interval, fn, fni, args, opts...)
+}
+
+// ReduceStreamWithContext is ReduceStream but with a context. If the context
+// is cancelled while waiting for an emission to be received, the reduction
+// stops and the returned function returns the error of the context, so a
+// cancelled context also releases an undrained reduction.
+func ReduceStreamWithContext[I any, R any](ctx context.Context, qlen int, n int, interval time.Duration, fn func(I) (R, error), fni func(R, R) (R, error), args []I, opts ...Option) (<-chan R, func() (R, error)) {
+	c := make(chan R)
+	var a R
+	var err error
+
+	go func() {
+		defer close(c)
+		var folded int
+		last := time.Now()
+		a, err = ReduceWithContext(ctx, qlen, fn, func(a R, v R) (R, error) {
+			a, err := fni(a, v)
+			if err != nil {
+				return a, err
+			}
+			folded++
+			if (n > 0 && folded%n == 0) || (interval > 0 && time.Since(last) >= interval) {
+				select {
+				case c <- a:
+				case <-ctx.Done():
+					return a, ctx.Err()
+				}
+				last = time.Now()
+			}
+			return a, nil
+		}, args, opts...)
+	}()
+
+	return c, func() (R, error) {
+		for range c {
+		}
+		return a, err
+	}
+}
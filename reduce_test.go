@@ -0,0 +1,88 @@
+package goroutines
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReduceStream(t *testing.T) {
+	sum := func(a, b int) (int, error) {
+		return a + b, nil
+	}
+	identity := func(n int) (int, error) {
+		return n, nil
+	}
+
+	c, result := ReduceStream(4, 10, 0, identity, sum, testInts)
+	var emitted []int
+	for a := range c {
+		emitted = append(emitted, a)
+	}
+	if v, err := result(); err != nil || v != 1830 {
+		t.Errorf("Expected result=%v but received result=%v error=%v", 1830, v, err)
+	}
+	expect := []int{55, 210, 465, 820, 1275, 1830}
+	if len(emitted) != len(expect) {
+		t.Fatalf("Expected emissions=%v but received emissions=%v", expect, emitted)
+	}
+	for i := range expect {
+		if emitted[i] != expect[i] {
+			t.Errorf("Expected emission=%v but received emission=%v", expect[i], emitted[i])
+		}
+	}
+
+	c, result = ReduceStream(1, 0, 15*time.Millisecond, func(n int) (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return n, nil
+	}, sum, testInts[:6])
+	emitted = emitted[:0]
+	for a := range c {
+		emitted = append(emitted, a)
+	}
+	if len(emitted) < 2 || emitted[len(emitted)-1] > 21 {
+		t.Errorf("Expected emissions by interval but received emissions=%v", emitted)
+	}
+	if v, err := result(); err != nil || v != 21 {
+		t.Errorf("Expected result=%v but received result=%v error=%v", 21, v, err)
+	}
+
+	// Result does not wait on unreceived emissions
+	_, result = ReduceStream(4, 1, 0, identity, sum, testInts)
+	if v, err := result(); err != nil || v != 1830 {
+		t.Errorf("Expected result=%v but received result=%v error=%v", 1830, v, err)
+	}
+	_, result = ReduceStream(4, 1, 0, func(n int) (int, error) {
+		if n == 5 {
+			return 0, testErr
+		}
+		return n, nil
+	}, sum, testInts)
+	if _, err := result(); err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+}
+
+func TestReduceStreamCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c, result := ReduceStreamWithContext(ctx, 4, 1, 0, func(n int) (int, error) {
+		return n, nil
+	}, func(a, b int) (int, error) {
+		return a + b, nil
+	}, testInts)
+	<-c
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := result(); err != context.Canceled {
+			t.Errorf("Expected error=%v but received error=%v", context.Canceled, err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected cancelled reduction to stop")
+	}
+}
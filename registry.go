@@ -0,0 +1,79 @@
+package goroutines
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// StatsProvider is implemented by Coalescer for any result type.
+type StatsProvider interface {
+	Stats() CoalescerStats
+}
+
+// Registry collects named Coalescers, so the Stats of every Coalescer in an
+// application may be retrieved in one place. Registry is an http.Handler
+// serving a snapshot of Stats as JSON, such as for /debug/coalescers.
+type Registry struct {
+	mu sync.RWMutex
+	m  map[string]StatsProvider
+}
+
+// DefaultRegistry is the Registry used by Coalescer.WithName.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{m: make(map[string]StatsProvider)}
+}
+
+// Register a Coalescer by name, replacing any Coalescer of the same name.
+func (r *Registry) Register(name string, s StatsProvider) {
+	r.mu.Lock()
+	r.m[name] = s
+	r.mu.Unlock()
+}
+
+// Unregister the Coalescer of the given name.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	delete(r.m, name)
+	r.mu.Unlock()
+}
+
+// Names returns the sorted names of registered Coalescers.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.m))
+	for name := range r.m {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// Snapshot returns the Stats of every registered Coalescer by name.
+func (r *Registry) Snapshot() map[string]CoalescerStats {
+	r.mu.RLock()
+	providers := make(map[string]StatsProvider, len(r.m))
+	for name, s := range r.m {
+		providers[name] = s
+	}
+	r.mu.RUnlock()
+
+	stats := make(map[string]CoalescerStats, len(providers))
+	for name, s := range providers {
+		stats[name] = s.Stats()
+	}
+	return stats
+}
+
+// ServeHTTP writes the Snapshot as JSON.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(r.Snapshot())
+}
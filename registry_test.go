@@ -0,0 +1,44 @@
+package goroutines
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	q := Coalesce(func() (string, error) {
+		return "foo", nil
+	}).WithName("test-foo")
+	defer DefaultRegistry.Unregister("test-foo")
+
+	r := NewRegistry()
+	r.Register("bar", Coalesce(func() (int, error) {
+		return 0, testErr
+	}))
+	r.Register("foo", q)
+	_, _ = q.Run()
+	_, _ = q.Run()
+
+	if names := r.Names(); len(names) != 2 || names[0] != "bar" || names[1] != "foo" {
+		t.Errorf("Expected names=%v but received names=%v", []string{"bar", "foo"}, names)
+	}
+	if stats := DefaultRegistry.Snapshot()["test-foo"]; stats.Refreshes != 2 {
+		t.Errorf("Expected refreshes=%v but received refreshes=%v", 2, stats.Refreshes)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/debug/coalescers", nil))
+	var stats map[string]CoalescerStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats["foo"].Misses != 2 {
+		t.Errorf("Expected stats of foo and bar but received stats=%+v", stats)
+	}
+
+	r.Unregister("bar")
+	if n := len(r.Snapshot()); n != 1 {
+		t.Errorf("Expected coalescers=%v but received coalescers=%v", 1, n)
+	}
+}
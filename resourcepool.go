@@ -0,0 +1,140 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPoolClosed occurs when acquiring from a closed pool
+var ErrPoolClosed = errors.New("pool is closed")
+
+type idleResource[T any] struct {
+	v     T
+	since time.Time
+}
+
+// ResourcePool shares a bounded number of reusable resources, such as
+// network clients, between goroutines.
+type ResourcePool[T any] struct {
+	mu      sync.Mutex
+	sem     *TimedMutex
+	idle    []idleResource[T]
+	newFn   func() (T, error)
+	closeFn func(T)
+	ttl     time.Duration
+	check   func(T) error
+	closed  bool
+}
+
+// NewResourcePool returns a pool of up to maxSize resources created on
+// demand by newFn. Discarded resources are passed to closeFn, if not nil.
+func NewResourcePool[T any](maxSize int, newFn func() (T, error), closeFn func(T)) *ResourcePool[T] {
+	return &ResourcePool[T]{
+		sem:     NewVariableTimedMutex(maxSize),
+		newFn:   newFn,
+		closeFn: closeFn,
+	}
+}
+
+// WithIdleTTL discards resources which have been idle longer than ttl.
+func (p *ResourcePool[T]) WithIdleTTL(ttl time.Duration) *ResourcePool[T] {
+	p.mu.Lock()
+	p.ttl = ttl
+	p.mu.Unlock()
+	return p
+}
+
+// WithHealthCheck discards idle resources for which check returns an error
+// before they are acquired.
+func (p *ResourcePool[T]) WithHealthCheck(check func(T) error) *ResourcePool[T] {
+	p.mu.Lock()
+	p.check = check
+	p.mu.Unlock()
+	return p
+}
+
+// Acquire an idle resource, or create a new one, waiting until one is
+// available or the context is cancelled. Acquired resources must be passed
+// to Release or Discard.
+func (p *ResourcePool[T]) Acquire(ctx context.Context) (T, error) {
+	var v T
+	if err := p.sem.LockWithContext(ctx); err != nil {
+		return v, err
+	}
+
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			p.sem.Unlock()
+			return v, ErrPoolClosed
+		}
+		if len(p.idle) == 0 {
+			p.mu.Unlock()
+			break
+		}
+		r := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		expired := p.ttl > 0 && time.Since(r.since) > p.ttl
+		check := p.check
+		p.mu.Unlock()
+
+		if !expired && (check == nil || check(r.v) == nil) {
+			return r.v, nil
+		}
+		p.close(r.v)
+	}
+
+	v, err := p.newFn()
+	if err != nil {
+		p.sem.Unlock()
+	}
+	return v, err
+}
+
+// Release returns an acquired resource to the pool.
+func (p *ResourcePool[T]) Release(v T) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		p.close(v)
+	} else {
+		p.idle = append(p.idle, idleResource[T]{v, time.Now()})
+		p.mu.Unlock()
+	}
+	p.sem.Unlock()
+}
+
+// Discard closes an acquired resource instead of returning it to the pool.
+func (p *ResourcePool[T]) Discard(v T) {
+	p.close(v)
+	p.sem.Unlock()
+}
+
+// Idle returns the number of idle resources.
+func (p *ResourcePool[T]) Idle() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idle)
+}
+
+// Close the pool and all idle resources. Resources in use are closed when
+// they are released.
+func (p *ResourcePool[T]) Close() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+	for _, r := range idle {
+		p.close(r.v)
+	}
+}
+
+func (p *ResourcePool[T]) close(v T) {
+	if p.closeFn != nil {
+		p.closeFn(v)
+	}
+}
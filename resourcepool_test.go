@@ -0,0 +1,79 @@
+package goroutines
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testResource struct {
+	id      int64
+	healthy bool
+}
+
+func TestResourcePool(t *testing.T) {
+	var created, closed atomic.Int64
+	p := NewResourcePool(2, func() (*testResource, error) {
+		return &testResource{id: created.Add(1), healthy: true}, nil
+	}, func(_ *testResource) {
+		closed.Add(1)
+	}).WithIdleTTL(50 * time.Millisecond).WithHealthCheck(func(r *testResource) error {
+		if !r.healthy {
+			return testErr
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+	r1, _ := p.Acquire(ctx)
+	r2, _ := p.Acquire(ctx)
+
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Acquire(tctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+	}
+
+	p.Release(r1)
+	if r, _ := p.Acquire(ctx); r != r1 {
+		t.Errorf("Expected idle resource=%v to be reused but received=%v", r1.id, r.id)
+	}
+	r1.healthy = false
+	p.Release(r1)
+	if r, _ := p.Acquire(ctx); r == r1 || r.id != 3 {
+		t.Errorf("Expected unhealthy resource to be replaced but received=%v", r.id)
+	} else {
+		p.Release(r)
+	}
+
+	p.Release(r2)
+	time.Sleep(60 * time.Millisecond)
+	if r, _ := p.Acquire(ctx); r == r2 {
+		t.Errorf("Expected expired resource to be replaced")
+	} else {
+		p.Discard(r)
+	}
+	if n := closed.Load(); n != 4 {
+		t.Errorf("Expected closed=%v but received closed=%v", 4, n)
+	}
+
+	p.Close()
+	if _, err := p.Acquire(ctx); err != ErrPoolClosed {
+		t.Errorf("Expected error=%v but received error=%v", ErrPoolClosed, err)
+	}
+	if n := closed.Load(); n != 4 || p.Idle() != 0 {
+		t.Errorf("Expected all idle resources closed but received closed=%v idle=%v", n, p.Idle())
+	}
+}
+
+func TestResourcePoolNewError(t *testing.T) {
+	p := NewResourcePool(1, func() (int, error) {
+		return 0, testErr
+	}, nil)
+	for i := 0; i < 2; i++ {
+		if _, err := p.Acquire(context.Background()); err != testErr {
+			t.Errorf("Expected error=%v but received error=%v", testErr, err)
+		}
+	}
+}
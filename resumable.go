@@ -0,0 +1,55 @@
+package goroutines
+
+import "context"
+
+// CollectResumable is Collect over the arguments from offset, calling
+// checkpoint with the index of the last argument completed in order after
+// every n results, and once more when execution stops with any further
+// results, including on error. A job restarted with an offset following the
+// last checkpoint resumes without repeating work. Results are always ordered.
+//
+// If checkpoint returns an error, new arguments will not be processed and the
+// error is returned.
+//
+// Options which reorder or drop results, which are WithSeededOrder,
+// WithMaxErrors and WithMaxErrorRate, would misplace the checkpoint, so
+// CollectResumable panics if they are used, and ErrSkipped is returned as an
+// error.
+func CollectResumable[I any, R any](qlen int, fn func(I) (R, error), args []I, offset int, n int, checkpoint func(index int) error, opts ...Option) ([]R, error) {
+	return CollectResumableWithContext(context.Background(), qlen, fn, args, offset, n, checkpoint, opts...)
+}
+
+// CollectResumableWithContext is CollectResumable but with a context.
+func CollectResumableWithContext[I any, R any](ctx context.Context, qlen int, fn func(I) (R, error), args []I, offset int, n int, checkpoint func(index int) error, opts ...Option) ([]R, error) {
+	if offset < 0 {
+		offset = 0
+	} else if offset > len(args) {
+		offset = len(args)
+	}
+	if n <= 0 {
+		n = 1
+	}
+	o := newOptions(append(opts[:len(opts):len(opts)], WithOrder(Ordered)))
+	o.reject("CollectResumable", optSeededOrder|optMaxErrors)
+	src := source[I]{len(args) - offset, func(i int) I { return args[offset+i] }}
+
+	var saved int // results passed to checkpoint
+	var checkpointErr error
+	r, err := inject(ctx, qlen, make([]R, 0, src.n), unskipped("CollectResumable", fn), withoutContext(func(a []R, v R) ([]R, error) {
+		a = append(a, v)
+		if len(a)-saved >= n {
+			saved = len(a)
+			if checkpointErr = checkpoint(offset + len(a) - 1); checkpointErr != nil {
+				return a, checkpointErr
+			}
+		}
+		return a, nil
+	}), src, o, nil)
+
+	if len(r) > saved && checkpointErr == nil {
+		if cerr := checkpoint(offset + len(r) - 1); err == nil {
+			err = cerr
+		}
+	}
+	return r, err
+}
@@ -0,0 +1,92 @@
+package goroutines
+
+import (
+	"errors"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCollectResumable(t *testing.T) {
+	fn := func(n int) (int, error) {
+		time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond)
+		if n == 40 {
+			return 0, testErr
+		}
+		return n * 2, nil
+	}
+
+	tests := []struct {
+		name        string
+		offset      int
+		args        []int
+		err         error
+		checkpoints []int
+	}{
+		{name: "complete", offset: 0, args: testInts[:25], checkpoints: []int{9, 19, 24}},
+		{name: "resumed", offset: 20, args: testInts[:25], checkpoints: []int{24}},
+		{name: "error", offset: 25, args: testInts, err: testErr, checkpoints: []int{34, 38}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var checkpoints []int
+			r, err := CollectResumable(4, fn, tt.args, tt.offset, 10, func(i int) error {
+				checkpoints = append(checkpoints, i)
+				return nil
+			}, WithOrder(Unordered))
+			if err != tt.err {
+				t.Errorf("Expected error=%v but received error=%v", tt.err, err)
+			}
+			for i, v := range r {
+				if expect := tt.args[tt.offset+i] * 2; v != expect {
+					t.Fatalf("Expected ordered result=%v but received result=%v", expect, v)
+				}
+			}
+			if len(checkpoints) != len(tt.checkpoints) {
+				t.Fatalf("Expected checkpoints=%v but received checkpoints=%v", tt.checkpoints, checkpoints)
+			}
+			for i := range checkpoints {
+				if checkpoints[i] != tt.checkpoints[i] {
+					t.Errorf("Expected checkpoints=%v but received checkpoints=%v", tt.checkpoints, checkpoints)
+				}
+			}
+		})
+	}
+
+	checkpointErr := errors.New("checkpoint failed")
+	var calls atomic.Int64
+	_, err := CollectResumable(2, func(n int) (int, error) {
+		calls.Add(1)
+		return n, nil
+	}, testInts, 0, 5, func(int) error {
+		return checkpointErr
+	})
+	if err != checkpointErr || calls.Load() >= int64(len(testInts)) {
+		t.Errorf("Expected error=%v to stop processing but received error=%v calls=%v", checkpointErr, err, calls.Load())
+	}
+}
+
+func TestCollectResumableOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{name: "WithSeededOrder", opt: WithSeededOrder(1)},
+		{name: "WithMaxErrors", opt: WithMaxErrors(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expected panic for unsupported option")
+				}
+			}()
+			_, _ = CollectResumable(2, func(n int) (int, error) { return n, nil }, testInts, 0, 5, func(int) error {
+				return nil
+			}, tt.opt)
+		})
+	}
+}
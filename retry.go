@@ -0,0 +1,101 @@
+package goroutines
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy configures the attempts and backoff used by Retry.
+type RetryPolicy struct {
+	// MaxAttempts limits the number of calls, unlimited when less than one.
+	MaxAttempts int
+
+	// InitialBackoff is the delay after the first failed attempt.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between attempts, uncapped when zero except
+	// by the maximum time.Duration.
+	MaxBackoff time.Duration
+
+	// Multiplier grows the delay after each failed attempt, two when zero.
+	Multiplier float64
+
+	// Jitter randomly reduces each delay by up to the given fraction [0, 1].
+	Jitter float64
+
+	// Retryable reports if an error should be retried, all errors are
+	// retried when nil.
+	Retryable func(error) bool
+
+	// Clock waits between attempts, such as a FakeClock in tests. RealClock
+	// when nil.
+	Clock Clock
+}
+
+// Backoff returns the delay to wait after the given failed attempt, starting
+// with attempt one. The delay saturates at the maximum time.Duration.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	mult := p.Multiplier
+	if mult == 0 {
+		mult = 2
+	}
+	d := float64(p.InitialBackoff)
+	if d != 0 && attempt > 1 {
+		if d *= math.Pow(mult, float64(attempt-1)); d > math.MaxInt64 {
+			d = math.MaxInt64
+		}
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		j := p.Jitter
+		if j > 1 {
+			j = 1
+		}
+		d -= d * j * rand.Float64()
+	}
+	if d >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(d)
+}
+
+// wait the backoff of the given failed attempt, returning the error of the
+// context if it is done first.
+func (p RetryPolicy) wait(ctx context.Context, attempt int) error {
+	return sleep(ctx, p.clock(), p.Backoff(attempt))
+}
+
+// clock returns the Clock of the policy, or RealClock if not set.
+func (p RetryPolicy) clock() Clock {
+	if p.Clock == nil {
+		return RealClock
+	}
+	return p.Clock
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// Retry calls fn until it succeeds, returns a non-retryable error, the
+// attempts are exhausted, or the context is cancelled. The last error
+// returned by fn is returned unless the context is cancelled while waiting.
+func Retry[T any](ctx context.Context, policy RetryPolicy, fn func(context.Context) (T, error)) (T, error) {
+	for attempt := 1; ; attempt++ {
+		v, err := fn(ctx)
+		if err == nil || !policy.retryable(err) {
+			return v, err
+		}
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return v, err
+		}
+
+		if err := policy.wait(ctx, attempt); err != nil {
+			return v, err
+		}
+	}
+}
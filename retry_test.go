@@ -0,0 +1,124 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	permanentErr := errors.New("permanent test error")
+	tests := []struct {
+		name       string
+		policy     RetryPolicy
+		failures   int
+		err        error
+		cancelTime time.Duration
+		expectN    int
+		expectErr  error
+	}{
+		{
+			name:    "succeeds first attempt",
+			policy:  RetryPolicy{MaxAttempts: 3},
+			expectN: 1,
+		},
+		{
+			name:     "succeeds after failures",
+			policy:   RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond},
+			failures: 3,
+			err:      testErr,
+			expectN:  4,
+		},
+		{
+			name:      "attempts exhausted",
+			policy:    RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Jitter: 0.5},
+			failures:  10,
+			err:       testErr,
+			expectN:   3,
+			expectErr: testErr,
+		},
+		{
+			name: "non-retryable error",
+			policy: RetryPolicy{MaxAttempts: 5, Retryable: func(err error) bool {
+				return err != permanentErr
+			}},
+			failures:  10,
+			err:       permanentErr,
+			expectN:   1,
+			expectErr: permanentErr,
+		},
+		{
+			name:       "context cancelled while waiting",
+			policy:     RetryPolicy{InitialBackoff: time.Second},
+			failures:   10,
+			err:        testErr,
+			cancelTime: 20 * time.Millisecond,
+			expectN:    1,
+			expectErr:  context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.cancelTime > 0 {
+				var cancel func()
+				ctx, cancel = context.WithCancel(context.Background())
+				time.AfterFunc(tt.cancelTime, cancel)
+			}
+			var n int
+			v, err := Retry(ctx, tt.policy, func(_ context.Context) (int, error) {
+				n++
+				if n <= tt.failures {
+					return n, tt.err
+				}
+				return n, nil
+			})
+			if err != tt.expectErr {
+				t.Errorf("Expected error=%v but received error=%v", tt.expectErr, err)
+			}
+			if n != tt.expectN || v != tt.expectN {
+				t.Errorf("Expected attempts=%v but received attempts=%v result=%v", tt.expectN, n, v)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	expect := []time.Duration{10, 20, 40, 50, 50}
+	for i, e := range expect {
+		if d := p.Backoff(i + 1); d != e*time.Millisecond {
+			t.Errorf("Expected backoff=%v for attempt=%v but received backoff=%v", e*time.Millisecond, i+1, d)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 1; i < 5; i++ {
+		if d := p.Backoff(i); d > 50*time.Millisecond || d < 5*time.Millisecond {
+			t.Errorf("Expected jittered backoff within bounds but received backoff=%v", d)
+		}
+	}
+}
+
+func TestRetryBackoffSaturates(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: time.Second}
+	for _, attempt := range []int{64, 1000, math.MaxInt32} {
+		if d := p.Backoff(attempt); d != math.MaxInt64 {
+			t.Errorf("Expected backoff=%v for attempt=%v but received backoff=%v", time.Duration(math.MaxInt64), attempt, d)
+		}
+	}
+
+	p.Jitter = 1
+	if d := p.Backoff(1000); d < 0 {
+		t.Errorf("Expected non-negative jittered backoff but received backoff=%v", d)
+	}
+
+	// Backoff which never grows returns without iterating attempts
+	for _, p := range []RetryPolicy{{}, {InitialBackoff: time.Second, Multiplier: 1}} {
+		if d := p.Backoff(math.MaxInt32); d != p.InitialBackoff {
+			t.Errorf("Expected backoff=%v but received backoff=%v", p.InitialBackoff, d)
+		}
+	}
+}
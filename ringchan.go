@@ -0,0 +1,96 @@
+package goroutines
+
+import "sync/atomic"
+
+// RingChan is a channel pair with a bounded ring buffer between them, such as
+// between stages of a pipeline built from MapChan. The overflow policy
+// determines what happens when a value is sent while the buffer is full.
+// Closing In closes Out once buffered values are received.
+type RingChan[T any] struct {
+	in      chan T
+	out     chan T
+	policy  OverflowPolicy
+	buf     []T
+	len     atomic.Int64
+	dropped atomic.Uint64
+}
+
+// NewRingChan returns a RingChan buffering up to capacity values. Capacity
+// less than one is treated as one. With OverflowBlock sends to In wait for
+// room, OverflowDropOldest discards the oldest buffered value, and
+// OverflowReject discards the sent value.
+func NewRingChan[T any](capacity int, policy OverflowPolicy) *RingChan[T] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	r := &RingChan[T]{
+		in:     make(chan T),
+		out:    make(chan T),
+		policy: policy,
+		buf:    make([]T, capacity),
+	}
+	go r.run()
+	return r
+}
+
+// In returns the channel to send values to.
+func (r *RingChan[T]) In() chan<- T {
+	return r.in
+}
+
+// Out returns the channel to receive values from.
+func (r *RingChan[T]) Out() <-chan T {
+	return r.out
+}
+
+// Len returns the number of buffered values.
+func (r *RingChan[T]) Len() int {
+	return int(r.len.Load())
+}
+
+// Dropped returns the number of values discarded by the overflow policy.
+func (r *RingChan[T]) Dropped() uint64 {
+	return r.dropped.Load()
+}
+
+func (r *RingChan[T]) run() {
+	var zero T
+	var head, n int
+	in := r.in
+	for in != nil || n > 0 {
+		var out chan T
+		var next T
+		if n > 0 {
+			out, next = r.out, r.buf[head]
+		}
+		recv := in
+		if n == len(r.buf) && r.policy == OverflowBlock {
+			recv = nil // wait for room
+		}
+
+		select {
+		case v, ok := <-recv:
+			if !ok {
+				in = nil
+				continue
+			}
+			if n == len(r.buf) {
+				r.dropped.Add(1)
+				if r.policy == OverflowReject {
+					continue
+				}
+				r.buf[head] = zero
+				head = (head + 1) % len(r.buf)
+				n--
+			}
+			r.buf[(head+n)%len(r.buf)] = v
+			n++
+		case out <- next:
+			r.buf[head] = zero
+			head = (head + 1) % len(r.buf)
+			n--
+		}
+		r.len.Store(int64(n))
+	}
+	close(r.out)
+}
@@ -0,0 +1,61 @@
+package goroutines
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRingChan(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  OverflowPolicy
+		expect  []int
+		dropped uint64
+	}{
+		{name: "drop oldest", policy: OverflowDropOldest, expect: []int{3, 4}, dropped: 3},
+		{name: "drop newest", policy: OverflowReject, expect: []int{0, 1}, dropped: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRingChan[int](2, tt.policy)
+			for i := 0; i < 5; i++ {
+				r.In() <- i
+			}
+			close(r.In())
+			var got []int
+			for v := range r.Out() {
+				got = append(got, v)
+			}
+			if len(got) != 2 || got[0] != tt.expect[0] || got[1] != tt.expect[1] {
+				t.Errorf("Expected values=%v but received values=%v", tt.expect, got)
+			}
+			if d := r.Dropped(); d != tt.dropped {
+				t.Errorf("Expected dropped=%v but received dropped=%v", tt.dropped, d)
+			}
+		})
+	}
+}
+
+func TestRingChanBlock(t *testing.T) {
+	r := NewRingChan[string](1, OverflowBlock)
+	r.In() <- "foo"
+	select {
+	case r.In() <- "bar":
+		t.Fatalf("Expected send to block on a full buffer")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if n := r.Len(); n != 1 {
+		t.Errorf("Expected len=%v but received len=%v", 1, n)
+	}
+	if v := <-r.Out(); v != "foo" {
+		t.Errorf("Expected foo received=%v", v)
+	}
+	r.In() <- "bar"
+	close(r.In())
+	if v := <-r.Out(); v != "bar" {
+		t.Errorf("Expected bar received=%v", v)
+	}
+	if _, ok := <-r.Out(); ok {
+		t.Errorf("Expected closed output")
+	}
+}
@@ -0,0 +1,51 @@
+package goroutines
+
+import "context"
+
+type gathered[K comparable, R any] struct {
+	k K
+	v R
+	e error
+}
+
+// ScatterGather calls each named request concurrently, returning the results
+// and errors by name. Unlike Collect an error does not stop other requests.
+func ScatterGather[K comparable, R any](qlen int, requests map[K]func(context.Context) (R, error)) (map[K]R, map[K]error) {
+	return ScatterGatherWithContext(context.Background(), qlen, requests)
+}
+
+// ScatterGatherWithContext is ScatterGather but with a context passed to each
+// request. Requests not started before the context is cancelled return the
+// error of the context.
+func ScatterGatherWithContext[K comparable, R any](ctx context.Context, qlen int, requests map[K]func(context.Context) (R, error)) (map[K]R, map[K]error) {
+	keys := make([]K, 0, len(requests))
+	for k := range requests {
+		keys = append(keys, k)
+	}
+
+	results := make(map[K]R, len(requests))
+	errs := make(map[K]error)
+	for r := range MapUnorderedWithContext(ctx, qlen, func(k K) gathered[K, R] {
+		var v R
+		err := protect(func() (err error) {
+			v, err = requests[k](ctx)
+			return
+		})
+		return gathered[K, R]{k, v, err}
+	}, keys) {
+		if r.e != nil {
+			errs[r.k] = r.e
+		} else {
+			results[r.k] = r.v
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		for _, k := range keys {
+			if _, ok := results[k]; !ok && errs[k] == nil {
+				errs[k] = err
+			}
+		}
+	}
+	return results, errs
+}
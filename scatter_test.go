@@ -0,0 +1,44 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScatterGather(t *testing.T) {
+	requests := map[string]func(context.Context) (string, error){
+		"web": func(ctx context.Context) (string, error) {
+			return "web result", nil
+		},
+		"image": func(ctx context.Context) (string, error) {
+			return "", testErr
+		},
+		"video": func(ctx context.Context) (string, error) {
+			panic("test panic")
+		},
+	}
+	results, errs := ScatterGather(2, requests)
+	if len(results) != 1 || results["web"] != "web result" {
+		t.Errorf("Expected result of web but received results=%v", results)
+	}
+	var perr *PanicError
+	if len(errs) != 2 || errs["image"] != testErr || !errors.As(errs["video"], &perr) {
+		t.Errorf("Expected errors of image and video but received errors=%v", errs)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	slow := make(map[int]func(context.Context) (int, error))
+	for i := 0; i < 10; i++ {
+		slow[i] = func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+	}
+	results2, errs2 := ScatterGatherWithContext(ctx, 2, slow)
+	if len(results2) != 0 || len(errs2) != 10 {
+		t.Errorf("Expected errors for all requests but received results=%v errors=%v", results2, errs2)
+	}
+}
@@ -0,0 +1,79 @@
+package goroutines
+
+import (
+	"context"
+	"time"
+)
+
+// Scheduled is a function scheduled to run in a Pool. See Pool.Schedule.
+type Scheduled struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Cancel stops the function being run again, and cancels the context of a
+// running function.
+func (s *Scheduled) Cancel() {
+	s.cancel()
+}
+
+// Done returns a channel which is closed once the function will not be run
+// again, and is not running.
+func (s *Scheduled) Done() <-chan struct{} {
+	return s.done
+}
+
+// Schedule runs fn once in the pool at the given time, or immediately if it
+// has passed, until the context is cancelled or the Scheduled is cancelled.
+// Like mapping functions using the pool, fn waits for the pool to have room.
+func (p *Pool) Schedule(ctx context.Context, at time.Time, fn func(context.Context)) *Scheduled {
+	return p.schedule(ctx, func(ctx context.Context) {
+		if Sleep(ctx, time.Until(at)) == nil {
+			p.run(ctx, fn)
+		}
+	})
+}
+
+// ScheduleEvery runs fn in the pool every interval until the context is
+// cancelled or the Scheduled is cancelled. Runs never overlap, and an
+// interval which elapses while fn is running is skipped. ScheduleEvery panics
+// if interval is not positive.
+func (p *Pool) ScheduleEvery(ctx context.Context, interval time.Duration, fn func(context.Context)) *Scheduled {
+	if interval <= 0 {
+		panic("ScheduleEvery interval must be positive")
+	}
+	return p.schedule(ctx, func(ctx context.Context) {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				p.run(ctx, fn)
+			}
+		}
+	})
+}
+
+// schedule runs loop in its own goroutine with a cancellable context.
+func (p *Pool) schedule(ctx context.Context, loop func(context.Context)) *Scheduled {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Scheduled{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		defer cancel()
+		loop(ctx)
+	}()
+	return s
+}
+
+// run fn once the pool has room, unless the context is done first.
+func (p *Pool) run(ctx context.Context, fn func(context.Context)) {
+	start, err := p.begin(ctx)
+	if err != nil {
+		return
+	}
+	defer p.end(start)
+	fn(ctx)
+}
@@ -0,0 +1,65 @@
+package goroutines
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolSchedule(t *testing.T) {
+	p := NewPool(1)
+	var ran atomic.Int64
+	start := time.Now()
+	s := p.Schedule(context.Background(), start.Add(20*time.Millisecond), func(context.Context) {
+		ran.Add(1)
+	})
+	<-s.Done()
+	if d := time.Since(start); d < 20*time.Millisecond || ran.Load() != 1 {
+		t.Errorf("Expected one run after=%v but received runs=%v after=%v", 20*time.Millisecond, ran.Load(), d)
+	}
+	if c := p.Completed(); c != 1 {
+		t.Errorf("Expected completed=%v but received completed=%v", 1, c)
+	}
+
+	s = p.Schedule(context.Background(), time.Now().Add(time.Hour), func(context.Context) {
+		ran.Add(1)
+	})
+	s.Cancel()
+	<-s.Done()
+	if n := ran.Load(); n != 1 {
+		t.Errorf("Expected cancelled function not to run but received runs=%v", n)
+	}
+}
+
+func TestPoolScheduleEvery(t *testing.T) {
+	p := NewPool(0)
+	var ran atomic.Int64
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+	s := p.ScheduleEvery(ctx, 10*time.Millisecond, func(context.Context) {
+		ran.Add(1)
+	})
+	<-s.Done()
+	if n := ran.Load(); n < 3 || n > 5 {
+		t.Errorf("Expected about 5 runs but received runs=%v", n)
+	}
+
+	s = p.ScheduleEvery(context.Background(), time.Millisecond, func(ctx context.Context) {
+		<-ctx.Done()
+	})
+	time.Sleep(5 * time.Millisecond)
+	s.Cancel()
+	select {
+	case <-s.Done():
+	case <-time.After(allowedVariance):
+		t.Errorf("Expected cancel to interrupt a running function")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic for interval=%v", 0)
+		}
+	}()
+	p.ScheduleEvery(context.Background(), 0, func(context.Context) {})
+}
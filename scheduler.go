@@ -0,0 +1,162 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrJobExists is returned when adding a job with the name of another job.
+var ErrJobExists = errors.New("job already scheduled")
+
+// defaultHistory is the number of runs kept for each job of a Scheduler.
+const defaultHistory = 10
+
+// JobRun describes a completed run of a job. See Scheduler.History.
+type JobRun struct {
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// Scheduler runs named jobs on a schedule in a shared Pool, keeping a
+// history of their recent runs. Each job is run by a Runner, so only one run
+// of a job is active at a time and overlapping runs follow its policy.
+type Scheduler struct {
+	mu      sync.Mutex
+	pool    *Pool
+	history int
+	jobs    map[string]*schedulerJob
+}
+
+type schedulerJob struct {
+	runner *Runner
+	runs   []JobRun // oldest first
+}
+
+// NewScheduler returns a Scheduler running jobs in the given Pool, or without
+// a limit if the pool is nil.
+func NewScheduler(pool *Pool) *Scheduler {
+	return &Scheduler{
+		pool:    pool,
+		history: defaultHistory,
+		jobs:    make(map[string]*schedulerJob),
+	}
+}
+
+// WithHistory sets the number of runs kept for each job, ten by default. No
+// runs are kept if n is not positive.
+func (s *Scheduler) WithHistory(n int) *Scheduler {
+	if n < 0 {
+		n = 0
+	}
+	s.mu.Lock()
+	s.history = n
+	s.mu.Unlock()
+	return s
+}
+
+// AddCron adds a job run on a cron spec. See ParseCron.
+func (s *Scheduler) AddCron(ctx context.Context, name string, spec string, overlap OverlapPolicy, fn func(context.Context) error) error {
+	sched, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	return s.Add(ctx, name, sched, overlap, fn)
+}
+
+// Add a named job, run according to spec until the context is cancelled or
+// the job is removed. Runs which are due while the job is still running
+// follow the overlap policy.
+func (s *Scheduler) Add(ctx context.Context, name string, spec ScheduleSpec, overlap OverlapPolicy, fn func(context.Context) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; ok {
+		return ErrJobExists
+	}
+	job := &schedulerJob{}
+	job.runner = NewRunner(0, func(ctx context.Context) error {
+		return s.run(ctx, job, fn)
+	}).WithOverlap(overlap)
+	s.jobs[name] = job
+
+	job.runner.start(ctx, func(ctx context.Context) {
+		for next := spec.Next(time.Now()); !next.IsZero(); next = spec.Next(next) {
+			if Sleep(ctx, time.Until(next)) != nil {
+				return
+			}
+			job.runner.Trigger()
+			if now := time.Now(); next.Before(now) {
+				next = now // skip runs missed while waiting
+			}
+		}
+	})
+	return nil
+}
+
+// run a job in the pool, recording it in the history of the job.
+func (s *Scheduler) run(ctx context.Context, job *schedulerJob, fn func(context.Context) error) error {
+	begin, err := s.pool.begin(ctx)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	err = protect(func() error {
+		return fn(ctx)
+	})
+	s.pool.end(begin)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.runs = append(job.runs, JobRun{Start: start, Duration: time.Since(start), Err: err})
+	if n := len(job.runs) - s.history; n > 0 {
+		job.runs = append(job.runs[:0], job.runs[n:]...)
+	}
+	return err
+}
+
+// Remove the named job, waiting for any active run to complete. Returns false
+// if there is no such job.
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	job, ok := s.jobs[name]
+	delete(s.jobs, name)
+	s.mu.Unlock()
+	if ok {
+		job.runner.Stop()
+	}
+	return ok
+}
+
+// History returns the recent runs of the named job, oldest first.
+func (s *Scheduler) History(name string) []JobRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[name]; ok {
+		return append([]JobRun(nil), job.runs...)
+	}
+	return nil
+}
+
+// Err returns the error of the most recent run of the named job.
+func (s *Scheduler) Err(name string) error {
+	s.mu.Lock()
+	job, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return job.runner.Err()
+}
+
+// Stop all jobs and wait for any active runs to complete.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	jobs := s.jobs
+	s.jobs = make(map[string]*schedulerJob)
+	s.mu.Unlock()
+	for _, job := range jobs {
+		job.runner.Stop()
+	}
+}
@@ -0,0 +1,80 @@
+package goroutines
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	p := NewPool(1)
+	s := NewScheduler(p).WithHistory(2)
+	ctx := context.Background()
+
+	var runs atomic.Int64
+	err := s.Add(ctx, "tick", EverySpec(10*time.Millisecond), OverlapSkip, func(context.Context) error {
+		if runs.Add(1)%2 == 0 {
+			return testErr
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(ctx, "tick", EverySpec(time.Hour), OverlapSkip, nil); err != ErrJobExists {
+		t.Errorf("Expected error=%v but received error=%v", ErrJobExists, err)
+	}
+	if err := s.AddCron(ctx, "bad", "* *", OverlapSkip, nil); err == nil {
+		t.Errorf("Expected error for invalid cron spec")
+	}
+	if err := s.AddCron(ctx, "hourly", "@hourly", OverlapSkip, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(55 * time.Millisecond)
+	h := s.History("tick")
+	if n := runs.Load(); n < 3 || len(h) != 2 {
+		t.Fatalf("Expected at least 3 runs with history=2 but received runs=%v history=%v", n, len(h))
+	}
+	if last := h[1].Err; last != s.Err("tick") || h[0].Err == h[1].Err {
+		t.Errorf("Expected alternating errors with last=%v but received history=%v", s.Err("tick"), h)
+	}
+	if !h[0].Start.Before(h[1].Start) {
+		t.Errorf("Expected history oldest first but received history=%v", h)
+	}
+	if c := p.Completed(); c < 3 {
+		t.Errorf("Expected runs in pool but received completed=%v", c)
+	}
+
+	if !s.Remove("tick") || s.Remove("tick") {
+		t.Errorf("Expected job to be removed once")
+	}
+	n := runs.Load()
+	time.Sleep(20 * time.Millisecond)
+	if runs.Load() != n || s.History("tick") != nil {
+		t.Errorf("Expected removed job not to run")
+	}
+	s.Stop()
+	if h := s.History("hourly"); h != nil {
+		t.Errorf("Expected stopped jobs to be removed but received history=%v", h)
+	}
+}
+
+func TestSchedulerNegativeHistory(t *testing.T) {
+	s := NewScheduler(nil).WithHistory(-1)
+	var runs atomic.Int64
+	if err := s.Add(context.Background(), "tick", EverySpec(5*time.Millisecond), OverlapSkip, func(context.Context) error {
+		runs.Add(1)
+		return testErr
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for runs.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if h := s.History("tick"); len(h) != 0 {
+		t.Errorf("Expected no history but received history=%v", h)
+	}
+	s.Stop()
+}
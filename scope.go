@@ -0,0 +1,74 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Scope runs goroutines which are joined before the function of RunScope
+// returns, so none outlive it.
+type Scope struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	err    error
+	panic  *PanicError
+}
+
+// RunScope calls fn with a Scope, and waits for every goroutine started by
+// the Scope before returning. The context of the Scope is cancelled when fn
+// or any goroutine returns an error, and the first error is returned. If fn
+// or any goroutine panics, the other goroutines are cancelled and joined,
+// then the panic is raised again in the caller as a PanicError with the
+// stack of the panicking goroutine.
+func RunScope(ctx context.Context, fn func(s *Scope) error) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	s := &Scope{ctx: ctx, cancel: cancel}
+	s.record(protect(func() error {
+		return fn(s)
+	}))
+	s.wg.Wait()
+	cancel(s.err)
+
+	if s.panic != nil {
+		panic(s.panic)
+	}
+	return s.err
+}
+
+// Context returns the context of the Scope, which is cancelled by the first
+// error.
+func (s *Scope) Context() context.Context {
+	return s.ctx
+}
+
+// Go calls fn with the context of the Scope in a new goroutine. Go must not
+// be called after the function of RunScope returns.
+func (s *Scope) Go(fn func(ctx context.Context) error) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.record(protect(func() error {
+			return fn(s.ctx)
+		}))
+	}()
+}
+
+// record the first error and panic, cancelling the context of the Scope.
+func (s *Scope) record(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var perr *PanicError
+	if errors.As(err, &perr) && s.panic == nil {
+		s.panic = perr
+	}
+	if s.err == nil {
+		s.err = err
+		s.cancel(err)
+	}
+}
@@ -0,0 +1,65 @@
+package goroutines
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunScope(t *testing.T) {
+	var finished atomic.Int64
+	err := RunScope(context.Background(), func(s *Scope) error {
+		for i := 0; i < 5; i++ {
+			s.Go(func(context.Context) error {
+				time.Sleep(10 * time.Millisecond)
+				finished.Add(1)
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil || finished.Load() != 5 {
+		t.Errorf("Expected all goroutines joined but received finished=%v error=%v", finished.Load(), err)
+	}
+
+	var cancelled atomic.Bool
+	err = RunScope(context.Background(), func(s *Scope) error {
+		s.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			cancelled.Store(context.Cause(ctx) == testErr)
+			return ctx.Err()
+		})
+		s.Go(func(context.Context) error {
+			return testErr
+		})
+		return nil
+	})
+	if err != testErr || !cancelled.Load() {
+		t.Errorf("Expected error=%v to cancel the scope but received error=%v cancelled=%v", testErr, err, cancelled.Load())
+	}
+
+	finished.Store(0)
+	func() {
+		defer func() {
+			perr, ok := recover().(*PanicError)
+			if !ok || perr.Value != "boom" {
+				t.Errorf("Expected panic to be raised in the caller but received %v", perr)
+			}
+		}()
+		_ = RunScope(context.Background(), func(s *Scope) error {
+			s.Go(func(ctx context.Context) error {
+				<-ctx.Done()
+				finished.Add(1)
+				return nil
+			})
+			s.Go(func(context.Context) error {
+				panic("boom")
+			})
+			return nil
+		})
+	}()
+	if n := finished.Load(); n != 1 {
+		t.Errorf("Expected goroutines joined before the panic but received finished=%v", n)
+	}
+}
@@ -0,0 +1,115 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// ErrOutOfRange is returned by SelectKth when k is not an index of the slice.
+var ErrOutOfRange = errors.New("index out of range")
+
+// selectSerial is the length below which SelectKth sorts the remaining
+// elements rather than partitioning them in parallel.
+const selectSerial = 4096
+
+// SelectKth returns the element which would be at index k if s was sorted by
+// less, without sorting s. Large slices are partitioned around a pivot in
+// parallel, keeping only the side containing k, so s is not modified.
+func SelectKth[T any](qlen int, less func(a, b T) bool, s []T, k int, opts ...Option) (T, error) {
+	return SelectKthWithContext(context.Background(), qlen, less, s, k, opts...)
+}
+
+// SelectKthWithContext is SelectKth but with a context.
+func SelectKthWithContext[T any](ctx context.Context, qlen int, less func(a, b T) bool, s []T, k int, opts ...Option) (T, error) {
+	var zero T
+	if k < 0 || k >= len(s) {
+		return zero, fmt.Errorf("%w: k=%d with length=%d", ErrOutOfRange, k, len(s))
+	}
+	p := qlen
+	if p <= 0 {
+		p = defaultPoolSize
+	}
+
+	cur := s
+	for len(cur) > selectSerial {
+		pivot := medianOf3(less, cur[rand.Intn(len(cur))], cur[rand.Intn(len(cur))], cur[rand.Intn(len(cur))])
+
+		// Count the elements either side of the pivot in each chunk
+		counts := make([][2]int, p)
+		err := ForNWithContext(ctx, qlen, p, func(i int) error {
+			lo, hi := chunk(len(cur), p, i)
+			for _, e := range cur[lo:hi] {
+				if less(e, pivot) {
+					counts[i][0]++
+				} else if less(pivot, e) {
+					counts[i][1]++
+				}
+			}
+			return nil
+		}, unordered(opts)...)
+		if err != nil {
+			return zero, err
+		}
+		var nl, ng int
+		for _, c := range counts {
+			nl, ng = nl+c[0], ng+c[1]
+		}
+
+		side, keep := 0, func(e T) bool { return less(e, pivot) }
+		switch {
+		case k < nl:
+		case k < len(cur)-ng:
+			return pivot, nil
+		default:
+			k -= len(cur) - ng
+			side, keep = 1, func(e T) bool { return less(pivot, e) }
+		}
+
+		// Copy the side containing k, each chunk at its offset
+		offsets := make([]int, p)
+		var n int
+		for i, c := range counts {
+			offsets[i] = n
+			n += c[side]
+		}
+		next := make([]T, n)
+		err = ForNWithContext(ctx, qlen, p, func(i int) error {
+			lo, hi := chunk(len(cur), p, i)
+			j := offsets[i]
+			for _, e := range cur[lo:hi] {
+				if keep(e) {
+					next[j] = e
+					j++
+				}
+			}
+			return nil
+		}, unordered(opts)...)
+		if err != nil {
+			return zero, err
+		}
+		cur = next
+	}
+
+	if len(cur) == len(s) {
+		cur = append([]T(nil), s...)
+	}
+	sort.Slice(cur, func(i, j int) bool { return less(cur[i], cur[j]) })
+	return cur[k], nil
+}
+
+// medianOf3 returns the median of three elements.
+func medianOf3[T any](less func(a, b T) bool, a, b, c T) T {
+	if less(b, a) {
+		a, b = b, a
+	}
+	if less(c, b) {
+		b = c
+		if less(b, a) {
+			b = a
+		}
+	}
+	return b
+}
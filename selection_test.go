@@ -0,0 +1,70 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSelectKth(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	tests := []struct {
+		name string
+		s    []int
+	}{
+		{name: "small", s: rand.Perm(100)},
+		{name: "large", s: rand.Perm(100000)},
+		{name: "duplicates", s: func() []int {
+			s := make([]int, 50000)
+			for i := range s {
+				s[i] = rand.Intn(10)
+			}
+			return s
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := append([]int(nil), tt.s...)
+			sorted := append([]int(nil), tt.s...)
+			sort.Ints(sorted)
+			for _, k := range []int{0, len(tt.s) / 2, len(tt.s) * 99 / 100, len(tt.s) - 1} {
+				v, err := SelectKth(4, less, tt.s, k)
+				if err != nil || v != sorted[k] {
+					t.Errorf("Expected element=%v at k=%v but received element=%v error=%v", sorted[k], k, v, err)
+				}
+			}
+			for i := range orig {
+				if tt.s[i] != orig[i] {
+					t.Fatalf("Expected slice not to be modified")
+				}
+			}
+		})
+	}
+
+	if _, err := SelectKth(4, less, testInts, len(testInts)); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("Expected error=%v but received error=%v", ErrOutOfRange, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := SelectKthWithContext(ctx, 4, less, rand.Perm(100000), 10); err != context.Canceled {
+		t.Errorf("Expected error=%v but received error=%v", context.Canceled, err)
+	}
+}
+
+func BenchmarkSelectKth(b *testing.B) {
+	s := rand.Perm(1000000)
+	less := func(a, b int) bool { return a < b }
+	b.Run("sort", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			c := append([]int(nil), s...)
+			sort.Ints(c)
+		}
+	})
+	b.Run("SelectKth", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = SelectKth(8, less, s, len(s)/2)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package goroutines
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"reflect"
+)
+
+// ForEachSharded is ForEachWorker but routes each argument to a worker chosen
+// by the hash of its key, so arguments with the same key are always processed
+// serially by the same goroutine. Each shard has its own worker state, which
+// then needs no locking. Workers are assigned a shard of keys in place of
+// taking the next argument, so a busy shard may delay others.
+//
+// Keys may be of any comparable type, and equal keys always have the same
+// shard. Keys with pointers, channels or interfaces of them are hashed by
+// address, as they are compared.
+func ForEachSharded[S any, I any, K comparable](qlen int, key func(I) K, init func() (S, error), close func(S), fn func(S, I) error, args []I, opts ...Option) error {
+	return ForEachShardedWithContext(context.Background(), qlen, key, init, close, fn, args, opts...)
+}
+
+// ForEachShardedWithContext is ForEachSharded but with a context.
+func ForEachShardedWithContext[S any, I any, K comparable](ctx context.Context, qlen int, key func(I) K, init func() (S, error), close func(S), fn func(S, I) error, args []I, opts ...Option) error {
+	return forEachWorker(ctx, qlen, shardBy(key), init, close, fn, args, newOptions(opts))
+}
+
+// CollectSharded is CollectWorker but routes each argument to a worker by
+// its key as by ForEachSharded.
+func CollectSharded[S any, I any, R any, K comparable](qlen int, key func(I) K, init func() (S, error), close func(S), fn func(S, I) (R, error), args []I, opts ...Option) ([]R, error) {
+	return CollectShardedWithContext(context.Background(), qlen, key, init, close, fn, args, opts...)
+}
+
+// CollectShardedWithContext is CollectSharded but with a context.
+func CollectShardedWithContext[S any, I any, R any, K comparable](ctx context.Context, qlen int, key func(I) K, init func() (S, error), close func(S), fn func(S, I) (R, error), args []I, opts ...Option) ([]R, error) {
+	return collectWorker(ctx, qlen, shardBy(key), init, close, fn, args, newOptions(opts))
+}
+
+// shardBy returns a function hashing the key of an argument.
+func shardBy[I any, K comparable](key func(I) K) func(I) uint64 {
+	return func(e I) uint64 {
+		switch k := any(key(e)).(type) {
+		case string:
+			return hashKey(k)
+		case int:
+			return hashKey(string(binary.AppendVarint(nil, int64(k))))
+		case int64:
+			return hashKey(string(binary.AppendVarint(nil, k)))
+		case uint64:
+			return hashKey(string(binary.AppendUvarint(nil, k)))
+		default:
+			return hashKey(string(appendKey(nil, reflect.ValueOf(k))))
+		}
+	}
+}
+
+// appendKey appends an encoding of a comparable value to b, which is equal
+// for equal values.
+func appendKey(b []byte, v reflect.Value) []byte {
+	switch v.Kind() {
+	case reflect.String:
+		b = binary.AppendUvarint(b, uint64(v.Len()))
+		return append(b, v.String()...)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.AppendVarint(b, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return binary.AppendUvarint(b, v.Uint())
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 1)
+		}
+		return append(b, 0)
+	case reflect.Float32, reflect.Float64:
+		return appendFloat(b, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return appendFloat(appendFloat(b, real(c)), imag(c))
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		return binary.AppendUvarint(b, uint64(v.Pointer()))
+	case reflect.Interface:
+		if v.IsNil() {
+			return append(b, 0)
+		}
+		return appendKey(append(b, 1), v.Elem())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			b = appendKey(b, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			b = appendKey(b, v.Field(i))
+		}
+	}
+	return b
+}
+
+// appendFloat appends the bits of f, where negative zero is equal to zero.
+func appendFloat(b []byte, f float64) []byte {
+	if f == 0 {
+		f = 0
+	}
+	return binary.LittleEndian.AppendUint64(b, math.Float64bits(f))
+}
+
+// hashKey is the 64-bit FNV-1a hash of key.
+func hashKey(key string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	return h
+}
@@ -0,0 +1,86 @@
+package goroutines
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCollectSharded(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "ordered"},
+		{name: "unordered", opts: []Option{WithOrder(Unordered)}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var mu sync.Mutex
+			workers := make(map[string]*testWorker)
+			var created atomic.Int64
+			r, err := CollectSharded(4, func(s string) string { return s }, func() (*testWorker, error) {
+				created.Add(1)
+				return &testWorker{}, nil
+			}, nil, func(w *testWorker, s string) (string, error) {
+				if !w.busy.CompareAndSwap(false, true) {
+					t.Errorf("Expected shard state to be used by a single worker")
+				}
+				defer w.busy.Store(false)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if prev, ok := workers[s]; ok && prev != w {
+					t.Errorf("Expected key=%v processed by the same worker", s)
+				}
+				workers[s] = w
+				return s, nil
+			}, testStrings, tt.opts...)
+			if err != nil || len(r) != len(testStrings) {
+				t.Fatalf("Expected results=%v but received results=%v error=%v", len(testStrings), len(r), err)
+			}
+			if n := created.Load(); n > 4 {
+				t.Errorf("Expected at most %v shards but received shards=%v", 4, n)
+			}
+		})
+	}
+
+	for i := 0; i < 3; i++ {
+		r, err := CollectSharded(3, func(n int) int { return n % 7 }, nil, nil, func(_ struct{}, n int) (int, error) {
+			return n * 2, nil
+		}, testInts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j, n := range testInts {
+			if r[j] != n*2 {
+				t.Fatalf("Expected ordered result=%v but received result=%v", n*2, r[j])
+			}
+		}
+	}
+}
+
+func TestShardBy(t *testing.T) {
+	type key struct {
+		name  string
+		id    int
+		score float64
+		ref   *int
+		value any
+	}
+	ref := new(int)
+	hash := shardBy(func(k key) key { return k })
+	a := key{name: "a", id: 1, score: 0, ref: ref, value: 1}
+	b := key{name: "a", id: 1, score: math.Copysign(0, -1), ref: ref, value: 1}
+	if a != b || hash(a) != hash(b) {
+		t.Errorf("Expected equal keys=%v to have equal hashes", a)
+	}
+	for _, c := range []key{{name: "b", id: 1}, {name: "a", id: 2}, {name: "a", id: 1, ref: new(int)}, {value: "1"}} {
+		if hash(c) == hash(a) {
+			t.Errorf("Expected keys=%v and %v to have different hashes", c, a)
+		}
+	}
+}
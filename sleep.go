@@ -0,0 +1,39 @@
+package goroutines
+
+import (
+	"context"
+	"time"
+)
+
+// Sleep pauses for duration d, or until the context is done. The error of the
+// context is returned if it is done before d elapses, otherwise nil.
+func Sleep(ctx context.Context, d time.Duration) error {
+	return sleep(ctx, RealClock, d)
+}
+
+// sleep is Sleep using the timers of clock.
+func sleep(ctx context.Context, clock Clock, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := clock.NewTimer(d)
+	select {
+	case <-ctx.Done():
+		t.Stop()
+		return ctx.Err()
+	case <-t.C():
+		return nil
+	}
+}
+
+// After returns a channel which is closed once duration d elapses, or the
+// context is done. Unlike time.After the timer is released as soon as the
+// context is done.
+func After(ctx context.Context, d time.Duration) <-chan struct{} {
+	c := make(chan struct{})
+	go func() {
+		_ = Sleep(ctx, d)
+		close(c)
+	}()
+	return c
+}
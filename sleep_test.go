@@ -0,0 +1,47 @@
+package goroutines
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSleep(t *testing.T) {
+	start := time.Now()
+	if err := Sleep(context.Background(), 20*time.Millisecond); err != nil {
+		t.Errorf("Expected no error but received error=%v", err)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("Expected sleep=%v but slept=%v", 20*time.Millisecond, d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if err := Sleep(ctx, time.Hour); err != context.DeadlineExceeded {
+		t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+	}
+	if d := time.Since(start); d > allowedVariance {
+		t.Errorf("Expected sleep to be interrupted but slept=%v", d)
+	}
+	if err := Sleep(ctx, 0); err != context.DeadlineExceeded {
+		t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestAfter(t *testing.T) {
+	select {
+	case <-After(context.Background(), 10*time.Millisecond):
+	case <-time.After(allowedVariance):
+		t.Errorf("Expected channel to be closed after duration")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := After(ctx, time.Hour)
+	cancel()
+	select {
+	case <-c:
+	case <-time.After(allowedVariance):
+		t.Errorf("Expected channel to be closed when context is done")
+	}
+}
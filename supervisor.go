@@ -0,0 +1,168 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// RestartPolicy determines when a supervised function is restarted.
+type RestartPolicy int
+
+const (
+	// RestartAlways restarts the function whenever it returns.
+	RestartAlways RestartPolicy = iota
+
+	// RestartOnFailure restarts the function when it returns an error or panics.
+	RestartOnFailure
+
+	// RestartOnPanic restarts the function only when it panics.
+	RestartOnPanic
+
+	// RestartNever runs the function once.
+	RestartNever
+)
+
+// SupervisorPolicy configures restarts of supervised functions.
+type SupervisorPolicy struct {
+	// Restart determines when the function is restarted.
+	Restart RestartPolicy
+
+	// MaxRestarts limits restarts, unlimited when less than one.
+	MaxRestarts int
+
+	// Backoff determines the delay before each restart, waited on its Clock.
+	Backoff RetryPolicy
+
+	// ResetAfter resets the count of restarts, and so the backoff, after a
+	// run of at least this long by the Clock of Backoff, so a function which
+	// ran healthily is not limited by earlier failures. Never reset when zero.
+	ResetAfter time.Duration
+}
+
+// Supervisor runs long-lived functions in goroutines, recovering panics and
+// restarting them according to a policy until shutdown.
+type Supervisor struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel func()
+	policy SupervisorPolicy
+	wg     sync.WaitGroup
+	errs   map[string]error
+	names  []string // in order of Go
+	down   bool     // set by Shutdown
+}
+
+// NewSupervisor returns a Supervisor with a default policy for Go.
+func NewSupervisor(policy SupervisorPolicy) *Supervisor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Supervisor{
+		ctx:    ctx,
+		cancel: cancel,
+		policy: policy,
+		errs:   make(map[string]error),
+	}
+}
+
+// Go runs the named function using the default policy. The context passed
+// to the function is cancelled on shutdown. Calls after Shutdown are ignored.
+func (s *Supervisor) Go(name string, fn func(context.Context) error) {
+	s.GoWithPolicy(name, s.policy, fn)
+}
+
+// GoWithPolicy runs the named function using the given policy.
+func (s *Supervisor) GoWithPolicy(name string, policy SupervisorPolicy, fn func(context.Context) error) {
+	s.mu.Lock()
+	if s.down {
+		s.mu.Unlock()
+		return
+	}
+	if _, ok := s.errs[name]; !ok {
+		s.errs[name] = nil
+		s.names = append(s.names, name)
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		clock := policy.Backoff.clock()
+		for restarts := 0; ; restarts++ {
+			start := clock.Now()
+			err := protect(func() error {
+				return fn(s.ctx)
+			})
+			s.mu.Lock()
+			s.errs[name] = err
+			s.mu.Unlock()
+
+			if s.ctx.Err() != nil || !policy.restart(err) {
+				return
+			}
+			if policy.ResetAfter > 0 && clock.Now().Sub(start) >= policy.ResetAfter {
+				restarts = 0
+			}
+			if policy.MaxRestarts > 0 && restarts >= policy.MaxRestarts {
+				return
+			}
+
+			if policy.Backoff.wait(s.ctx, restarts+1) != nil {
+				return
+			}
+		}
+	}()
+}
+
+// Err returns the last error returned by the named function.
+func (s *Supervisor) Err(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.errs[name]
+}
+
+// Shutdown cancels all supervised functions and waits for them to return, or
+// for the context to be cancelled. Errors returned by functions, other than
+// context cancellation, are joined in the result in the order the functions
+// were started.
+func (s *Supervisor) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.down = true
+	s.mu.Unlock()
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for _, name := range s.names {
+		if err := s.errs[name]; err != nil && !errors.Is(err, context.Canceled) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p SupervisorPolicy) restart(err error) bool {
+	switch p.Restart {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	case RestartOnPanic:
+		var perr *PanicError
+		return errors.As(err, &perr)
+	}
+	return false
+}
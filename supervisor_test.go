@@ -0,0 +1,209 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSupervisor(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      SupervisorPolicy
+		fn          func(n int64) error
+		expectRuns  int64
+		expectPanic bool
+		expectErr   error
+	}{
+		{
+			name:       "never restart",
+			policy:     SupervisorPolicy{Restart: RestartNever},
+			fn:         func(_ int64) error { return testErr },
+			expectRuns: 1,
+			expectErr:  testErr,
+		},
+		{
+			name:   "restart on failure until success",
+			policy: SupervisorPolicy{Restart: RestartOnFailure},
+			fn: func(n int64) error {
+				if n < 3 {
+					return testErr
+				}
+				return nil
+			},
+			expectRuns: 3,
+		},
+		{
+			name:   "restart on panic",
+			policy: SupervisorPolicy{Restart: RestartOnPanic},
+			fn: func(n int64) error {
+				if n < 3 {
+					panic("test panic")
+				}
+				return testErr
+			},
+			expectRuns: 3,
+			expectErr:  testErr,
+		},
+		{
+			name:        "always restart limited",
+			policy:      SupervisorPolicy{Restart: RestartAlways, MaxRestarts: 4},
+			fn:          func(_ int64) error { panic("test panic") },
+			expectRuns:  5,
+			expectPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var runs atomic.Int64
+			s := NewSupervisor(tt.policy)
+			s.Go(tt.name, func(_ context.Context) error {
+				return tt.fn(runs.Add(1))
+			})
+			time.Sleep(20 * time.Millisecond)
+			err := s.Shutdown(context.Background())
+			if n := runs.Load(); n != tt.expectRuns {
+				t.Errorf("Expected runs=%v but received runs=%v", tt.expectRuns, n)
+			}
+			var perr *PanicError
+			if tt.expectPanic && !errors.As(err, &perr) {
+				t.Errorf("Expected panic error but received error=%v", err)
+			}
+			if !tt.expectPanic && !errors.Is(err, tt.expectErr) && err != tt.expectErr {
+				t.Errorf("Expected error=%v but received error=%v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestSupervisorShutdown(t *testing.T) {
+	s := NewSupervisor(SupervisorPolicy{Restart: RestartAlways, Backoff: RetryPolicy{InitialBackoff: time.Millisecond}})
+	s.Go("loop", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	s.Go("stubborn", func(_ context.Context) error {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+	}
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected error=%v but received error=%v", nil, err)
+	}
+	if err := s.Err("loop"); err != context.Canceled {
+		t.Errorf("Expected error=%v but received error=%v", context.Canceled, err)
+	}
+
+	var ran atomic.Bool
+	s.Go("late", func(_ context.Context) error {
+		ran.Store(true)
+		return nil
+	})
+	if err := s.Shutdown(context.Background()); err != nil || ran.Load() {
+		t.Errorf("Expected error=%v without running but received error=%v ran=%v", nil, err, ran.Load())
+	}
+}
+
+func TestSupervisorBackoffClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	s := NewSupervisor(SupervisorPolicy{
+		Restart:     RestartOnFailure,
+		MaxRestarts: 1,
+		Backoff:     RetryPolicy{InitialBackoff: time.Hour, Clock: clock},
+	})
+	var runs atomic.Int64
+	s.Go("fail", func(_ context.Context) error {
+		runs.Add(1)
+		return testErr
+	})
+
+	for clock.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runs.Load(); n != 1 {
+		t.Errorf("Expected runs=%v during backoff but received runs=%v", 1, n)
+	}
+	clock.Advance(time.Hour)
+	for runs.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := s.Shutdown(context.Background()); !errors.Is(err, testErr) {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+}
+
+func TestSupervisorResetAfter(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	s := NewSupervisor(SupervisorPolicy{
+		Restart:     RestartOnFailure,
+		MaxRestarts: 1,
+		Backoff:     RetryPolicy{InitialBackoff: time.Second, Clock: clock},
+		ResetAfter:  time.Minute,
+	})
+	var runs atomic.Int64
+	healthy := make(chan struct{})
+	s.Go("flaky", func(_ context.Context) error {
+		switch runs.Add(1) {
+		case 2:
+			<-healthy // fails after a long healthy run
+		case 3:
+			return nil
+		}
+		return testErr
+	})
+
+	for clock.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+	for runs.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Minute)
+	close(healthy)
+
+	// restarted beyond MaxRestarts with the initial backoff
+	for clock.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+	for runs.Load() < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected error=%v but received error=%v", nil, err)
+	}
+}
+
+func TestSupervisorShutdownOrder(t *testing.T) {
+	s := NewSupervisor(SupervisorPolicy{Restart: RestartNever})
+	var expect []error
+	for i := 0; i < 10; i++ {
+		err := fmt.Errorf("test error %d", i)
+		expect = append(expect, err)
+		s.Go(err.Error(), func(_ context.Context) error { return err })
+	}
+	err := s.Shutdown(context.Background())
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("Expected joined errors but received error=%v", err)
+	}
+	errs := joined.Unwrap()
+	if len(errs) != len(expect) {
+		t.Fatalf("Expected errors=%v but received errors=%v", expect, errs)
+	}
+	for i := range errs {
+		if errs[i] != expect[i] {
+			t.Errorf("Expected errors=%v but received errors=%v", expect, errs)
+			break
+		}
+	}
+}
@@ -1,16 +1,67 @@
 package goroutines
 
 import (
+	"container/list"
 	"context"
+	"log"
+	"math/bits"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-var s = struct{}{}
+type semWaiter struct {
+	n         int
+	exclusive bool // n is the limit when granted
+	ready     chan struct{}
+}
 
 // TimedMutex implements mutex-like interface but adds lock timeouts.
 // The zero value cannot be used.
+//
+// A TimedMutex with a limit greater than one is a weighted semaphore. Waiters
+// are served in order, so a large acquisition is not starved by smaller ones.
 type TimedMutex struct {
-	c chan struct{}
+	mu        sync.Mutex
+	size      int
+	cur       int
+	exclusive bool // held by an exclusive holder
+	waiters   list.List
+	watch     time.Duration
+	onHeld    func(LockHolder)
+	holders   list.List // holders when watched, oldest first
+	stats     MutexStats
+	waits     [waitBuckets]uint64 // histogram of wait times
+}
+
+// waitBuckets of a histogram of wait times, where bucket i counts waits
+// shorter than 2^i microseconds.
+const waitBuckets = 32
+
+// MutexStats are counters of a TimedMutex. See TimedMutex.Stats.
+type MutexStats struct {
+	Acquisitions  uint64        // successful acquisitions
+	Timeouts      uint64        // acquisitions which timed out, including TryLock
+	Cancellations uint64        // acquisitions cancelled by context
+	WaitTotal     time.Duration // total time waiting to acquire
+	WaitP50       time.Duration // upper bound of the median wait
+	WaitP99       time.Duration // upper bound of the 99th percentile wait
+	Held          int           // slots currently held
+	Waiting       int           // acquisitions currently waiting
+}
+
+// LockHolder describes a holder of a TimedMutex. See WithWatchdog.
+type LockHolder struct {
+	Slots int
+	Since time.Time
+	Stack []byte // stack of the goroutine which acquired the lock
+}
+
+type lockHolder struct {
+	LockHolder
+	timer *time.Timer
+	elem  *list.Element
 }
 
 // NewVariableTimedMutex returns a new TimedMutex.
@@ -20,13 +71,32 @@ func NewVariableTimedMutex(limit int) *TimedMutex {
 	if p <= 0 {
 		p = 1
 	}
-	l := &TimedMutex{
-		c: make(chan struct{}, p),
+	return &TimedMutex{size: p}
+}
+
+// SetLimit changes how many consumers can obtain the mutex at once. When the
+// limit shrinks, holders keep their slots and new acquisitions wait until
+// enough slots are released. Acquisitions of more slots than the limit wait
+// until it grows, and waiters behind them are served in order.
+func (l *TimedMutex) SetLimit(limit int) {
+	if limit <= 0 {
+		limit = 1
 	}
-	for i := 0; i < p; i++ {
-		l.c <- s
+	l.mu.Lock()
+	if l.size == 0 {
+		l.mu.Unlock()
+		panic("Uninitialized TimedMutex")
 	}
-	return l
+	l.size = limit
+	l.notify()
+	l.mu.Unlock()
+}
+
+// Limit returns how many consumers can obtain the mutex at once.
+func (l *TimedMutex) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.size
 }
 
 // NewTimedMutex returns a TimedMutex similar to sync.Mutex.
@@ -34,67 +104,420 @@ func NewTimedMutex() *TimedMutex {
 	return NewVariableTimedMutex(1)
 }
 
-func (l *TimedMutex) internalLock(t time.Duration) bool {
-	if l.c == nil {
+// WithWatchdog records the stack of each holder of the mutex, and calls fn
+// once for each holder which holds the mutex longer than threshold. If fn is
+// nil the holder is logged. Recording stacks is expensive, so the watchdog is
+// intended for debugging contention.
+//
+// Unlock and Release are assumed to release the oldest holder, unless the
+// mutex was acquired with a guard method such as LockGuard.
+func (l *TimedMutex) WithWatchdog(threshold time.Duration, fn func(LockHolder)) *TimedMutex {
+	if fn == nil {
+		fn = func(h LockHolder) {
+			log.Printf("TimedMutex held for more than %v since %v by:\n%s", threshold, h.Since, h.Stack)
+		}
+	}
+	l.mu.Lock()
+	l.watch = threshold
+	l.onHeld = fn
+	l.mu.Unlock()
+	return l
+}
+
+// hold records a holder of n slots when watched, must be called with lock
+// held.
+func (l *TimedMutex) hold(n int) *lockHolder {
+	if l.watch <= 0 {
+		return nil
+	}
+	h := &lockHolder{LockHolder: LockHolder{
+		Slots: n,
+		Since: time.Now(),
+		Stack: debug.Stack(),
+	}}
+	fn := l.onHeld
+	h.timer = time.AfterFunc(l.watch, func() {
+		fn(h.LockHolder)
+	})
+	h.elem = l.holders.PushBack(h)
+	return h
+}
+
+// acquire n slots, waiting until the context is cancelled or timeout. Wait
+// indefinitely when timeout is negative, or not at all when it is zero.
+func (l *TimedMutex) acquire(ctx context.Context, n int, t time.Duration) (*lockHolder, error) {
+	return l.wait(ctx, &semWaiter{n: n}, t)
+}
+
+// wait for the slots of waiter w until the context is cancelled or timeout.
+func (l *TimedMutex) wait(ctx context.Context, w *semWaiter, t time.Duration) (*lockHolder, error) {
+	l.mu.Lock()
+	if l.size == 0 {
+		l.mu.Unlock()
 		panic("Uninitialized TimedMutex")
 	}
-	if t < 0 {
-		<-l.c
-		return true
+	if w.n <= 0 && !w.exclusive {
+		l.mu.Unlock()
+		panic("TimedMutex acquire of non-positive slots")
+	}
+	if l.waiters.Len() == 0 && l.take(w) {
+		l.waited(0, nil)
+		h := l.hold(w.n)
+		l.mu.Unlock()
+		return h, nil
 	}
 	if t == 0 {
-		select {
-		case <-l.c:
-			return true
-		default:
+		l.waited(0, ErrRunnerTimedout)
+		l.mu.Unlock()
+		return nil, ErrRunnerTimedout
+	}
+
+	start := time.Now()
+	ready := make(chan struct{})
+	w.ready = ready
+	elem := l.waiters.PushBack(w)
+	l.mu.Unlock()
+
+	var expired <-chan time.Time
+	if t > 0 {
+		timer := time.NewTimer(t)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	var err error
+	select {
+	case <-ready:
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.waited(time.Since(start), nil)
+		return l.hold(w.n), nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-expired:
+		err = ErrRunnerTimedout
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-ready:
+		l.waited(time.Since(start), nil)
+		return l.hold(w.n), nil // acquired while giving up
+	default:
+	}
+	l.waited(time.Since(start), err)
+	isFront := l.waiters.Front() == elem
+	l.waiters.Remove(elem)
+	if isFront && l.size > l.cur {
+		l.notify() // smaller waiters may now fit
+	}
+	return nil, err
+}
+
+// waited records the outcome of an acquisition, must be called with lock
+// held.
+func (l *TimedMutex) waited(d time.Duration, err error) {
+	switch err {
+	case nil:
+		l.stats.Acquisitions++
+	case ErrRunnerTimedout:
+		l.stats.Timeouts++
+	default:
+		l.stats.Cancellations++
+	}
+	l.stats.WaitTotal += d
+	i := bits.Len64(uint64(d / time.Microsecond))
+	if i >= waitBuckets {
+		i = waitBuckets - 1
+	}
+	l.waits[i]++
+}
+
+// Stats returns the counters of the mutex.
+func (l *TimedMutex) Stats() MutexStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := l.stats
+	stats.Held = l.cur
+	stats.Waiting = l.waiters.Len()
+	stats.WaitP50 = l.percentile(0.5)
+	stats.WaitP99 = l.percentile(0.99)
+	return stats
+}
+
+// percentile returns the upper bound of the bucket containing the given
+// percentile of wait times, must be called with lock held.
+func (l *TimedMutex) percentile(p float64) time.Duration {
+	var total uint64
+	for _, n := range l.waits {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(p * float64(total))
+	var sum uint64
+	for i, n := range l.waits {
+		sum += n
+		if sum > rank {
+			return time.Duration(1<<i) * time.Microsecond
+		}
+	}
+	return time.Duration(1<<(waitBuckets-1)) * time.Microsecond
+}
+
+// notify waiters which fit in order, must be called with lock held.
+func (l *TimedMutex) notify() {
+	for {
+		next := l.waiters.Front()
+		if next == nil {
+			return
+		}
+		w := next.Value.(*semWaiter)
+		if !l.take(w) {
+			return // no starvation of large waiters
+		}
+		l.waiters.Remove(next)
+		close(w.ready)
+	}
+}
+
+// take the slots of waiter w if they are available, must be called with lock
+// held. An exclusive waiter takes every slot of the current limit.
+func (l *TimedMutex) take(w *semWaiter) bool {
+	if l.exclusive {
+		return false
+	}
+	if w.exclusive {
+		if l.cur > 0 {
 			return false
 		}
+		w.n = l.size
+		l.exclusive = true
+	} else if l.size-l.cur < w.n {
+		return false
 	}
-	timer := time.NewTimer(t)
-	select {
-	case <-l.c:
-		return true
-	case <-timer.C:
+	l.cur += w.n
+	return true
+}
+
+// Acquire n slots of the mutex, blocking until available. Acquire and the
+// other methods acquiring or releasing n slots panic if n is not positive.
+func (l *TimedMutex) Acquire(n int) {
+	_, _ = l.acquire(context.Background(), n, -1)
+}
+
+// AcquireTimeout returns true if n slots were acquired before timeout.
+func (l *TimedMutex) AcquireTimeout(n int, timeout time.Duration) bool {
+	_, err := l.acquire(context.Background(), n, timeout)
+	return err == nil
+}
+
+// AcquireUntil returns true if n slots were acquired before deadline. If the
+// deadline has passed it only succeeds if the slots are available.
+func (l *TimedMutex) AcquireUntil(n int, deadline time.Time) bool {
+	d := time.Until(deadline)
+	if d < 0 {
+		d = 0 // negative timeouts wait indefinitely
 	}
-	return false
+	return l.AcquireTimeout(n, d)
 }
 
-// LockTimeout returns true if the lock succeeded before timeout.
-func (l *TimedMutex) LockTimeout(timeout time.Duration) bool {
-	return l.internalLock(timeout)
+// AcquireWithContext returns an error if context is cancelled before n slots
+// are acquired.
+func (l *TimedMutex) AcquireWithContext(ctx context.Context, n int) error {
+	_, err := l.acquire(ctx, n, -1)
+	return err
 }
 
-// LockTimeout returns an error if context is cancelled before lock succeeds.
-func (l *TimedMutex) LockWithContext(ctx context.Context) error {
-	if l.c == nil {
+// TryAcquire tries to acquire n slots and reports whether it succeeded.
+func (l *TimedMutex) TryAcquire(n int) bool {
+	_, err := l.acquire(context.Background(), n, 0)
+	return err == nil
+}
+
+// Release n slots of the mutex.
+func (l *TimedMutex) Release(n int) {
+	l.release(n, nil, false)
+}
+
+// release n slots acquired by holder h, or the oldest holder if nil, and the
+// exclusive hold of the mutex if exclusive.
+func (l *TimedMutex) release(n int, h *lockHolder, exclusive bool) {
+	l.mu.Lock()
+	if l.size == 0 {
+		l.mu.Unlock()
 		panic("Uninitialized TimedMutex")
 	}
-	select {
-	case <-l.c:
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
+	if n <= 0 {
+		l.mu.Unlock()
+		panic("TimedMutex release of non-positive slots")
+	}
+	l.cur -= n
+	if l.cur < 0 {
+		l.cur += n
+		l.mu.Unlock()
+		panic("TimedMutex unlock of unlocked mutex")
 	}
+	if exclusive {
+		l.exclusive = false
+	}
+	if h == nil && l.holders.Len() > 0 {
+		h = l.holders.Front().Value.(*lockHolder)
+	}
+	if h != nil && h.elem != nil {
+		h.timer.Stop()
+		l.holders.Remove(h.elem)
+		h.elem = nil
+	}
+	l.notify()
+	l.mu.Unlock()
+}
+
+// LockTimeout returns true if the lock succeeded before timeout. A negative
+// timeout waits indefinitely, see LockUntil for deadlines.
+func (l *TimedMutex) LockTimeout(timeout time.Duration) bool {
+	return l.AcquireTimeout(1, timeout)
+}
+
+// LockUntil returns true if the lock succeeded before deadline. If the
+// deadline has passed it is identical to TryLock.
+func (l *TimedMutex) LockUntil(deadline time.Time) bool {
+	return l.AcquireUntil(1, deadline)
+}
+
+// LockTimeout returns an error if context is cancelled before lock succeeds.
+func (l *TimedMutex) LockWithContext(ctx context.Context) error {
+	return l.AcquireWithContext(ctx, 1)
 }
 
 // Lock locks the mutex.
 func (l *TimedMutex) Lock() {
-	l.internalLock(-1)
+	l.Acquire(1)
 }
 
 // TryLock tries to lock and reports whether it succeeded.
 func (l *TimedMutex) TryLock() bool {
-	return l.internalLock(0)
+	return l.TryAcquire(1)
 }
 
 // Unlock unlocks the mutex.
 func (l *TimedMutex) Unlock() {
-	if l.c == nil {
-		panic("Uninitialized TimedMutex")
+	l.Release(1)
+}
+
+// Unlocker releases the slots acquired by a guard method of TimedMutex, such
+// as LockGuard. It panics if called more than once, unlike Unlock which any
+// goroutine may call without holding the lock.
+type Unlocker func()
+
+// guard returns an Unlocker releasing n slots acquired by holder h.
+func (l *TimedMutex) guard(n int, h *lockHolder) Unlocker {
+	var released atomic.Bool
+	return func() {
+		if !released.CompareAndSwap(false, true) {
+			panic("TimedMutex unlock of released guard")
+		}
+		l.release(n, h, false)
 	}
-	select {
-	case l.c <- s:
-	default:
-		panic("TimedMutex unlock of unlocked mutex")
+}
+
+// LockGuard locks the mutex, returning the Unlocker to unlock it.
+func (l *TimedMutex) LockGuard() Unlocker {
+	h, _ := l.acquire(context.Background(), 1, -1)
+	return l.guard(1, h)
+}
+
+// LockGuardTimeout is LockTimeout returning the Unlocker to unlock the mutex,
+// or nil if the lock did not succeed before timeout.
+func (l *TimedMutex) LockGuardTimeout(timeout time.Duration) (Unlocker, bool) {
+	h, err := l.acquire(context.Background(), 1, timeout)
+	if err != nil {
+		return nil, false
+	}
+	return l.guard(1, h), true
+}
+
+// LockGuardWithContext is LockWithContext returning the Unlocker to unlock
+// the mutex, or nil with an error if the context is cancelled.
+func (l *TimedMutex) LockGuardWithContext(ctx context.Context) (Unlocker, error) {
+	h, err := l.acquire(ctx, 1, -1)
+	if err != nil {
+		return nil, err
+	}
+	return l.guard(1, h), nil
+}
+
+// TryLockGuard is TryLock returning the Unlocker to unlock the mutex, or nil
+// if the lock did not succeed.
+func (l *TimedMutex) TryLockGuard() (Unlocker, bool) {
+	h, err := l.acquire(context.Background(), 1, 0)
+	if err != nil {
+		return nil, false
+	}
+	return l.guard(1, h), true
+}
+
+// AcquireGuard is AcquireWithContext returning the Unlocker to release the
+// n slots, or nil with an error if the context is cancelled.
+func (l *TimedMutex) AcquireGuard(ctx context.Context, n int) (Unlocker, error) {
+	h, err := l.acquire(ctx, n, -1)
+	if err != nil {
+		return nil, err
+	}
+	return l.guard(n, h), nil
+}
+
+// TimedMutex satisfies sync.Locker, blocking indefinitely in Lock.
+var _ sync.Locker = (*TimedMutex)(nil)
+
+// Locker returns a sync.Locker of the mutex, such as for sync.Cond, whose
+// Lock panics if the lock does not succeed within timeout. A negative
+// timeout blocks indefinitely, like the TimedMutex itself.
+func (l *TimedMutex) Locker(timeout time.Duration) sync.Locker {
+	return timedLocker{l, timeout}
+}
+
+type timedLocker struct {
+	l       *TimedMutex
+	timeout time.Duration
+}
+
+func (t timedLocker) Lock() {
+	if !t.l.LockTimeout(t.timeout) {
+		panic("TimedMutex lock timed out")
+	}
+}
+
+func (t timedLocker) Unlock() {
+	t.l.Unlock()
+}
+
+// ExclusiveLocker returns a sync.Locker of the mutex which holds every slot
+// of the limit, excluding all other holders even if the limit grows. Since
+// Lock and Locker hold one slot, they act as the read side of a read-write
+// lock for which the ExclusiveLocker is the write side. Waiters are served in
+// order, so the exclusive holder is not starved. Lock panics if the lock does
+// not succeed within timeout, or blocks indefinitely if timeout is negative.
+func (l *TimedMutex) ExclusiveLocker(timeout time.Duration) sync.Locker {
+	return &exclusiveLocker{l: l, timeout: timeout}
+}
+
+type exclusiveLocker struct {
+	l       *TimedMutex
+	timeout time.Duration
+	n       int // slots held, written only by the exclusive holder
+}
+
+func (e *exclusiveLocker) Lock() {
+	w := &semWaiter{exclusive: true}
+	if _, err := e.l.wait(context.Background(), w, e.timeout); err != nil {
+		panic("TimedMutex lock timed out")
 	}
+	e.n = w.n
+}
+
+func (e *exclusiveLocker) Unlock() {
+	e.l.release(e.n, nil, true)
 }
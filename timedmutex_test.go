@@ -1,7 +1,9 @@
 package goroutines
 
 import (
+	"bytes"
 	"context"
+	"sync"
 	"testing"
 	"time"
 )
@@ -39,6 +41,24 @@ func TestTimedLock(t *testing.T) {
 			},
 			elapsed: 1 * time.Second,
 		},
+		{
+			name: "lock until deadline fails",
+			op: func(tl *TimedMutex) {
+				if tl.LockUntil(time.Now().Add(500 * time.Millisecond)) {
+					t.Errorf("Expected a timeout waiting for lock")
+				}
+			},
+			elapsed: 500 * time.Millisecond,
+		},
+		{
+			name: "lock until past deadline fails immediately",
+			op: func(tl *TimedMutex) {
+				if tl.LockUntil(time.Now().Add(-time.Second)) {
+					t.Errorf("Expected a timeout waiting for lock")
+				}
+			},
+			elapsed: 100 * time.Millisecond,
+		},
 		{
 			name: "lock with context fails",
 			op: func(tl *TimedMutex) {
@@ -89,6 +109,22 @@ func TestTimedLock(t *testing.T) {
 			},
 			elapsed: 100 * time.Millisecond,
 		},
+		{
+			name: "lock until past deadline succeeds",
+			op: func(tl *TimedMutex) {
+				if !tl.LockUntil(time.Now().Add(-time.Second)) {
+					t.Errorf("Expected immediate lock")
+				}
+			},
+			elapsed: 100 * time.Millisecond,
+		},
+		{
+			name: "unlock immediate",
+			op: func(tl *TimedMutex) {
+				tl.Unlock()
+			},
+			elapsed: 100 * time.Millisecond,
+		},
 		{
 			name: "lock with context succeeds",
 			op: func(tl *TimedMutex) {
@@ -151,6 +187,62 @@ func TestPanics(t *testing.T) {
 				mu.Unlock()
 			},
 		},
+		{
+			name: "panic double unlock of guard",
+			fn: func() {
+				mu := NewVariableTimedMutex(2)
+				unlock := mu.LockGuard()
+				mu.Lock()
+				unlock()
+				unlock()
+			},
+		},
+		{
+			name: "panic locker timeout",
+			fn: func() {
+				mu := NewTimedMutex()
+				mu.Lock()
+				mu.Locker(10 * time.Millisecond).Lock()
+			},
+		},
+		{
+			name: "panic exclusive locker timeout while locked",
+			fn: func() {
+				mu := NewVariableTimedMutex(3)
+				mu.Lock()
+				mu.ExclusiveLocker(10 * time.Millisecond).Lock()
+			},
+		},
+		{
+			name: "panic locker timeout while exclusively locked",
+			fn: func() {
+				mu := NewVariableTimedMutex(3)
+				mu.ExclusiveLocker(-1).Lock()
+				mu.Locker(10 * time.Millisecond).Lock()
+			},
+		},
+		{
+			name: "panic acquire of zero slots",
+			fn: func() {
+				mu := NewVariableTimedMutex(2)
+				mu.Acquire(0)
+			},
+		},
+		{
+			name: "panic try acquire of negative slots",
+			fn: func() {
+				mu := NewVariableTimedMutex(2)
+				mu.TryAcquire(-1)
+			},
+		},
+		{
+			name: "panic release of negative slots",
+			fn: func() {
+				mu := NewVariableTimedMutex(2)
+				mu.Lock()
+				mu.Release(-1)
+			},
+		},
 	}
 
 	for _, tt := range ttests {
@@ -166,3 +258,256 @@ func TestPanics(t *testing.T) {
 		})
 	}
 }
+
+func TestWeightedAcquire(t *testing.T) {
+	l := NewVariableTimedMutex(4)
+	l.Acquire(3)
+	if l.TryAcquire(2) {
+		t.Errorf("Expected acquire beyond limit to fail")
+	}
+
+	// A large waiter is not starved by smaller acquisitions
+	large := make(chan struct{})
+	go func() {
+		l.Acquire(4)
+		close(large)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if l.TryAcquire(1) {
+		t.Errorf("Expected acquire behind waiter to fail")
+	}
+	if l.AcquireTimeout(1, 20*time.Millisecond) {
+		t.Errorf("Expected acquire behind waiter to time out")
+	}
+	l.Release(3)
+	<-large
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.AcquireWithContext(ctx, 1); err != context.DeadlineExceeded {
+		t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+	}
+	l.Release(2)
+	if err := l.AcquireWithContext(context.Background(), 2); err != nil {
+		t.Errorf("Expected acquire but received error=%v", err)
+	}
+	l.Release(4)
+	if !l.TryAcquire(4) {
+		t.Errorf("Expected acquire of all slots")
+	}
+}
+
+func TestLockGuard(t *testing.T) {
+	l := NewVariableTimedMutex(3)
+	unlock := l.LockGuard()
+	release, err := l.AcquireGuard(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, ok := l.TryLockGuard(); ok || u != nil {
+		t.Errorf("Expected guard of full mutex to fail")
+	}
+	if u, ok := l.LockGuardTimeout(10 * time.Millisecond); ok || u != nil {
+		t.Errorf("Expected guard of full mutex to time out")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if u, err := l.LockGuardWithContext(ctx); err != context.Canceled || u != nil {
+		t.Errorf("Expected error=%v but received error=%v", context.Canceled, err)
+	}
+
+	release()
+	unlock()
+	if u, ok := l.LockGuardTimeout(time.Second); !ok {
+		t.Errorf("Expected guard after unlock")
+	} else {
+		u()
+	}
+	if !l.TryAcquire(3) {
+		t.Errorf("Expected all slots released")
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	held := make(chan LockHolder, 2)
+	l := NewVariableTimedMutex(2).WithWatchdog(20*time.Millisecond, func(h LockHolder) {
+		held <- h
+	})
+
+	l.Lock()
+	unlock := l.LockGuard()
+	unlock()
+	select {
+	case h := <-held:
+		if h.Slots != 1 || !bytes.Contains(h.Stack, []byte("TestWatchdog")) {
+			t.Errorf("Expected holder stack of TestWatchdog but received slots=%v stack=%s", h.Slots, h.Stack)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected watchdog callback for lock held beyond threshold")
+	}
+	l.Unlock()
+
+	l.Lock()
+	l.Unlock()
+	time.Sleep(40 * time.Millisecond)
+	select {
+	case h := <-held:
+		t.Errorf("Unexpected watchdog callback since=%v", h.Since)
+	default:
+	}
+}
+
+func TestMutexStats(t *testing.T) {
+	l := NewVariableTimedMutex(2)
+	l.Acquire(2)
+	if l.TryLock() {
+		t.Errorf("Expected lock of full mutex to fail")
+	}
+	if l.LockTimeout(10 * time.Millisecond) {
+		t.Errorf("Expected lock of full mutex to time out")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.LockWithContext(ctx); err == nil {
+		t.Errorf("Expected lock of full mutex to be cancelled")
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		l.Release(2)
+	}()
+	l.Lock()
+
+	stats := l.Stats()
+	if stats.Acquisitions != 2 || stats.Timeouts != 2 || stats.Cancellations != 1 || stats.Held != 1 || stats.Waiting != 0 {
+		t.Errorf("Expected acquisitions=2 timeouts=2 cancellations=1 held=1 but received stats=%+v", stats)
+	}
+	if stats.WaitTotal < 40*time.Millisecond || stats.WaitP99 < 16*time.Millisecond || stats.WaitP50 > stats.WaitP99 {
+		t.Errorf("Expected wait times of at least %v but received stats=%+v", 40*time.Millisecond, stats)
+	}
+}
+
+func TestSetLimit(t *testing.T) {
+	l := NewVariableTimedMutex(2)
+	l.Lock()
+	l.Lock()
+
+	acquired := make(chan struct{})
+	go func() {
+		l.Lock()
+		close(acquired)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	l.SetLimit(3) // grow wakes waiter
+	<-acquired
+
+	l.SetLimit(1)
+	if n := l.Limit(); n != 1 {
+		t.Errorf("Expected limit=%v but received limit=%v", 1, n)
+	}
+	l.Unlock()
+	l.Unlock()
+	if l.TryLock() {
+		t.Errorf("Expected lock to fail until held slots are within limit")
+	}
+	l.Unlock()
+	if !l.TryLock() {
+		t.Errorf("Expected lock within shrunk limit")
+	}
+	if l.TryLock() {
+		t.Errorf("Expected lock beyond shrunk limit to fail")
+	}
+}
+
+func TestSetLimitWaiter(t *testing.T) {
+	l := NewVariableTimedMutex(2)
+	if l.TryAcquire(3) {
+		t.Errorf("Expected acquire beyond limit to fail")
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		l.Acquire(3)
+		close(acquired)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-acquired:
+		t.Fatalf("Expected acquire beyond limit to wait")
+	default:
+	}
+	if l.TryLock() {
+		t.Errorf("Expected lock behind waiter beyond limit to fail")
+	}
+
+	l.SetLimit(3) // grow wakes waiter
+	<-acquired
+	if n := l.Stats().Held; n != 3 {
+		t.Errorf("Expected held=%v but received held=%v", 3, n)
+	}
+	l.Release(3)
+}
+
+func TestLocker(t *testing.T) {
+	l := NewTimedMutex()
+	cond := sync.NewCond(l.Locker(time.Second))
+	ready := false
+	done := make(chan struct{})
+	go func() {
+		cond.L.Lock()
+		for !ready {
+			cond.Wait()
+		}
+		cond.L.Unlock()
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cond.L.Lock()
+	ready = true
+	cond.L.Unlock()
+	cond.Broadcast()
+	<-done
+
+	if !l.TryLock() {
+		t.Errorf("Expected mutex to be unlocked")
+	}
+}
+
+func TestExclusiveLocker(t *testing.T) {
+	l := NewVariableTimedMutex(3)
+	r, w := l.Locker(time.Second), l.ExclusiveLocker(time.Second)
+
+	for i := 0; i < 3; i++ {
+		r.Lock()
+	}
+	if held := l.Stats().Held; held != 3 {
+		t.Errorf("Expected held=%v but received held=%v", 3, held)
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		w.Lock()
+		close(locked)
+	}()
+	for l.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	l.SetLimit(2) // shrinking while waiting does not deadlock the waiter
+	for i := 0; i < 3; i++ {
+		r.Unlock()
+	}
+	<-locked
+	if held := l.Stats().Held; held != 2 {
+		t.Errorf("Expected held=%v but received held=%v", 2, held)
+	}
+
+	l.SetLimit(5) // growing while held does not admit other holders
+	if l.TryAcquire(1) {
+		t.Errorf("Expected exclusive holder to exclude others")
+	}
+	w.Unlock()
+	if !l.TryAcquire(5) {
+		t.Errorf("Expected mutex to be unlocked")
+	}
+	l.Release(5)
+}
@@ -0,0 +1,50 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ForEachTx is ForEach where each function returns an undo function after
+// applying a change. If an error is returned, the undo functions of every
+// applied argument are called serially in reverse order of completion before
+// the error is returned. Panics in undo functions are recovered, and joined
+// to the error as a PanicError. A nil undo function is skipped.
+func ForEachTx[I any](qlen int, apply func(I) (undo func(), err error), args []I, opts ...Option) error {
+	return ForEachTxWithContext(context.Background(), qlen, apply, args, opts...)
+}
+
+// ForEachTxWithContext is ForEachTx but with a context. If the context is
+// cancelled, applied arguments are also undone.
+func ForEachTxWithContext[I any](ctx context.Context, qlen int, apply func(I) (undo func(), err error), args []I, opts ...Option) error {
+	var mu sync.Mutex
+	var undos []func() // in order of completion
+	err := ForEachWithContext(ctx, qlen, func(e I) error {
+		undo, err := apply(e)
+		if err != nil {
+			return err
+		}
+		if undo != nil {
+			mu.Lock()
+			undos = append(undos, undo)
+			mu.Unlock()
+		}
+		return nil
+	}, args, opts...)
+	if err == nil {
+		return nil
+	}
+
+	errs := []error{err}
+	for i := len(undos) - 1; i >= 0; i-- {
+		undo := undos[i]
+		if perr := protect(func() error { undo(); return nil }); perr != nil {
+			errs = append(errs, perr)
+		}
+	}
+	if len(errs) == 1 {
+		return err
+	}
+	return errors.Join(errs...)
+}
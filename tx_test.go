@@ -0,0 +1,60 @@
+package goroutines
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestForEachTx(t *testing.T) {
+	var mu sync.Mutex
+	applied := make(map[int]bool)
+	var completed, undone []int
+	apply := func(fail int) func(n int) (func(), error) {
+		return func(n int) (func(), error) {
+			time.Sleep(time.Duration(n%3) * time.Millisecond)
+			if n == fail {
+				return nil, testErr
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			applied[n] = true
+			completed = append(completed, n)
+			return func() {
+				mu.Lock()
+				defer mu.Unlock()
+				delete(applied, n)
+				undone = append(undone, n)
+			}, nil
+		}
+	}
+
+	if err := ForEachTx(4, apply(-1), testInts[:10]); err != nil || len(applied) != 10 {
+		t.Fatalf("Expected all applied but received applied=%v error=%v", len(applied), err)
+	}
+
+	applied, completed = make(map[int]bool), nil
+	if err := ForEachTx(4, apply(8), testInts[:10]); err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+	if len(applied) != 0 || len(undone) != len(completed) {
+		t.Fatalf("Expected all applied to be undone but received applied=%v undone=%v", applied, undone)
+	}
+	for i := range undone {
+		if undone[i] != completed[len(completed)-1-i] {
+			t.Fatalf("Expected undo in reverse of completed=%v but received undone=%v", completed, undone)
+		}
+	}
+
+	err := ForEachTx(1, func(n int) (func(), error) {
+		if n == 2 {
+			return nil, testErr
+		}
+		return func() { panic("undo failed") }, nil
+	}, testInts)
+	var perr *PanicError
+	if !errors.Is(err, testErr) || !errors.As(err, &perr) {
+		t.Errorf("Expected error=%v joined with panic but received error=%v", testErr, err)
+	}
+}
@@ -0,0 +1,126 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TimedWaitGroup is sync.WaitGroup with timeouts and context aware waits.
+// The zero value is ready to use.
+type TimedWaitGroup struct {
+	mu   sync.Mutex
+	n    int
+	done chan struct{}
+}
+
+// Add delta, which may be negative, to the counter. If the counter becomes
+// zero all waiters are released, and Add panics if it becomes negative.
+func (wg *TimedWaitGroup) Add(delta int) {
+	wg.mu.Lock()
+	defer wg.mu.Unlock()
+	wg.n += delta
+	if wg.n < 0 {
+		panic("TimedWaitGroup negative counter")
+	}
+	if wg.n == 0 && wg.done != nil {
+		close(wg.done)
+		wg.done = nil
+	}
+}
+
+// Done decrements the counter by one.
+func (wg *TimedWaitGroup) Done() {
+	wg.Add(-1)
+}
+
+// Go calls fn in a new goroutine, adding it to the counter.
+func (wg *TimedWaitGroup) Go(fn func()) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		fn()
+	}()
+}
+
+// wait returns a channel closed when the counter is zero.
+func (wg *TimedWaitGroup) wait() <-chan struct{} {
+	wg.mu.Lock()
+	defer wg.mu.Unlock()
+	if wg.n == 0 {
+		return closedChan
+	}
+	if wg.done == nil {
+		wg.done = make(chan struct{})
+	}
+	return wg.done
+}
+
+// Wait blocks until the counter is zero.
+func (wg *TimedWaitGroup) Wait() {
+	<-wg.wait()
+}
+
+// WaitTimeout returns true if the counter became zero before timeout. A
+// negative timeout waits indefinitely.
+func (wg *TimedWaitGroup) WaitTimeout(timeout time.Duration) bool {
+	done := wg.wait()
+	select {
+	case <-done:
+		return true
+	default:
+	}
+	if timeout < 0 {
+		<-done
+		return true
+	}
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-done:
+		return true
+	case <-t.C:
+		return false
+	}
+}
+
+// WaitWithContext returns an error if the context is cancelled before the
+// counter is zero.
+func (wg *TimedWaitGroup) WaitWithContext(ctx context.Context) error {
+	select {
+	case <-wg.wait():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// WaitTimeout returns true if the sync.WaitGroup completed before timeout. A
+// negative timeout waits indefinitely. When the timeout occurs a goroutine
+// waits on the WaitGroup until it completes, prefer TimedWaitGroup where
+// possible.
+func WaitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	if timeout < 0 {
+		wg.Wait()
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-done:
+		return true
+	case <-t.C:
+		return false
+	}
+}
@@ -0,0 +1,76 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimedWaitGroup(t *testing.T) {
+	var wg TimedWaitGroup
+	if !wg.WaitTimeout(0) {
+		t.Errorf("Expected wait of empty group to succeed")
+	}
+
+	release := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		wg.Go(func() {
+			<-release
+		})
+	}
+	if wg.WaitTimeout(10 * time.Millisecond) {
+		t.Errorf("Expected wait to time out")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := wg.WaitWithContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected error=%v but received error=%v", context.DeadlineExceeded, err)
+	}
+
+	close(release)
+	wg.Wait()
+	if err := wg.WaitWithContext(context.Background()); err != nil {
+		t.Errorf("Expected wait to succeed but received error=%v", err)
+	}
+
+	// reusable after completion
+	wg.Add(1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		wg.Done()
+	}()
+	if !wg.WaitTimeout(time.Second) {
+		t.Errorf("Expected wait to succeed")
+	}
+	wg.Add(1)
+	time.AfterFunc(10*time.Millisecond, wg.Done)
+	if !wg.WaitTimeout(-1) {
+		t.Errorf("Expected negative timeout to wait until done")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic of negative counter")
+		}
+	}()
+	wg.Done()
+}
+
+func TestWaitTimeout(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	if WaitTimeout(&wg, 10*time.Millisecond) {
+		t.Errorf("Expected wait to time out")
+	}
+	wg.Done()
+	if !WaitTimeout(&wg, time.Second) {
+		t.Errorf("Expected wait to succeed")
+	}
+	var wg2 sync.WaitGroup // wg may still be waited on after the timeout
+	wg2.Add(1)
+	time.AfterFunc(10*time.Millisecond, wg2.Done)
+	if !WaitTimeout(&wg2, -1) {
+		t.Errorf("Expected negative timeout to wait until done")
+	}
+}
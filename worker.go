@@ -0,0 +1,205 @@
+package goroutines
+
+import (
+	"context"
+	"sync"
+)
+
+// ForEachWorker is ForEach but function also receives the state of the worker
+// calling it. State is created by init when a worker first needs it, so an
+// error is returned as the error of the function, and released by close once
+// all functions complete. Without init the state is the zero value, and
+// close may be nil.
+func ForEachWorker[S any, I any](qlen int, init func() (S, error), close func(S), fn func(S, I) error, args []I, opts ...Option) error {
+	return ForEachWorkerWithContext(context.Background(), qlen, init, close, fn, args, opts...)
+}
+
+// ForEachWorkerWithContext is ForEachWorker but with a context.
+func ForEachWorkerWithContext[S any, I any](ctx context.Context, qlen int, init func() (S, error), close func(S), fn func(S, I) error, args []I, opts ...Option) error {
+	return forEachWorker(ctx, qlen, nil, init, close, fn, args, newOptions(opts))
+}
+
+// CollectWorker is Collect but function also receives the state of the
+// worker calling it, created by init and released by close as by
+// ForEachWorker.
+func CollectWorker[S any, I any, R any](qlen int, init func() (S, error), close func(S), fn func(S, I) (R, error), args []I, opts ...Option) ([]R, error) {
+	return CollectWorkerWithContext(context.Background(), qlen, init, close, fn, args, opts...)
+}
+
+// CollectWorkerWithContext is CollectWorker but with a context.
+func CollectWorkerWithContext[S any, I any, R any](ctx context.Context, qlen int, init func() (S, error), close func(S), fn func(S, I) (R, error), args []I, opts ...Option) ([]R, error) {
+	return collectWorker(ctx, qlen, nil, init, close, fn, args, newOptions(opts))
+}
+
+// forEachWorker calls fn with the state of each worker, where workers are
+// sharded by the hash of the key of each argument if not nil.
+func forEachWorker[S any, I any](ctx context.Context, qlen int, shard func(I) uint64, init func() (S, error), fin func(S), fn func(S, I) error, args []I, o *options) error {
+	h := &hooks[I, any]{shardHash: shard}
+	ws := newWorkerStates(qlen, init, fin, args, h)
+	defer ws.close()
+	return forEach(ctx, qlen, func(e I) error {
+		s, err := ws.get(e)
+		if err != nil {
+			return err
+		}
+		defer ws.put(s)
+		return fn(s, e)
+	}, sliceSource(args), o, h)
+}
+
+// collectWorker is forEachWorker but collects results.
+func collectWorker[S any, I any, R any](ctx context.Context, qlen int, shard func(I) uint64, init func() (S, error), fin func(S), fn func(S, I) (R, error), args []I, o *options) ([]R, error) {
+	h := &hooks[I, R]{shardHash: shard}
+	ws := newWorkerStates(qlen, init, fin, args, h)
+	defer ws.close()
+	return collect(ctx, qlen, func(e I) (R, error) {
+		s, err := ws.get(e)
+		if err != nil {
+			var r R
+			return r, err
+		}
+		defer ws.put(s)
+		return fn(s, e)
+	}, sliceSource(args), o, h)
+}
+
+// workerStates holds the state of idle workers. Functions are called by at
+// most qlen workers at once, so no more states are created than workers.
+type workerStates[S any, I any] struct {
+	mu     sync.Mutex
+	init   func() (S, error)
+	fin    func(S)
+	idle   []S
+	all    []S
+	route  func(I) int
+	shards []*S // state of each shard when sharded
+}
+
+func newWorkerStates[S any, I any, R any](qlen int, init func() (S, error), fin func(S), args []I, h *hooks[I, R]) *workerStates[S, I] {
+	ws := &workerStates[S, I]{init: init, fin: fin}
+
+	// Workers are sharded as in mapping functions
+	n := qlen
+	if n <= 0 {
+		n = defaultPoolSize
+	}
+	if n > len(args) {
+		n = len(args)
+	}
+	if ws.route = h.route(n); ws.route != nil {
+		ws.shards = make([]*S, n)
+	}
+	return ws
+}
+
+// get the state of an idle worker, or create state for a new worker. When
+// sharded the state of the worker processing the argument is returned, which
+// is never used by another worker.
+func (ws *workerStates[S, I]) get(e I) (S, error) {
+	if ws.route != nil {
+		shard := ws.route(e)
+		if s := ws.shards[shard]; s != nil {
+			return *s, nil
+		}
+		return ws.create(shard)
+	}
+
+	ws.mu.Lock()
+	if n := len(ws.idle); n > 0 {
+		s := ws.idle[n-1]
+		ws.idle = ws.idle[:n-1]
+		ws.mu.Unlock()
+		return s, nil
+	}
+	ws.mu.Unlock()
+	return ws.create(-1)
+}
+
+// create state for a new worker, and the given shard if not negative.
+func (ws *workerStates[S, I]) create(shard int) (S, error) {
+	var s S
+	if ws.init == nil {
+		return s, nil
+	}
+	s, err := ws.init()
+	if err != nil {
+		return s, err
+	}
+	if shard >= 0 {
+		ws.shards[shard] = &s
+	}
+	ws.mu.Lock()
+	ws.all = append(ws.all, s)
+	ws.mu.Unlock()
+	return s, nil
+}
+
+// put the state of a worker which is now idle.
+func (ws *workerStates[S, I]) put(s S) {
+	if ws.route != nil {
+		return // state remains with the shard
+	}
+	ws.mu.Lock()
+	ws.idle = append(ws.idle, s)
+	ws.mu.Unlock()
+}
+
+// close the state of all workers.
+func (ws *workerStates[S, I]) close() {
+	ws.mu.Lock()
+	all := ws.all
+	ws.all, ws.idle = nil, nil
+	ws.mu.Unlock()
+	if ws.fin == nil {
+		return
+	}
+	for _, s := range all {
+		ws.fin(s)
+	}
+}
+
+// InjectSharded is Inject but each worker folds results into its own
+// accumulator created by init, so the reduction function "fni" runs
+// concurrently. Accumulators are then combined serially by merge. Results
+// are folded in no particular order, so the reduction should be commutative.
+//
+// If an error is returned, new arguments will not be processed and execution
+// will return when all goroutines finish.
+func InjectSharded[I any, R any, A any](qlen int, init func() A, fn func(I) (R, error), fni func(A, R) (A, error), merge func(A, A) (A, error), args []I, opts ...Option) (A, error) {
+	return InjectShardedWithContext(context.Background(), qlen, init, fn, fni, merge, args, opts...)
+}
+
+// InjectShardedWithContext is InjectSharded but with a context.
+func InjectShardedWithContext[I any, R any, A any](ctx context.Context, qlen int, init func() A, fn func(I) (R, error), fni func(A, R) (A, error), merge func(A, A) (A, error), args []I, opts ...Option) (A, error) {
+	var mu sync.Mutex
+	var shards []*A
+	err := ForEachWorkerWithContext(ctx, qlen, func() (*A, error) {
+		a := init()
+		mu.Lock()
+		shards = append(shards, &a)
+		mu.Unlock()
+		return &a, nil
+	}, nil, func(a *A, e I) error {
+		r, err := fn(e)
+		if err != nil {
+			return err
+		}
+		*a, err = fni(*a, r)
+		return err
+	}, args, opts...)
+	if err != nil || len(shards) == 0 {
+		var a A
+		if err == nil {
+			a = init()
+		}
+		return a, err
+	}
+
+	a := *shards[0]
+	for _, shard := range shards[1:] {
+		if a, err = merge(a, *shard); err != nil {
+			return a, err
+		}
+	}
+	return a, nil
+}
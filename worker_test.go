@@ -0,0 +1,101 @@
+package goroutines
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+type testWorker struct {
+	busy  atomic.Bool
+	calls int
+}
+
+func TestWorker(t *testing.T) {
+	var created, closed atomic.Int64
+	init := func() (*testWorker, error) {
+		created.Add(1)
+		return &testWorker{}, nil
+	}
+	fin := func(w *testWorker) {
+		closed.Add(1)
+	}
+
+	r, err := CollectWorker(4, init, fin, func(w *testWorker, n int) (int, error) {
+		if !w.busy.CompareAndSwap(false, true) {
+			t.Errorf("Expected worker state to be used by a single worker")
+		}
+		defer w.busy.Store(false)
+		w.calls++
+		return n * 2, nil
+	}, testInts)
+	if err != nil || len(r) != len(testInts) {
+		t.Fatalf("Expected results=%v but received results=%v error=%v", len(testInts), len(r), err)
+	}
+	if n := created.Load(); n < 1 || n > 4 {
+		t.Errorf("Expected between 1 and 4 workers but received workers=%v", n)
+	}
+	if c, n := closed.Load(), created.Load(); c != n {
+		t.Errorf("Expected closed=%v but received closed=%v", n, c)
+	}
+
+	initErr := errors.New("init failed")
+	err = ForEachWorker(4, func() (*testWorker, error) {
+		return nil, initErr
+	}, nil, func(w *testWorker, s string) error {
+		return nil
+	}, testStrings)
+	if err != initErr {
+		t.Errorf("Expected error=%v but received error=%v", initErr, err)
+	}
+
+	if err := ForEachWorker(4, nil, nil, func(n int, s string) error {
+		if n != 0 {
+			t.Errorf("Expected zero state without init but received state=%v", n)
+		}
+		return nil
+	}, testStrings); err != nil {
+		t.Errorf("Expected no error but received error=%v", err)
+	}
+}
+
+func TestInjectSharded(t *testing.T) {
+	count := func(m map[string]int, s string) (map[string]int, error) {
+		m[s]++
+		return m, nil
+	}
+	merge := func(a, b map[string]int) (map[string]int, error) {
+		for k, v := range b {
+			a[k] += v
+		}
+		return a, nil
+	}
+	identity := func(s string) (string, error) {
+		if s == "" {
+			return "", testErr
+		}
+		return s, nil
+	}
+	newMap := func() map[string]int { return make(map[string]int) }
+
+	m, err := InjectSharded(4, newMap, identity, count, merge, testStrings)
+	if err != nil {
+		t.Fatalf("Expected no error but received error=%v", err)
+	}
+	expect, _ := Inject(1, newMap(), identity, count, testStrings)
+	if len(m) != len(expect) {
+		t.Fatalf("Expected counts=%v but received counts=%v", expect, m)
+	}
+	for k, v := range expect {
+		if m[k] != v {
+			t.Errorf("Expected count=%v for key=%v but received count=%v", v, k, m[k])
+		}
+	}
+
+	if m, err := InjectSharded(4, newMap, identity, count, merge, nil); err != nil || m == nil {
+		t.Errorf("Expected initial accumulator but received=%v error=%v", m, err)
+	}
+	if _, err := InjectSharded(4, newMap, identity, count, merge, []string{"a", ""}); err != testErr {
+		t.Errorf("Expected error=%v but received error=%v", testErr, err)
+	}
+}